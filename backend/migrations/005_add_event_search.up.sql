@@ -0,0 +1,8 @@
+ALTER TABLE events ADD COLUMN search_vector TSVECTOR;
+
+UPDATE events SET search_vector =
+    setweight(to_tsvector('english', COALESCE(question, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(category, '')), 'C');
+
+CREATE INDEX idx_events_search ON events USING GIN(search_vector);
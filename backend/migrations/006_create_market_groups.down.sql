@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_events_polymarket_event_id;
+DROP TABLE IF EXISTS market_groups;
@@ -0,0 +1,17 @@
+CREATE TABLE market_groups (
+    id              VARCHAR(100) PRIMARY KEY,
+    slug            VARCHAR(500),
+    title           TEXT NOT NULL,
+    description     TEXT,
+    image_url       TEXT,
+    tags            JSONB NOT NULL DEFAULT '[]',
+    volume_24h      NUMERIC(20,2) NOT NULL DEFAULT 0,
+    market_count    INTEGER NOT NULL DEFAULT 0,
+
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    synced_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_market_groups_volume_24h ON market_groups(volume_24h DESC, id);
+CREATE INDEX idx_events_polymarket_event_id ON events(polymarket_event_id);
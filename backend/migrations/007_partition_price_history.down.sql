@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS price_history_daily;
+DROP TABLE IF EXISTS price_history_hourly;
+
+ALTER TABLE price_history RENAME TO price_history_partitioned;
+ALTER INDEX idx_price_history_event_time RENAME TO idx_price_history_partitioned_event_time;
+
+CREATE TABLE price_history (
+    id              BIGINT PRIMARY KEY DEFAULT nextval('price_history_id_seq'),
+    event_id        VARCHAR(100) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    outcome_label   VARCHAR(100) NOT NULL,
+    price           NUMERIC(10,6) NOT NULL,
+    recorded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER SEQUENCE price_history_id_seq OWNED BY price_history.id;
+
+INSERT INTO price_history (id, event_id, outcome_label, price, recorded_at)
+SELECT id, event_id, outcome_label, price, recorded_at
+  FROM price_history_partitioned;
+
+DROP TABLE price_history_partitioned;
+
+CREATE INDEX idx_price_history_event_time ON price_history(event_id, recorded_at DESC);
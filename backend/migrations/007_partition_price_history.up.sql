@@ -0,0 +1,78 @@
+-- Rebuild price_history as a table range-partitioned by month so expired raw
+-- points can be dropped a partition at a time. Partitions are named
+-- price_history_pYYYYMM with UTC month boundaries; the scraper creates
+-- upcoming ones ahead of time.
+ALTER TABLE price_history RENAME TO price_history_unpartitioned;
+ALTER INDEX idx_price_history_event_time RENAME TO idx_price_history_unpartitioned_event_time;
+
+CREATE TABLE price_history (
+    id              BIGINT NOT NULL DEFAULT nextval('price_history_id_seq'),
+    event_id        VARCHAR(100) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    outcome_label   VARCHAR(100) NOT NULL,
+    price           NUMERIC(10,6) NOT NULL,
+    recorded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, recorded_at)
+) PARTITION BY RANGE (recorded_at);
+
+ALTER SEQUENCE price_history_id_seq OWNED BY price_history.id;
+
+DO $$
+DECLARE
+    month_start TIMESTAMPTZ;
+    last_month  TIMESTAMPTZ := date_trunc('month', NOW() + INTERVAL '1 month', 'UTC');
+BEGIN
+    SELECT COALESCE(date_trunc('month', MIN(recorded_at), 'UTC'), date_trunc('month', NOW(), 'UTC'))
+      INTO month_start
+      FROM price_history_unpartitioned;
+
+    WHILE month_start <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF price_history FOR VALUES FROM (%L) TO (%L)',
+            'price_history_p' || to_char(month_start AT TIME ZONE 'UTC', 'YYYYMM'),
+            month_start,
+            month_start + INTERVAL '1 month'
+        );
+        month_start := month_start + INTERVAL '1 month';
+    END LOOP;
+END $$;
+
+INSERT INTO price_history (id, event_id, outcome_label, price, recorded_at)
+SELECT id, event_id, outcome_label, price, recorded_at
+  FROM price_history_unpartitioned;
+
+DROP TABLE price_history_unpartitioned;
+
+CREATE INDEX idx_price_history_event_time ON price_history(event_id, recorded_at DESC);
+
+-- Rollup tiers. Raw points older than the raw retention window are
+-- aggregated into hourly candles, which are in turn aggregated into daily
+-- candles and kept indefinitely.
+CREATE TABLE price_history_hourly (
+    event_id        VARCHAR(100) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    outcome_label   VARCHAR(100) NOT NULL,
+    bucket_start    TIMESTAMPTZ NOT NULL,
+    open            NUMERIC(10,6) NOT NULL,
+    high            NUMERIC(10,6) NOT NULL,
+    low             NUMERIC(10,6) NOT NULL,
+    close           NUMERIC(10,6) NOT NULL,
+    samples         INTEGER NOT NULL,
+    PRIMARY KEY (event_id, outcome_label, bucket_start)
+);
+
+CREATE INDEX idx_price_history_hourly_event_time ON price_history_hourly(event_id, bucket_start DESC);
+CREATE INDEX idx_price_history_hourly_bucket ON price_history_hourly(bucket_start);
+
+CREATE TABLE price_history_daily (
+    event_id        VARCHAR(100) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    outcome_label   VARCHAR(100) NOT NULL,
+    bucket_start    TIMESTAMPTZ NOT NULL,
+    open            NUMERIC(10,6) NOT NULL,
+    high            NUMERIC(10,6) NOT NULL,
+    low             NUMERIC(10,6) NOT NULL,
+    close           NUMERIC(10,6) NOT NULL,
+    samples         INTEGER NOT NULL,
+    PRIMARY KEY (event_id, outcome_label, bucket_start)
+);
+
+CREATE INDEX idx_price_history_daily_event_time ON price_history_daily(event_id, bucket_start DESC);
+CREATE INDEX idx_price_history_daily_bucket ON price_history_daily(bucket_start);
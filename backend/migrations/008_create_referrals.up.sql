@@ -0,0 +1,25 @@
+ALTER TABLE users ADD COLUMN referral_code VARCHAR(16);
+
+UPDATE users SET referral_code = upper(substr(md5(id::text || random()::text), 1, 8));
+
+ALTER TABLE users
+    ALTER COLUMN referral_code SET NOT NULL,
+    ALTER COLUMN referral_code SET DEFAULT upper(substr(md5(gen_random_uuid()::text), 1, 8));
+
+CREATE UNIQUE INDEX idx_users_referral_code ON users(referral_code);
+
+CREATE TABLE referrals (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    referrer_id     UUID NOT NULL REFERENCES users(id),
+    referee_id      UUID NOT NULL UNIQUE REFERENCES users(id),
+    code            VARCHAR(16) NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+    claim_ip        INET,
+    rewarded_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT referral_not_self CHECK (referrer_id <> referee_id)
+);
+
+CREATE INDEX idx_referrals_referrer ON referrals(referrer_id, created_at DESC);
+CREATE INDEX idx_referrals_pending ON referrals(referee_id) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS name_blocklist;
+
+DROP INDEX IF EXISTS idx_users_handle;
+ALTER TABLE users DROP COLUMN IF EXISTS handle_changed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS handle;
@@ -0,0 +1,29 @@
+ALTER TABLE users ADD COLUMN handle VARCHAR(20);
+ALTER TABLE users ADD COLUMN handle_changed_at TIMESTAMPTZ;
+
+UPDATE users SET handle = 'player_' || substr(md5(id::text), 1, 10);
+
+-- Auto-created users were all named "Player"; give them their handle instead
+-- so the leaderboard can tell them apart.
+UPDATE users SET display_name = handle WHERE display_name = 'Player';
+
+ALTER TABLE users
+    ALTER COLUMN handle SET NOT NULL,
+    ALTER COLUMN handle SET DEFAULT 'player_' || substr(md5(gen_random_uuid()::text), 1, 10);
+
+CREATE UNIQUE INDEX idx_users_handle ON users(lower(handle));
+
+CREATE TABLE name_blocklist (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    term            VARCHAR(50) NOT NULL UNIQUE,
+    reason          VARCHAR(20) NOT NULL DEFAULT 'profanity',
+    created_by      UUID REFERENCES admin_users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO name_blocklist (term, reason) VALUES
+    ('admin', 'impersonation'),
+    ('moderator', 'impersonation'),
+    ('official', 'impersonation'),
+    ('support', 'impersonation'),
+    ('polypredict', 'impersonation');
@@ -0,0 +1,15 @@
+CREATE TABLE api_keys (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID NOT NULL REFERENCES users(id),
+    name            VARCHAR(100) NOT NULL,
+    prefix          VARCHAR(16) NOT NULL,
+    key_hash        CHAR(64) NOT NULL UNIQUE,
+    scopes          TEXT[] NOT NULL,
+    last_used_at    TIMESTAMPTZ,
+    revoked_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT api_key_scopes_valid CHECK (scopes <@ ARRAY['read', 'trade']::TEXT[] AND cardinality(scopes) > 0)
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys(user_id, created_at DESC) WHERE revoked_at IS NULL;
@@ -0,0 +1,13 @@
+-- Enum values cannot be dropped, so the type is rebuilt without 'refunded'.
+-- Refunded bets become cancelled, which also had their stake returned.
+UPDATE bets SET status = 'cancelled' WHERE status::text = 'refunded';
+
+DROP INDEX IF EXISTS idx_bets_pending;
+ALTER TYPE bet_status RENAME TO bet_status_old;
+CREATE TYPE bet_status AS ENUM ('pending', 'won', 'lost', 'cancelled');
+ALTER TABLE bets
+    ALTER COLUMN status DROP DEFAULT,
+    ALTER COLUMN status TYPE bet_status USING status::text::bet_status,
+    ALTER COLUMN status SET DEFAULT 'pending';
+DROP TYPE bet_status_old;
+CREATE INDEX idx_bets_pending ON bets(event_id, status) WHERE status = 'pending';
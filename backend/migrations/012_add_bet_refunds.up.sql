@@ -0,0 +1,3 @@
+-- Bets on voided markets have their stake refunded. Adding an enum value
+-- inside a transaction needs Postgres 12 or later.
+ALTER TYPE bet_status ADD VALUE IF NOT EXISTS 'refunded';
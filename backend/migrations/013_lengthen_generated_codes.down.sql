@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ALTER COLUMN referral_code SET DEFAULT upper(substr(md5(gen_random_uuid()::text), 1, 8)),
+    ALTER COLUMN handle SET DEFAULT 'player_' || substr(md5(gen_random_uuid()::text), 1, 10);
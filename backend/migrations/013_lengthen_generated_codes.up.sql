@@ -0,0 +1,7 @@
+-- Generated referral codes and handles had 32 and 40 random bits under a
+-- unique index, enough for collisions to fail sign-ups as users grow. The
+-- API now generates both itself and retries on a collision; these defaults
+-- cover any other inserts. Existing codes and handles are kept.
+ALTER TABLE users
+    ALTER COLUMN referral_code SET DEFAULT upper(substr(md5(gen_random_uuid()::text), 1, 12)),
+    ALTER COLUMN handle SET DEFAULT 'player_' || substr(md5(gen_random_uuid()::text), 1, 12);
@@ -0,0 +1,3 @@
+module github.com/poly-predict/backend/migrations
+
+go 1.24.3
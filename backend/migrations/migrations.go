@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL schema migrations so that they ship
+// inside every binary that can apply them. Files are named
+// NNN_description.up.sql and NNN_description.down.sql.
+package migrations
+
+import "embed"
+
+// FS holds every migration file.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,131 @@
+// Package apperr defines typed errors that carry the HTTP status and stable,
+// machine-readable code they are reported to clients with.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier. Clients may switch on
+// it; messages are for humans and may change.
+type Code string
+
+// Generic codes, one per HTTP status the services respond with.
+const (
+	CodeBadRequest      Code = "bad_request"
+	CodeUnauthorized    Code = "unauthorized"
+	CodeForbidden       Code = "forbidden"
+	CodeNotFound        Code = "not_found"
+	CodeConflict        Code = "conflict"
+	CodeGone            Code = "gone"
+	CodePayloadTooLarge Code = "payload_too_large"
+	CodeValidation      Code = "validation_failed"
+	CodeRateLimited     Code = "rate_limited"
+	CodeInternal        Code = "internal_error"
+)
+
+// Domain codes.
+const (
+	CodeInsufficientBalance  Code = "insufficient_balance"
+	CodeEventNotOpen         Code = "event_not_open"
+	CodeEventAlreadyResolved Code = "event_already_resolved"
+	CodeInvalidOutcome       Code = "invalid_outcome"
+	CodeOddsMoved            Code = "odds_moved"
+	CodeInvalidCursor        Code = "invalid_cursor"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeHandleTaken          Code = "handle_taken"
+	CodeNameBlocked          Code = "name_blocked"
+	CodeRenameCooldown       Code = "rename_cooldown"
+	CodeReferralCodeNotFound Code = "referral_code_not_found"
+	CodeAlreadyReferred      Code = "already_referred"
+	CodeSelfReferral         Code = "self_referral"
+	CodeReferralClaimClosed  Code = "referral_claim_closed"
+	CodeInvalidImage         Code = "invalid_image"
+	CodeLimitReached         Code = "limit_reached"
+)
+
+// Error is an error that is safe to show to clients. Errors compare equal
+// under errors.Is when their codes match, so a sentinel still matches after
+// WithMessage or WithDetails.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details any
+}
+
+// New creates an Error.
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Is reports whether target is an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of e with a different message.
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// WithDetails returns a copy of e carrying structured details for clients,
+// such as the offending field or the value that was expected.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// As returns the *Error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// CodeForStatus returns the generic code for an HTTP status.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Common errors shared by the services.
+var (
+	ErrNotFound            = New(http.StatusNotFound, CodeNotFound, "not found")
+	ErrValidation          = New(http.StatusUnprocessableEntity, CodeValidation, "invalid request")
+	ErrInsufficientBalance = New(http.StatusUnprocessableEntity, CodeInsufficientBalance, "insufficient balance")
+	ErrEventNotOpen        = New(http.StatusUnprocessableEntity, CodeEventNotOpen, "event is not open for betting")
+	ErrEventResolved       = New(http.StatusConflict, CodeEventAlreadyResolved, "event is already resolved")
+	ErrInvalidOutcome      = New(http.StatusUnprocessableEntity, CodeInvalidOutcome, "invalid outcome")
+	ErrOddsMoved           = New(http.StatusConflict, CodeOddsMoved, "odds have moved past the accepted limit")
+	ErrInvalidCursor       = New(http.StatusUnprocessableEntity, CodeInvalidCursor, "invalid cursor")
+)
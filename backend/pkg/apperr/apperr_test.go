@@ -0,0 +1,35 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("place bet: %w", ErrInsufficientBalance.WithDetails(map[string]int64{"balance": 5}))
+
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Error("errors.Is does not match a sentinel carrying details")
+	}
+	if errors.Is(err, ErrEventNotOpen) {
+		t.Error("errors.Is matches a different code")
+	}
+
+	e, ok := As(err)
+	if !ok || e.Status != http.StatusUnprocessableEntity || e.Details == nil {
+		t.Errorf("As = %+v, %v", e, ok)
+	}
+}
+
+func TestWithMessageCopies(t *testing.T) {
+	e := ErrNotFound.WithMessage("user not found")
+
+	if ErrNotFound.Message != "not found" {
+		t.Errorf("WithMessage modified the sentinel: %q", ErrNotFound.Message)
+	}
+	if e.Message != "user not found" || e.Code != CodeNotFound {
+		t.Errorf("WithMessage = %+v", e)
+	}
+}
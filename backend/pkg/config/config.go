@@ -1,49 +1,310 @@
+// Package config loads the typed configuration shared by all services.
+//
+// Values are layered: built-in defaults first, then the YAML file named by
+// CONFIG_FILE (if set), then environment variables, which always win. The
+// result is validated for the service that loads it, and settings that are
+// insecure in production, such as the development admin secret or wildcard
+// CORS, are rejected outright.
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
-// Config holds the application configuration loaded from environment variables.
+// Service names the service loading the configuration; validation only
+// checks the sections that service uses.
+type Service string
+
+const (
+	ServiceAPI     Service = "api"
+	ServiceAdmin   Service = "admin"
+	ServiceScraper Service = "scraper"
+	ServiceSettler Service = "settler"
+
+	// ServiceTools is used by command-line tools such as migrate, which only
+	// need the shared settings.
+	ServiceTools Service = "tools"
+)
+
+// Environments.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// DevAdminJWTSecret is the admin token secret used outside production when
+// none is configured. Production refuses to start with it.
+const DevAdminJWTSecret = "admin-secret-change-me"
+
+// Config holds the configuration of one service.
 type Config struct {
-	DatabaseURL       string
-	RedisURL          string
-	Port              string
-	SupabaseURL       string
-	SupabaseJWTSecret string
-	AdminJWTSecret    string
-	Environment       string
+	// Service is the service that loaded this configuration.
+	Service Service `yaml:"-"`
+
+	Environment string `yaml:"environment"`
+
+	// LogLevel is the minimum zerolog level logged, e.g. "debug" or "warn".
+	// Logs are JSON when Environment is "production" and console text
+	// otherwise.
+	LogLevel string `yaml:"log_level"`
+
+	DatabaseURL string `yaml:"database_url"`
+	RedisURL    string `yaml:"redis_url"`
+
+	// MigrateOnStart applies pending schema migrations before a service
+	// starts serving. Concurrent starts are safe; the first one migrates.
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+
+	// StartingBalance is the number of credits a new user starts with.
+	// Rankings measure profit against it.
+	StartingBalance int64 `yaml:"starting_balance"`
+
+	Prices  PricesConfig  `yaml:"prices"`
+	Storage StorageConfig `yaml:"storage"`
+	Health  HealthConfig  `yaml:"health"`
+	API     APIConfig     `yaml:"api"`
+	Admin   AdminConfig   `yaml:"admin"`
+	Scraper ScraperConfig `yaml:"scraper"`
+	Settler SettlerConfig `yaml:"settler"`
+}
+
+// PricesConfig controls price history retention. RawRetention is how long
+// raw price_history points are kept before only their hourly rollups remain.
+// HourlyRetention is the same for hourly rollups, after which only daily
+// rollups remain.
+type PricesConfig struct {
+	RawRetention    time.Duration `yaml:"raw_retention"`
+	HourlyRetention time.Duration `yaml:"hourly_retention"`
+}
+
+// StorageConfig selects where uploaded files such as avatars are kept:
+// "local" writes under LocalDir, "s3" uses an S3-compatible bucket.
+// PublicURL is the base URL stored objects are served from.
+type StorageConfig struct {
+	Backend   string   `yaml:"backend"`
+	LocalDir  string   `yaml:"local_dir"`
+	PublicURL string   `yaml:"public_url"`
+	S3        S3Config `yaml:"s3"`
+}
+
+// S3Config holds the S3-compatible bucket used by the "s3" storage backend.
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+// HealthConfig sets the readiness thresholds. SyncStaleAfter is how long
+// after the last market sync the scraper is reported as stalled.
+// SettlementStaleAfter is the same for the settler and for resolved events
+// still waiting to be settled.
+type HealthConfig struct {
+	SyncStaleAfter       time.Duration `yaml:"sync_stale_after"`
+	SettlementStaleAfter time.Duration `yaml:"settlement_stale_after"`
+}
+
+// APIConfig configures the user-facing API service. MetricsPort serves
+// metrics on a listener of its own, to keep them off the public port.
+type APIConfig struct {
+	Port        string   `yaml:"port"`
+	MetricsPort string   `yaml:"metrics_port"`
+	CORSOrigins []string `yaml:"cors_origins"`
+
+	SupabaseURL       string `yaml:"supabase_url"`
+	SupabaseJWTSecret string `yaml:"supabase_jwt_secret"`
+
+	// RateLimitPerMinute is the request budget of each signed-in user or,
+	// for anonymous requests, each client address. APIKeyRateLimitPerMinute
+	// is the separate budget of each personal API key.
+	RateLimitPerMinute       int `yaml:"rate_limit_per_minute"`
+	APIKeyRateLimitPerMinute int `yaml:"api_key_rate_limit_per_minute"`
 }
 
-// Load reads configuration from a .env file (if present) and environment variables.
-func Load() (*Config, error) {
+// AdminConfig configures the admin API service. MetricsPort serves metrics
+// on a listener of its own, to keep them off the public port.
+type AdminConfig struct {
+	Port        string   `yaml:"port"`
+	MetricsPort string   `yaml:"metrics_port"`
+	CORSOrigins []string `yaml:"cors_origins"`
+	JWTSecret   string   `yaml:"jwt_secret"`
+}
+
+// ScraperConfig configures the Polymarket scraper. MetricsPort serves
+// metrics and health probes, since the scraper has no HTTP server of its own.
+// CLOBConcurrency and CLOBRateLimit (requests a second, 0 for no limit) bound
+// the midpoint requests a sync makes; CLOBBatchSize is the number of tokens
+// per batch request, or 0 to fetch them one at a time. Failed requests are
+// tried up to HTTPRetries times in all with backoff between RetryBaseDelay
+// and RetryMaxDelay, and a markets page that still fails is resumed from up
+// to PageResumes times. CircuitThreshold consecutive failures to a host
+// (0 to disable) stop requests to it for CircuitCooldown.
+type ScraperConfig struct {
+	MetricsPort       string        `yaml:"metrics_port"`
+	SyncInterval      time.Duration `yaml:"sync_interval"`
+	RetentionInterval time.Duration `yaml:"retention_interval"`
+	GammaURL          string        `yaml:"gamma_url"`
+	CLOBURL           string        `yaml:"clob_url"`
+	HTTPTimeout       time.Duration `yaml:"http_timeout"`
+	CLOBConcurrency   int           `yaml:"clob_concurrency"`
+	CLOBRateLimit     int           `yaml:"clob_rate_limit"`
+	CLOBBatchSize     int           `yaml:"clob_batch_size"`
+	HTTPRetries       int           `yaml:"http_retries"`
+	RetryBaseDelay    time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay     time.Duration `yaml:"retry_max_delay"`
+	PageResumes       int           `yaml:"page_resumes"`
+	CircuitThreshold  int           `yaml:"circuit_threshold"`
+	CircuitCooldown   time.Duration `yaml:"circuit_cooldown"`
+}
+
+// SettlerConfig configures the settler. MetricsPort serves metrics and
+// health probes, since the settler has no HTTP server of its own.
+type SettlerConfig struct {
+	MetricsPort string        `yaml:"metrics_port"`
+	Interval    time.Duration `yaml:"interval"`
+}
+
+// Defaults returns the built-in configuration.
+func Defaults() *Config {
+	return &Config{
+		Environment:     EnvDevelopment,
+		LogLevel:        "info",
+		StartingBalance: 10000,
+		Prices: PricesConfig{
+			RawRetention:    14 * 24 * time.Hour,
+			HourlyRetention: 180 * 24 * time.Hour,
+		},
+		Storage: StorageConfig{
+			Backend:  "local",
+			LocalDir: "./data/media",
+			S3:       S3Config{Region: "us-east-1"},
+		},
+		Health: HealthConfig{
+			SyncStaleAfter:       75 * time.Minute,
+			SettlementStaleAfter: 30 * time.Minute,
+		},
+		API: APIConfig{
+			Port:                     "8080",
+			MetricsPort:              "9092",
+			CORSOrigins:              []string{"*"},
+			RateLimitPerMinute:       300,
+			APIKeyRateLimitPerMinute: 60,
+		},
+		Admin: AdminConfig{
+			Port:        "8081",
+			MetricsPort: "9093",
+			CORSOrigins: []string{"*"},
+		},
+		Scraper: ScraperConfig{
+			MetricsPort:       "9090",
+			SyncInterval:      30 * time.Minute,
+			RetentionInterval: time.Hour,
+			GammaURL:          "https://gamma-api.polymarket.com",
+			CLOBURL:           "https://clob.polymarket.com",
+			HTTPTimeout:       30 * time.Second,
+			CLOBConcurrency:   8,
+			CLOBRateLimit:     20,
+			CLOBBatchSize:     100,
+			HTTPRetries:       4,
+			RetryBaseDelay:    500 * time.Millisecond,
+			RetryMaxDelay:     30 * time.Second,
+			PageResumes:       3,
+			CircuitThreshold:  10,
+			CircuitCooldown:   time.Minute,
+		},
+		Settler: SettlerConfig{
+			MetricsPort: "9091",
+			Interval:    5 * time.Minute,
+		},
+	}
+}
+
+// Load builds the configuration of service from defaults, the optional YAML
+// file named by CONFIG_FILE, a .env file (if present) and environment
+// variables, then validates it.
+func Load(service Service) (*Config, error) {
 	// Load .env file if it exists; ignore error if the file is missing.
 	_ = godotenv.Load()
 
-	cfg := &Config{
-		DatabaseURL:       os.Getenv("DATABASE_URL"),
-		RedisURL:          os.Getenv("REDIS_URL"),
-		Port:              os.Getenv("PORT"),
-		SupabaseURL:       os.Getenv("SUPABASE_URL"),
-		SupabaseJWTSecret: os.Getenv("SUPABASE_JWT_SECRET"),
-		AdminJWTSecret:    os.Getenv("ADMIN_JWT_SECRET"),
-		Environment:       os.Getenv("ENVIRONMENT"),
-	}
+	cfg := Defaults()
+	cfg.Service = service
 
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
 	}
 
-	if cfg.Port == "" {
-		cfg.Port = "8080"
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
 	}
 
-	if cfg.Environment == "" {
-		cfg.Environment = "development"
+	if cfg.Admin.JWTSecret == "" && cfg.Environment != EnvProduction {
+		cfg.Admin.JWTSecret = DevAdminJWTSecret
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
+
+// loadFile overlays the YAML file at path. Unknown keys are errors, so a
+// misspelt setting cannot be silently ignored.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Port is the HTTP port of the loading service, or "" for services without
+// an HTTP server of their own.
+func (c *Config) Port() string {
+	switch c.Service {
+	case ServiceAPI:
+		return c.API.Port
+	case ServiceAdmin:
+		return c.Admin.Port
+	}
+	return ""
+}
+
+// MetricsPort is the port on which the loading service serves metrics, along
+// with the health probes of a worker service without an HTTP server of its
+// own.
+func (c *Config) MetricsPort() string {
+	switch c.Service {
+	case ServiceAPI:
+		return c.API.MetricsPort
+	case ServiceAdmin:
+		return c.Admin.MetricsPort
+	case ServiceScraper:
+		return c.Scraper.MetricsPort
+	case ServiceSettler:
+		return c.Settler.MetricsPort
+	}
+	return ""
+}
+
+// IsProduction reports whether the service runs in production.
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv sets the minimum environment needed to load, isolated from any
+// variables already set for the test process.
+func setenv(t *testing.T, kv map[string]string) {
+	t.Helper()
+	for _, key := range []string{"CONFIG_FILE", "ENVIRONMENT", "PORT", "METRICS_PORT", "ADMIN_JWT_SECRET", "API_CORS_ORIGINS", "ADMIN_CORS_ORIGINS", "SUPABASE_URL", "SUPABASE_JWT_SECRET"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("DATABASE_URL", "postgres://localhost/test")
+	for k, v := range kv {
+		t.Setenv(k, v)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setenv(t, nil)
+
+	cfg, err := Load(ServiceAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port() != "8081" || cfg.StartingBalance != 10000 {
+		t.Errorf("unexpected defaults: port %q, starting balance %d", cfg.Port(), cfg.StartingBalance)
+	}
+	if cfg.Admin.JWTSecret != DevAdminJWTSecret {
+		t.Errorf("expected the development admin secret outside production")
+	}
+}
+
+func TestLoadLayering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `
+starting_balance: 5000
+api:
+  cors_origins: ["https://a.example"]
+scraper:
+  sync_interval: 10m
+  gamma_url: http://localhost:9999
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, map[string]string{"CONFIG_FILE": path, "STARTING_BALANCE": "7000", "PORT": "9000"})
+
+	cfg, err := Load(ServiceScraper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.StartingBalance != 7000 {
+		t.Errorf("environment should override the file: starting balance %d", cfg.StartingBalance)
+	}
+	if cfg.Scraper.SyncInterval != 10*time.Minute || cfg.Scraper.GammaURL != "http://localhost:9999" {
+		t.Errorf("file values not applied: %+v", cfg.Scraper)
+	}
+	if !slices.Equal(cfg.API.CORSOrigins, []string{"https://a.example"}) {
+		t.Errorf("file list should replace the default: %v", cfg.API.CORSOrigins)
+	}
+	if cfg.API.Port != "8080" {
+		t.Errorf("PORT applies only to the loading service, got API port %q", cfg.API.Port)
+	}
+}
+
+func TestLoadRejectsMetricsOnThePublicPort(t *testing.T) {
+	setenv(t, map[string]string{"PORT": "8080", "METRICS_PORT": "8080"})
+
+	if _, err := Load(ServiceAPI); err == nil || !strings.Contains(err.Error(), "api.metrics_port") {
+		t.Errorf("expected a metrics port error, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("scraper:\n  sync_intervall: 10m\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, map[string]string{"CONFIG_FILE": path})
+
+	if _, err := Load(ServiceScraper); err == nil || !strings.Contains(err.Error(), "sync_intervall") {
+		t.Errorf("expected an unknown key error, got %v", err)
+	}
+}
+
+func TestLoadProductionRejectsInsecureSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		service Service
+		env     map[string]string
+		want    []string
+	}{
+		{
+			name:    "admin defaults",
+			service: ServiceAdmin,
+			want:    []string{"ADMIN_JWT_SECRET is required", "admin.cors_origins"},
+		},
+		{
+			name:    "admin development secret",
+			service: ServiceAdmin,
+			env:     map[string]string{"ADMIN_JWT_SECRET": DevAdminJWTSecret, "ADMIN_CORS_ORIGINS": "https://admin.example"},
+			want:    []string{"development default"},
+		},
+		{
+			name:    "api wildcard cors",
+			service: ServiceAPI,
+			env:     map[string]string{"SUPABASE_URL": "https://x.supabase.co", "STORAGE_PUBLIC_URL": "https://cdn.example/media"},
+			want:    []string{"api.cors_origins"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"ENVIRONMENT": EnvProduction}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			setenv(t, env)
+
+			_, err := Load(tt.service)
+			if err == nil {
+				t.Fatal("expected production validation to fail")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadProductionAcceptsSecureSettings(t *testing.T) {
+	setenv(t, map[string]string{
+		"ENVIRONMENT":        EnvProduction,
+		"ADMIN_JWT_SECRET":   strings.Repeat("s", minSecretLen),
+		"ADMIN_CORS_ORIGINS": "https://admin.example, https://ops.example",
+	})
+
+	cfg, err := Load(ServiceAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Admin.CORSOrigins) != 2 {
+		t.Errorf("origins not split: %v", cfg.Admin.CORSOrigins)
+	}
+}
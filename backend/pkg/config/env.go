@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader overlays environment variables onto a Config, collecting parse
+// errors so that all of them are reported at once. Unset variables leave the
+// current value alone.
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) string(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (r *envReader) list(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func (r *envReader) bool(dst *bool, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false", key))
+		return
+	}
+	*dst = b
+}
+
+func (r *envReader) int(dst *int, key string) {
+	var n int64
+	if r.parseInt(&n, key) {
+		*dst = int(n)
+	}
+}
+
+func (r *envReader) int64(dst *int64, key string) {
+	r.parseInt(dst, key)
+}
+
+func (r *envReader) parseInt(dst *int64, key string) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer", key))
+		return false
+	}
+	*dst = n
+	return true
+}
+
+// scaled reads a whole number of units, such as days or minutes.
+func (r *envReader) scaled(dst *time.Duration, key string, unit time.Duration) {
+	var n int64
+	if r.parseInt(&n, key) {
+		*dst = time.Duration(n) * unit
+	}
+}
+
+// duration reads a Go duration string such as "30m" or "1h30m".
+func (r *envReader) duration(dst *time.Duration, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a duration such as 30s or 5m", key))
+		return
+	}
+	*dst = d
+}
+
+// applyEnv overlays environment variables. PORT and METRICS_PORT apply to
+// whichever service is loading, for platforms that assign the port.
+func (c *Config) applyEnv() error {
+	var r envReader
+
+	r.string(&c.Environment, "ENVIRONMENT")
+	r.string(&c.LogLevel, "LOG_LEVEL")
+	r.string(&c.DatabaseURL, "DATABASE_URL")
+	r.string(&c.RedisURL, "REDIS_URL")
+	r.bool(&c.MigrateOnStart, "MIGRATE_ON_START")
+	r.int64(&c.StartingBalance, "STARTING_BALANCE")
+
+	r.scaled(&c.Prices.RawRetention, "PRICE_RAW_RETENTION_DAYS", 24*time.Hour)
+	r.scaled(&c.Prices.HourlyRetention, "PRICE_HOURLY_RETENTION_DAYS", 24*time.Hour)
+
+	r.string(&c.Storage.Backend, "STORAGE_BACKEND")
+	r.string(&c.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	r.string(&c.Storage.PublicURL, "STORAGE_PUBLIC_URL")
+	r.string(&c.Storage.S3.Endpoint, "S3_ENDPOINT")
+	r.string(&c.Storage.S3.Region, "S3_REGION")
+	r.string(&c.Storage.S3.Bucket, "S3_BUCKET")
+	r.string(&c.Storage.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+	r.string(&c.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+
+	r.scaled(&c.Health.SyncStaleAfter, "SYNC_STALE_AFTER_MINUTES", time.Minute)
+	r.scaled(&c.Health.SettlementStaleAfter, "SETTLEMENT_STALE_AFTER_MINUTES", time.Minute)
+
+	r.string(&c.API.Port, "API_PORT")
+	r.string(&c.API.MetricsPort, "API_METRICS_PORT")
+	r.list(&c.API.CORSOrigins, "API_CORS_ORIGINS")
+	r.string(&c.API.SupabaseURL, "SUPABASE_URL")
+	r.string(&c.API.SupabaseJWTSecret, "SUPABASE_JWT_SECRET")
+	r.int(&c.API.RateLimitPerMinute, "RATE_LIMIT_PER_MINUTE")
+	r.int(&c.API.APIKeyRateLimitPerMinute, "API_KEY_RATE_LIMIT_PER_MINUTE")
+
+	r.string(&c.Admin.Port, "ADMIN_PORT")
+	r.string(&c.Admin.MetricsPort, "ADMIN_METRICS_PORT")
+	r.list(&c.Admin.CORSOrigins, "ADMIN_CORS_ORIGINS")
+	r.string(&c.Admin.JWTSecret, "ADMIN_JWT_SECRET")
+
+	r.string(&c.Scraper.MetricsPort, "SCRAPER_METRICS_PORT")
+	r.duration(&c.Scraper.SyncInterval, "SCRAPER_SYNC_INTERVAL")
+	r.duration(&c.Scraper.RetentionInterval, "SCRAPER_RETENTION_INTERVAL")
+	r.string(&c.Scraper.GammaURL, "GAMMA_API_URL")
+	r.string(&c.Scraper.CLOBURL, "CLOB_API_URL")
+	r.duration(&c.Scraper.HTTPTimeout, "POLYMARKET_HTTP_TIMEOUT")
+	r.int(&c.Scraper.CLOBConcurrency, "CLOB_CONCURRENCY")
+	r.int(&c.Scraper.CLOBRateLimit, "CLOB_RATE_LIMIT")
+	r.int(&c.Scraper.CLOBBatchSize, "CLOB_BATCH_SIZE")
+	r.int(&c.Scraper.HTTPRetries, "POLYMARKET_HTTP_RETRIES")
+	r.duration(&c.Scraper.RetryBaseDelay, "POLYMARKET_RETRY_BASE_DELAY")
+	r.duration(&c.Scraper.RetryMaxDelay, "POLYMARKET_RETRY_MAX_DELAY")
+	r.int(&c.Scraper.PageResumes, "POLYMARKET_PAGE_RESUMES")
+	r.int(&c.Scraper.CircuitThreshold, "POLYMARKET_CIRCUIT_THRESHOLD")
+	r.duration(&c.Scraper.CircuitCooldown, "POLYMARKET_CIRCUIT_COOLDOWN")
+
+	r.string(&c.Settler.MetricsPort, "SETTLER_METRICS_PORT")
+	r.duration(&c.Settler.Interval, "SETTLER_INTERVAL")
+
+	switch c.Service {
+	case ServiceAPI:
+		r.string(&c.API.Port, "PORT")
+		r.string(&c.API.MetricsPort, "METRICS_PORT")
+	case ServiceAdmin:
+		r.string(&c.Admin.Port, "PORT")
+		r.string(&c.Admin.MetricsPort, "METRICS_PORT")
+	case ServiceScraper:
+		r.string(&c.Scraper.MetricsPort, "METRICS_PORT")
+	case ServiceSettler:
+		r.string(&c.Settler.MetricsPort, "METRICS_PORT")
+	}
+
+	return errors.Join(r.errs...)
+}
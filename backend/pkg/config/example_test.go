@@ -0,0 +1,13 @@
+package config
+
+import "testing"
+
+// TestExampleFile keeps config.example.yaml in step with the Config struct.
+func TestExampleFile(t *testing.T) {
+	setenv(t, map[string]string{"CONFIG_FILE": "../../config.example.yaml"})
+	for _, s := range []Service{ServiceAPI, ServiceAdmin, ServiceScraper, ServiceSettler} {
+		if _, err := Load(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+}
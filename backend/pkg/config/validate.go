@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// minSecretLen is the shortest signing secret accepted in production.
+const minSecretLen = 32
+
+// Validate checks the sections used by c.Service and reports every problem
+// found, not just the first.
+func (c *Config) Validate() error {
+	var v validator
+
+	switch c.Service {
+	case ServiceAPI, ServiceAdmin, ServiceScraper, ServiceSettler, ServiceTools:
+	default:
+		v.addf("unknown service %q", c.Service)
+	}
+
+	v.check(slices.Contains([]string{EnvDevelopment, EnvStaging, EnvProduction}, c.Environment),
+		"environment must be development, staging or production, got %q", c.Environment)
+	if _, err := zerolog.ParseLevel(c.LogLevel); err != nil || c.LogLevel == "" {
+		v.addf("log_level %q is not a valid level", c.LogLevel)
+	}
+	v.check(c.DatabaseURL != "", "DATABASE_URL is required")
+	v.check(c.StartingBalance > 0, "starting_balance must be positive")
+
+	v.check(c.Prices.RawRetention > 0, "prices.raw_retention must be positive")
+	v.check(c.Prices.HourlyRetention > c.Prices.RawRetention,
+		"prices.hourly_retention must exceed prices.raw_retention")
+	v.check(c.Health.SyncStaleAfter > 0, "health.sync_stale_after must be positive")
+	v.check(c.Health.SettlementStaleAfter > 0, "health.settlement_stale_after must be positive")
+
+	switch c.Service {
+	case ServiceAPI:
+		c.validateStorage(&v)
+		v.port("api.port", c.API.Port)
+		v.port("api.metrics_port", c.API.MetricsPort)
+		v.check(c.API.MetricsPort != c.API.Port, "api.metrics_port must differ from api.port")
+		v.check(len(c.API.CORSOrigins) > 0, "api.cors_origins must not be empty")
+		v.check(c.API.RateLimitPerMinute > 0, "api.rate_limit_per_minute must be positive")
+		v.check(c.API.APIKeyRateLimitPerMinute > 0, "api.api_key_rate_limit_per_minute must be positive")
+		if c.API.SupabaseURL != "" {
+			v.url("api.supabase_url", c.API.SupabaseURL)
+		}
+	case ServiceAdmin:
+		c.validateStorage(&v)
+		v.port("admin.port", c.Admin.Port)
+		v.port("admin.metrics_port", c.Admin.MetricsPort)
+		v.check(c.Admin.MetricsPort != c.Admin.Port, "admin.metrics_port must differ from admin.port")
+		v.check(len(c.Admin.CORSOrigins) > 0, "admin.cors_origins must not be empty")
+	case ServiceScraper:
+		v.port("scraper.metrics_port", c.Scraper.MetricsPort)
+		v.check(c.Scraper.SyncInterval >= time.Minute, "scraper.sync_interval must be at least 1m")
+		v.check(c.Scraper.RetentionInterval >= time.Minute, "scraper.retention_interval must be at least 1m")
+		v.url("scraper.gamma_url", c.Scraper.GammaURL)
+		v.url("scraper.clob_url", c.Scraper.CLOBURL)
+		v.check(c.Scraper.HTTPTimeout > 0, "scraper.http_timeout must be positive")
+		v.check(c.Scraper.CLOBConcurrency >= 1, "scraper.clob_concurrency must be at least 1")
+		v.check(c.Scraper.CLOBRateLimit >= 0, "scraper.clob_rate_limit must not be negative")
+		v.check(c.Scraper.CLOBBatchSize >= 0, "scraper.clob_batch_size must not be negative")
+		v.check(c.Scraper.HTTPRetries >= 1, "scraper.http_retries must be at least 1")
+		v.check(c.Scraper.RetryBaseDelay > 0, "scraper.retry_base_delay must be positive")
+		v.check(c.Scraper.RetryMaxDelay >= c.Scraper.RetryBaseDelay, "scraper.retry_max_delay must not be below scraper.retry_base_delay")
+		v.check(c.Scraper.PageResumes >= 0, "scraper.page_resumes must not be negative")
+		v.check(c.Scraper.CircuitThreshold >= 0, "scraper.circuit_threshold must not be negative")
+		v.check(c.Scraper.CircuitThreshold == 0 || c.Scraper.CircuitCooldown > 0, "scraper.circuit_cooldown must be positive")
+	case ServiceSettler:
+		v.port("settler.metrics_port", c.Settler.MetricsPort)
+		v.check(c.Settler.Interval >= time.Minute, "settler.interval must be at least 1m")
+	}
+
+	if c.IsProduction() {
+		c.validateProduction(&v)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+func (c *Config) validateStorage(v *validator) {
+	switch c.Storage.Backend {
+	case "local":
+		v.check(c.Storage.LocalDir != "", "storage.local_dir is required for local storage")
+	case "s3":
+		s3 := c.Storage.S3
+		v.check(s3.Endpoint != "" && s3.Bucket != "" && s3.AccessKeyID != "" && s3.SecretAccessKey != "",
+			"storage.s3 needs endpoint, bucket, access_key_id and secret_access_key")
+	default:
+		v.addf("storage.backend must be local or s3, got %q", c.Storage.Backend)
+	}
+	if c.Storage.PublicURL != "" {
+		v.url("storage.public_url", c.Storage.PublicURL)
+	}
+}
+
+// validateProduction rejects settings that are only acceptable in
+// development. Both HTTP services allow credentialed CORS requests, so a
+// wildcard origin would let any site act on a signed-in user's behalf.
+func (c *Config) validateProduction(v *validator) {
+	switch c.Service {
+	case ServiceAPI:
+		v.check(!slices.Contains(c.API.CORSOrigins, "*"),
+			"api.cors_origins must list explicit origins in production, not *")
+		v.check(c.API.SupabaseURL != "" || c.API.SupabaseJWTSecret != "",
+			"SUPABASE_URL or SUPABASE_JWT_SECRET is required in production")
+		if s := c.API.SupabaseJWTSecret; s != "" {
+			v.check(len(s) >= minSecretLen,
+				"SUPABASE_JWT_SECRET must be at least %d characters in production", minSecretLen)
+		}
+		v.check(c.Storage.Backend != "local" || c.Storage.PublicURL != "",
+			"storage.public_url is required for local storage in production")
+	case ServiceAdmin:
+		v.check(!slices.Contains(c.Admin.CORSOrigins, "*"),
+			"admin.cors_origins must list explicit origins in production, not *")
+		switch s := c.Admin.JWTSecret; {
+		case s == "":
+			v.addf("ADMIN_JWT_SECRET is required in production")
+		case s == DevAdminJWTSecret:
+			v.addf("ADMIN_JWT_SECRET must not be the development default in production")
+		case len(s) < minSecretLen:
+			v.addf("ADMIN_JWT_SECRET must be at least %d characters in production", minSecretLen)
+		}
+	}
+}
+
+// validator collects validation errors.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.addf(format, args...)
+	}
+}
+
+func (v *validator) port(name, port string) {
+	n, err := strconv.Atoi(port)
+	v.check(err == nil && n > 0 && n < 65536, "%s must be a port number, got %q", name, port)
+}
+
+func (v *validator) url(name, raw string) {
+	u, err := url.Parse(raw)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"%s must be an absolute http(s) URL, got %q", name, raw)
+}
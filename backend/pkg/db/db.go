@@ -8,11 +8,13 @@ import (
 )
 
 // NewPool creates a new PostgreSQL connection pool from the given database URL.
+// Failed queries are logged with the logger carried by their context.
 func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
+	config.ConnConfig.Tracer = errorTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
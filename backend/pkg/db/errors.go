@@ -0,0 +1,44 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+// uniqueViolation is the Postgres SQLSTATE for unique_violation.
+const uniqueViolation = "23505"
+
+// invalidValueCodes are the SQLSTATEs Postgres raises when a text parameter
+// cannot be cast to the type of the expression it is compared with.
+var invalidValueCodes = map[string]bool{
+	"22P02": true, // invalid_text_representation
+	"22007": true, // invalid_datetime_format
+	"22008": true, // datetime_field_overflow
+	"22003": true, // numeric_value_out_of_range
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation on
+// the named constraint or index. An empty name matches any constraint.
+func IsUniqueViolation(err error, name string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return false
+	}
+	return name == "" || pgErr.ConstraintName == name
+}
+
+// CursorError returns apperr.ErrInvalidCursor if p continues after a cursor
+// and err is Postgres rejecting one of its values, such as "abc" for a
+// timestamp key. Cursors are opaque but clients can still edit them, so this
+// is reported to the client rather than as an internal error. Any other err
+// is returned unchanged.
+func (p Page) CursorError(err error) error {
+	var pgErr *pgconn.PgError
+	if p.After != nil && errors.As(err, &pgErr) && invalidValueCodes[pgErr.Code] {
+		return apperr.ErrInvalidCursor
+	}
+	return err
+}
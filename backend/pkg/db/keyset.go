@@ -0,0 +1,132 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+// Page selects one page of a list, either by offset (the legacy page/page_size
+// mode) or by keyset (cursor mode).
+type Page struct {
+	Limit  int
+	Offset int
+
+	// Keyset selects cursor mode. After holds the sort-key values of the last
+	// row on the previous page, or nil for the first page.
+	Keyset bool
+	After  []string
+
+	// Sort names the ordering the page was requested for. It is embedded in
+	// cursors so they cannot be replayed against a different ordering.
+	Sort string
+}
+
+// Number returns the 1-based page number in offset mode.
+func (p Page) Number() int {
+	if p.Limit <= 0 {
+		return 1
+	}
+	return p.Offset/p.Limit + 1
+}
+
+// FetchLimit returns the LIMIT to query with. Keyset mode fetches one extra
+// row so the presence of a next page can be detected without a COUNT(*).
+func (p Page) FetchLimit() int {
+	if p.Keyset {
+		return p.Limit + 1
+	}
+	return p.Limit
+}
+
+// SortKey is one expression of a keyset ordering. Expr must never evaluate to
+// NULL; wrap nullable columns in COALESCE.
+type SortKey struct {
+	Expr string
+	Desc bool
+}
+
+// Keyset is a total ordering over a result set. The last key must be unique
+// (usually the primary key) so rows with equal sort values never straddle a
+// page boundary.
+type Keyset []SortKey
+
+// OrderBy renders the ORDER BY clause for the ordering.
+func (k Keyset) OrderBy() string {
+	parts := make([]string, len(k))
+	for i, key := range k {
+		parts[i] = key.Expr + direction(key.Desc)
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Select renders the key expressions cast to text, prefixed with a comma, for
+// appending to a select list. Scanning them back with ScanDest yields the
+// cursor values of a row.
+func (k Keyset) Select() string {
+	var b strings.Builder
+	for _, key := range k {
+		fmt.Fprintf(&b, ", (%s)::text", key.Expr)
+	}
+	return b.String()
+}
+
+// ScanDest returns scan destinations for the columns produced by Select.
+func (k Keyset) ScanDest(values []string) []interface{} {
+	dest := make([]interface{}, len(k))
+	for i := range k {
+		dest[i] = &values[i]
+	}
+	return dest
+}
+
+// After renders a predicate matching rows strictly after values in this
+// ordering. Placeholders are numbered from argIdx and the returned args must
+// be appended to the query arguments in order. Cursors come from clients, so
+// a value count that does not match the ordering is apperr.ErrInvalidCursor.
+func (k Keyset) After(values []string, argIdx int) (string, []interface{}, error) {
+	if len(values) != len(k) {
+		return "", nil, apperr.ErrInvalidCursor
+	}
+
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	// Expand (a, b, c) > (x, y, z) by hand so each key may have its own
+	// direction: a > x OR (a = x AND b > y) OR (a = x AND b = y AND c > z).
+	var branches []string
+	for i, key := range k {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, fmt.Sprintf("%s = $%d", k[j].Expr, argIdx+j))
+		}
+		op := ">"
+		if key.Desc {
+			op = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s $%d", key.Expr, op, argIdx+i))
+		branches = append(branches, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(branches, " OR ") + ")", args, nil
+}
+
+// TrimPage drops the look-ahead row fetched in keyset mode and returns the
+// cursor values for the next page, or nil when there are no further rows.
+// keys must be parallel to items.
+func TrimPage[T any](page Page, items []T, keys [][]string) ([]T, []string) {
+	if !page.Keyset || len(items) <= page.Limit {
+		return items, nil
+	}
+	return items[:page.Limit], keys[page.Limit-1]
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+func TestKeysetAfter(t *testing.T) {
+	keys := Keyset{{Expr: "volume", Desc: true}, {Expr: "id"}}
+
+	got, args, err := keys.After([]string{"12.50", "abc"}, 3)
+	if err != nil {
+		t.Fatalf("After returned error: %v", err)
+	}
+
+	want := "((volume < $3) OR (volume = $3 AND id > $4))"
+	if got != want {
+		t.Errorf("After predicate = %q, want %q", got, want)
+	}
+
+	wantArgs := []interface{}{"12.50", "abc"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("After args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestKeysetAfter_ValueCountMismatch(t *testing.T) {
+	keys := Keyset{{Expr: "created_at", Desc: true}, {Expr: "id", Desc: true}}
+
+	if _, _, err := keys.After([]string{"2024-01-01"}, 1); !errors.Is(err, apperr.ErrInvalidCursor) {
+		t.Errorf("After with too few values: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestPageCursorError(t *testing.T) {
+	badValue := fmt.Errorf("list bets: %w", &pgconn.PgError{Code: "22007"})
+	other := &pgconn.PgError{Code: "42P01"}
+	after := Page{Keyset: true, After: []string{"abc", "1"}}
+	first := Page{Keyset: true}
+
+	tests := []struct {
+		name string
+		page Page
+		err  error
+		want error
+	}{
+		{"bad cursor value", after, badValue, apperr.ErrInvalidCursor},
+		{"other database error", after, other, other},
+		{"first page", first, badValue, badValue},
+		{"nil", after, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.page.CursorError(tt.err); got != tt.want {
+				t.Errorf("CursorError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetOrderBy(t *testing.T) {
+	keys := Keyset{{Expr: "created_at", Desc: true}, {Expr: "id"}}
+
+	want := "ORDER BY created_at DESC, id ASC"
+	if got := keys.OrderBy(); got != want {
+		t.Errorf("OrderBy() = %q, want %q", got, want)
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	items := []int{1, 2, 3}
+	keys := [][]string{{"a"}, {"b"}, {"c"}}
+
+	tests := []struct {
+		name      string
+		page      Page
+		wantItems []int
+		wantNext  []string
+	}{
+		{"keyset with look-ahead row", Page{Limit: 2, Keyset: true}, []int{1, 2}, []string{"b"}},
+		{"keyset last page", Page{Limit: 3, Keyset: true}, []int{1, 2, 3}, nil},
+		{"offset mode untouched", Page{Limit: 2}, []int{1, 2, 3}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotItems, gotNext := TrimPage(tt.page, items, keys)
+			if !reflect.DeepEqual(gotItems, tt.wantItems) {
+				t.Errorf("items = %v, want %v", gotItems, tt.wantItems)
+			}
+			if !reflect.DeepEqual(gotNext, tt.wantNext) {
+				t.Errorf("next = %v, want %v", gotNext, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/poly-predict/backend/pkg/logging"
+)
+
+// errorTracer logs failed queries through the logger carried by the query's
+// context, so a repository error served to a request is logged with that
+// request's ID and caller.
+type errorTracer struct{}
+
+type querySQLKey struct{}
+
+func (errorTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, querySQLKey{}, data.SQL)
+}
+
+func (errorTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if data.Err == nil || errors.Is(data.Err, context.Canceled) {
+		return
+	}
+
+	logger := logging.Ctx(ctx)
+	event := logger.Error()
+
+	// Integrity constraint violations (class 23) are usually expected, such
+	// as a taken handle, and are turned into client errors by the caller.
+	var pgErr *pgconn.PgError
+	if errors.As(data.Err, &pgErr) {
+		if strings.HasPrefix(pgErr.Code, "23") {
+			event = logger.Warn()
+		}
+		event = event.Str("sqlstate", pgErr.Code).Str("constraint", pgErr.ConstraintName)
+	}
+
+	sql, _ := ctx.Value(querySQLKey{}).(string)
+	event.Err(data.Err).Str("sql", compactSQL(sql)).Msg("query failed")
+}
+
+// compactSQL collapses the whitespace of a query so it logs on one line.
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
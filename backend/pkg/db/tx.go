@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Transactor runs functions inside a database transaction. Services depend on
+// it rather than on a pool so their logic can be tested without a database.
+type Transactor interface {
+	// InTx runs fn in a transaction, committing it if fn returns nil and
+	// rolling it back otherwise.
+	InTx(ctx context.Context, fn func(tx pgx.Tx) error) error
+}
+
+type poolTransactor struct {
+	pool *pgxpool.Pool
+}
+
+// NewTransactor returns a Transactor that begins transactions on pool.
+func NewTransactor(pool *pgxpool.Pool) Transactor {
+	return poolTransactor{pool: pool}
+}
+
+// InTx implements Transactor. Errors returned by fn are passed through
+// unwrapped so callers can match them.
+func (t poolTransactor) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
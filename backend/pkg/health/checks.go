@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Database checks that the database answers a ping.
+func Database(pool *pgxpool.Pool) Check {
+	return Check{
+		Name:     "database",
+		Critical: true,
+		Run: func(ctx context.Context) (map[string]any, error) {
+			start := time.Now()
+			if err := pool.Ping(ctx); err != nil {
+				return nil, fmt.Errorf("ping database: %w", err)
+			}
+			return map[string]any{"latency_ms": time.Since(start).Milliseconds()}, nil
+		},
+	}
+}
+
+// PoolSaturation checks that fewer than threshold (a fraction between 0 and
+// 1) of the pool's connections are in use.
+func PoolSaturation(pool *pgxpool.Pool, threshold float64) Check {
+	return Check{
+		Name:     "database_pool",
+		Critical: true,
+		Run: func(context.Context) (map[string]any, error) {
+			s := pool.Stat()
+			used := float64(s.AcquiredConns()) / float64(s.MaxConns())
+			details := map[string]any{
+				"acquired": s.AcquiredConns(),
+				"idle":     s.IdleConns(),
+				"max":      s.MaxConns(),
+			}
+			if used >= threshold {
+				return details, fmt.Errorf("%.0f%% of connections in use", used*100)
+			}
+			return details, nil
+		},
+	}
+}
+
+// SyncFreshness checks that some event was synced from Polymarket within
+// maxAge, which catches a scraper that has stopped without crashing.
+func SyncFreshness(pool *pgxpool.Pool, maxAge time.Duration, critical bool) Check {
+	return Check{
+		Name:     "sync_freshness",
+		Critical: critical,
+		Run: func(ctx context.Context) (map[string]any, error) {
+			var last *time.Time
+			if err := pool.QueryRow(ctx, `SELECT MAX(synced_at) FROM events`).Scan(&last); err != nil {
+				return nil, fmt.Errorf("query last sync: %w", err)
+			}
+			if last == nil {
+				return nil, errors.New("no events have been synced")
+			}
+
+			age := time.Since(*last)
+			details := map[string]any{"last_synced_at": *last, "age_seconds": int64(age.Seconds())}
+			if age > maxAge {
+				return details, fmt.Errorf("last sync was %s ago, limit %s", age.Round(time.Second), maxAge)
+			}
+			return details, nil
+		},
+	}
+}
+
+// SettlementBacklog checks that no resolved event has waited longer than
+// maxAge to be settled.
+func SettlementBacklog(pool *pgxpool.Pool, maxAge time.Duration, critical bool) Check {
+	return Check{
+		Name:     "settlement_backlog",
+		Critical: critical,
+		Run: func(ctx context.Context) (map[string]any, error) {
+			var (
+				pending int64
+				oldest  *time.Time
+			)
+			err := pool.QueryRow(ctx, `
+				SELECT COUNT(*), MIN(COALESCE(e.resolved_at, e.updated_at))
+				FROM events e
+				WHERE e.status = 'resolved'
+				  AND e.resolved_outcome IS NOT NULL
+				  AND NOT EXISTS (SELECT 1 FROM settlements s WHERE s.event_id = e.id)`,
+			).Scan(&pending, &oldest)
+			if err != nil {
+				return nil, fmt.Errorf("query settlement backlog: %w", err)
+			}
+
+			details := map[string]any{"pending": pending}
+			if oldest == nil {
+				return details, nil
+			}
+
+			age := time.Since(*oldest)
+			details["oldest_resolved_at"] = *oldest
+			details["age_seconds"] = int64(age.Seconds())
+			if age > maxAge {
+				return details, fmt.Errorf("oldest unsettled event resolved %s ago, limit %s", age.Round(time.Second), maxAge)
+			}
+			return details, nil
+		},
+	}
+}
+
+// RunTracker records the outcome of a periodic job's most recent run. The
+// zero value is ready to use.
+type RunTracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	finished time.Time
+	err      error
+}
+
+// Record records a run that started at started and has just finished with
+// err.
+func (t *RunTracker) Record(started time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started, t.finished, t.err = started, time.Now(), err
+}
+
+// LastRun checks that the job tracked by t has run within maxAge and that
+// its most recent run succeeded.
+func LastRun(t *RunTracker, maxAge time.Duration) Check {
+	return Check{
+		Name:     "last_run",
+		Critical: true,
+		Run: func(context.Context) (map[string]any, error) {
+			t.mu.Lock()
+			started, finished, runErr := t.started, t.finished, t.err
+			t.mu.Unlock()
+
+			if finished.IsZero() {
+				return nil, errors.New("no run has finished yet")
+			}
+
+			details := map[string]any{
+				"started_at":       started,
+				"finished_at":      finished,
+				"duration_seconds": finished.Sub(started).Seconds(),
+				"outcome":          "ok",
+			}
+			if runErr != nil {
+				details["outcome"] = "error"
+				return details, fmt.Errorf("last run failed: %w", runErr)
+			}
+			if age := time.Since(finished); age > maxAge {
+				return details, fmt.Errorf("last run finished %s ago, limit %s", age.Round(time.Second), maxAge)
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,120 @@
+// Package health implements the liveness and readiness endpoints shared by
+// all services. Liveness only says the process is serving; readiness runs a
+// set of checks against the service's real dependencies and data.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Overall readiness states. A failing critical check makes the service
+// unavailable (503); a failing non-critical check only degrades it, so that
+// for example a stalled scraper is reported by the API without taking the
+// API out of rotation.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// checkTimeout bounds each check so a hung dependency cannot hang the probe.
+const checkTimeout = 3 * time.Second
+
+// Check is a single named readiness check. Run returns details worth
+// reporting, such as a timestamp or an age, and an error if the check fails.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) (map[string]any, error)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name     string         `json:"name"`
+	OK       bool           `json:"ok"`
+	Critical bool           `json:"critical"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs a fixed set of checks and serves the report as a readiness
+// endpoint.
+type Checker struct {
+	checks []Check
+}
+
+// NewChecker creates a Checker for the given checks.
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// Run runs all checks concurrently and returns their results in order.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			details, err := check.Run(ctx)
+			results[i] = Result{Name: check.Name, OK: err == nil, Critical: check.Critical, Details: details}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, r := range results {
+		switch {
+		case r.OK:
+		case r.Critical:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// ServeHTTP responds with the report, using 503 when the service is
+// unavailable and 200 otherwise.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// Live returns a liveness handler, which succeeds as long as the process can
+// serve HTTP at all.
+func Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
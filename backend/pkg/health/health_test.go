@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) (map[string]any, error) {
+		return nil, err
+	}}
+}
+
+func TestCheckerStatus(t *testing.T) {
+	failure := errors.New("boom")
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus string
+		wantCode   int
+	}{
+		{"all pass", []Check{check("a", true, nil), check("b", false, nil)}, StatusOK, http.StatusOK},
+		{"non-critical fails", []Check{check("a", true, nil), check("b", false, failure)}, StatusDegraded, http.StatusOK},
+		{"critical fails", []Check{check("a", true, failure), check("b", false, failure)}, StatusUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(tt.checks...)
+			report := c.Run(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.checks) || report.Checks[0].Name != "a" {
+				t.Errorf("checks not reported in order: %+v", report.Checks)
+			}
+
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLastRun(t *testing.T) {
+	var tracker RunTracker
+	c := LastRun(&tracker, time.Hour)
+
+	if _, err := c.Run(context.Background()); err == nil {
+		t.Error("expected failure before any run")
+	}
+
+	tracker.Record(time.Now().Add(-time.Second), nil)
+	details, err := c.Run(context.Background())
+	if err != nil || details["outcome"] != "ok" {
+		t.Errorf("after a successful run: details %v, err %v", details, err)
+	}
+
+	tracker.Record(time.Now(), errors.New("gamma unavailable"))
+	details, err = c.Run(context.Background())
+	if err == nil || details["outcome"] != "error" {
+		t.Errorf("after a failed run: details %v, err %v", details, err)
+	}
+
+	tracker.Record(time.Now().Add(-3*time.Hour), nil)
+	tracker.finished = time.Now().Add(-2 * time.Hour)
+	if _, err := c.Run(context.Background()); err == nil {
+		t.Error("expected failure for a stale run")
+	}
+}
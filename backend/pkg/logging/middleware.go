@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader is the header a request ID is read from and echoed in.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds incoming request IDs so a client cannot bloat every
+// log line of its request.
+const maxRequestIDLen = 128
+
+// RequestID assigns each request an ID, honouring a well-formed incoming
+// X-Request-ID header and generating one otherwise. The ID is echoed in the
+// response header, stored under the "request_id" gin key, and attached with a
+// logger to the request's context so that service and repository code logging
+// through Ctx includes it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// SetField adds key=value to the logger of the request being handled, for
+// example the user_id once the caller has been authenticated.
+func SetField(c *gin.Context, key, value string) {
+	c.Request = c.Request.WithContext(With(c.Request.Context(), key, value))
+}
+
+// AccessLog logs one line per request once it has been handled, through the
+// request's logger so that it carries the request ID and caller. Server
+// errors are logged at error level and client errors at warn.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		logger := Ctx(c.Request.Context())
+
+		var event *zerolog.Event
+		switch {
+		case status >= 500:
+			event = logger.Error()
+		case status >= 400:
+			event = logger.Warn()
+		default:
+			event = logger.Info()
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		if len(c.Errors) > 0 {
+			event = event.Str("errors", c.Errors.String())
+		}
+
+		event.
+			Str("method", c.Request.Method).
+			Str("route", route).
+			Str("path", c.Request.URL.Path).
+			Int("status", status).
+			Int("bytes", c.Writer.Size()).
+			Dur("latency", time.Since(start)).
+			Str("client_ip", c.ClientIP()).
+			Msg("request")
+	}
+}
+
+// validRequestID reports whether an incoming request ID is safe to reuse:
+// non-empty, bounded, and made only of characters that need no escaping.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex ID.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func newTestRouter(buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log.Logger = zerolog.New(buf)
+	zerolog.DefaultContextLogger = &log.Logger
+
+	r := gin.New()
+	r.Use(RequestID(), AccessLog())
+	r.GET("/things/:id", func(c *gin.Context) {
+		SetField(c, "user_id", "u1")
+		Ctx(c.Request.Context()).Info().Msg("handled")
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestRequestIDHonoursIncomingHeader(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTestRouter(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Fatalf("response header = %q, want abc-123", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["request_id"] != "abc-123" || entry["user_id"] != "u1" {
+			t.Errorf("log line missing request fields: %s", line)
+		}
+	}
+
+	var access map[string]any
+	_ = json.Unmarshal([]byte(lines[1]), &access)
+	if access["route"] != "/things/:id" || access["status"] != float64(http.StatusNoContent) {
+		t.Errorf("unexpected access log: %s", lines[1])
+	}
+}
+
+func TestRequestIDReplacesInvalidHeader(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTestRouter(&buf)
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("a", maxRequestIDLen+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		got := w.Header().Get(RequestIDHeader)
+		if got == incoming || len(got) != 32 {
+			t.Errorf("incoming %q: got request ID %q, want a generated one", incoming, got)
+		}
+	}
+}
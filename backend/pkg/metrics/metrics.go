@@ -0,0 +1,73 @@
+// Package metrics exposes Prometheus metrics shared by all services: HTTP
+// request latency, connection pool statistics, and the /metrics endpoint
+// itself. Service-specific metrics live next to the code they measure and
+// register on the same default registry under Namespace.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+// Namespace prefixes every metric name.
+const Namespace = "polypredict"
+
+var httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "HTTP request latency by method, route and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// Middleware records the latency and status of every request. Requests are
+// labelled by their route pattern rather than path, so IDs in URLs do not
+// create new series; requests matching no route share the "unmatched" label.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Serve starts a standalone HTTP listener serving /metrics on addr, for
+// services that have no HTTP server of their own, along with any extra routes
+// such as health checks. The returned server should be shut down on exit.
+func Serve(addr string, extra map[string]http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	for pattern, h := range extra {
+		mux.Handle(pattern, h)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("addr", addr).Msg("starting metrics listener")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("metrics listener failed")
+		}
+	}()
+
+	return srv
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/events/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/events/1", "/events/2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(httpRequestDuration); n != 2 {
+		t.Fatalf("got %d series, want 2 (one per route)", n)
+	}
+	for _, tc := range []struct {
+		route, status string
+		want          uint64
+	}{
+		{"/events/:id", "200", 2},
+		{"unmatched", "404", 1},
+	} {
+		var m dto.Metric
+		h := httpRequestDuration.WithLabelValues(http.MethodGet, tc.route, tc.status).(prometheus.Metric)
+		if err := h.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetHistogram().GetSampleCount(); got != tc.want {
+			t.Errorf("%s %s: got %d observations, want %d", tc.route, tc.status, got, tc.want)
+		}
+	}
+}
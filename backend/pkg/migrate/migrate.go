@@ -0,0 +1,347 @@
+// Package migrate applies the SQL schema migrations embedded by the
+// migrations module. Applied versions are recorded in the schema_migrations
+// table, and every run holds a Postgres advisory lock so that several
+// services starting at once apply each migration exactly once.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/poly-predict/backend/migrations"
+	"github.com/poly-predict/backend/pkg/logging"
+)
+
+// lockKey identifies the advisory lock held while migrating.
+const lockKey int64 = 0x706f6c796d6967 // "polymig"
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change. Down is empty if the migration cannot be
+// rolled back.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied. Missing is set for a
+// version recorded in the database that has no migration file.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// Load reads the migrations in the root of fsys, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 001_description.up.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations against one database.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New creates a Migrator for the migrations in fsys.
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version, or 0 if there are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// To migrates up or down so that exactly the migrations up to and including
+// version are applied, and returns how many migrations were run.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	var n int
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		// Roll back newer migrations first, newest first.
+		for _, v := range appliedDesc(applied) {
+			if v <= version {
+				break
+			}
+			mig, err := m.find(v)
+			if err != nil {
+				return err
+			}
+			if err := m.run(ctx, conn, mig, false); err != nil {
+				return err
+			}
+			n++
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if err := m.run(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Down rolls back the steps most recently applied migrations and returns
+// how many were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	var n int
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, v := range appliedDesc(applied) {
+			if n == steps {
+				break
+			}
+			mig, err := m.find(v)
+			if err != nil {
+				return err
+			}
+			if err := m.run(ctx, conn, mig, false); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it. It is meant for databases whose schema was created
+// before versions were tracked, and returns how many versions were recorded.
+func (m *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	var n int
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if _, err := conn.Exec(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				mig.Version, mig.Name,
+			); err != nil {
+				return fmt.Errorf("record migration %03d: %w", mig.Version, err)
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Status lists every known migration, and any applied version without a
+// file, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(_ *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, mig := range m.migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &at
+			}
+			statuses = append(statuses, s)
+		}
+		for v, at := range applied {
+			if _, err := m.find(v); err != nil {
+				statuses = append(statuses, Status{Version: v, AppliedAt: &at, Missing: true})
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(statuses, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, err
+}
+
+// locked runs fn on a single connection holding the migration lock, with the
+// applied versions read after the lock was taken.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer func() {
+		// The lock belongs to the session, so a connection that could not
+		// release it must not go back to the pool.
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     BIGINT PRIMARY KEY,
+			name        TEXT NOT NULL,
+			applied_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("query applied migrations: %w", err)
+	}
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan applied migration: %w", err)
+		}
+		applied[version] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate applied migrations: %w", err)
+	}
+
+	return fn(conn, applied)
+}
+
+// run applies or rolls back one migration in its own transaction, together
+// with its schema_migrations record.
+func (m *Migrator) run(ctx context.Context, conn *pgxpool.Conn, mig Migration, up bool) error {
+	sql, record, direction := mig.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, "up"
+	if !up {
+		if mig.Down == "" {
+			return fmt.Errorf("migration %03d_%s cannot be rolled back: no down file", mig.Version, mig.Name)
+		}
+		sql, record, direction = mig.Down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`, "down"
+	}
+
+	start := time.Now()
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		// Without arguments the simple protocol is used, which allows the
+		// several statements a migration file usually holds.
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, record, mig.Version, mig.Name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %03d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	logging.Ctx(ctx).Info().
+		Int64("version", mig.Version).
+		Str("name", mig.Name).
+		Str("direction", direction).
+		Dur("duration", time.Since(start)).
+		Msg("applied migration")
+	return nil
+}
+
+func (m *Migrator) find(version int64) (Migration, error) {
+	i, ok := slices.BinarySearchFunc(m.migrations, version, func(mig Migration, v int64) int {
+		return cmp.Compare(mig.Version, v)
+	})
+	if !ok {
+		return Migration{}, fmt.Errorf("applied migration %03d has no migration file", version)
+	}
+	return m.migrations[i], nil
+}
+
+// appliedDesc returns the applied versions, newest first.
+func appliedDesc(applied map[int64]time.Time) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+	return versions
+}
+
+// ApplyEmbedded applies every pending migration shipped with this build.
+// Services call it at startup when MigrateOnStart is set.
+func ApplyEmbedded(ctx context.Context, pool *pgxpool.Pool) error {
+	m, err := New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+	n, err := m.Up(ctx)
+	if err != nil {
+		return err
+	}
+	logging.Ctx(ctx).Info().Int("applied", n).Int64("version", m.Latest()).Msg("database schema is up to date")
+	return nil
+}
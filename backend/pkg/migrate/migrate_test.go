@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/poly-predict/backend/migrations"
+)
+
+func TestLoadSortsAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_add_b.up.sql":        {Data: []byte("b up")},
+		"002_create_a.up.sql":     {Data: []byte("a up")},
+		"002_create_a.down.sql":   {Data: []byte("a down")},
+		"010_add_b.down.sql":      {Data: []byte("b down")},
+		"011_irreversible.up.sql": {Data: []byte("c up")},
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(got))
+	}
+	if got[0].Version != 2 || got[0].Name != "create_a" || got[0].Up != "a up" || got[0].Down != "a down" {
+		t.Errorf("unexpected first migration: %+v", got[0])
+	}
+	if got[1].Version != 10 || got[2].Version != 11 || got[2].Down != "" {
+		t.Errorf("unexpected order or pairing: %+v", got)
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":     {"create_a.up.sql": {}},
+		"down only":    {"001_a.down.sql": {Data: []byte("x")}},
+		"name clash":   {"001_a.up.sql": {Data: []byte("x")}, "001_b.up.sql": {Data: []byte("y")}},
+		"zero version": {"000_a.up.sql": {Data: []byte("x")}},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestLoadEmbedded checks that the shipped migrations parse and pair up.
+func TestLoadEmbedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range got {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration versions have a gap before %03d_%s", m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %03d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
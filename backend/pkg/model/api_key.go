@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// APIKeyScope is a permission granted to a personal API key.
+type APIKeyScope string
+
+const (
+	// APIKeyScopeRead allows reading the owner's profile, bets and
+	// transactions.
+	APIKeyScopeRead APIKeyScope = "read"
+	// APIKeyScopeTrade additionally allows placing bets.
+	APIKeyScopeTrade APIKeyScope = "trade"
+)
+
+// MaxAPIKeysPerUser is the number of active keys a user may hold at once.
+const MaxAPIKeysPerUser = 10
+
+// APIKey is a personal API key. Only a SHA-256 hash of the secret is stored;
+// Prefix is the non-secret start of the key, shown so users can tell keys
+// apart.
+type APIKey struct {
+	ID         string        `json:"id" db:"id"`
+	UserID     string        `json:"user_id" db:"user_id"`
+	Name       string        `json:"name" db:"name"`
+	Prefix     string        `json:"prefix" db:"prefix"`
+	Scopes     []APIKeyScope `json:"scopes" db:"scopes"`
+	LastUsedAt *time.Time    `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
@@ -10,6 +10,7 @@ const (
 	BetStatusWon       BetStatus = "won"
 	BetStatusLost      BetStatus = "lost"
 	BetStatusCancelled BetStatus = "cancelled"
+	BetStatusRefunded  BetStatus = "refunded"
 )
 
 // Bet represents a user's wager on an event outcome.
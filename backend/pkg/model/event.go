@@ -46,3 +46,19 @@ type PriceHistory struct {
 	Price        float64   `json:"price" db:"price"`
 	RecordedAt   time.Time `json:"recorded_at" db:"recorded_at"`
 }
+
+// MarketGroup is a Polymarket event that groups related markets, such as
+// every candidate market under "2028 Presidential Election".
+type MarketGroup struct {
+	ID          string          `json:"id" db:"id"`
+	Slug        *string         `json:"slug" db:"slug"`
+	Title       string          `json:"title" db:"title"`
+	Description *string         `json:"description" db:"description"`
+	ImageURL    *string         `json:"image_url" db:"image_url"`
+	Tags        json.RawMessage `json:"tags" db:"tags"`
+	Volume24h   float64         `json:"volume_24h" db:"volume_24h"`
+	MarketCount int             `json:"market_count" db:"market_count"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	SyncedAt    time.Time       `json:"synced_at" db:"synced_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// NameBlockReason records why a term is on the name blocklist.
+type NameBlockReason string
+
+const (
+	NameBlockProfanity     NameBlockReason = "profanity"
+	NameBlockImpersonation NameBlockReason = "impersonation"
+)
+
+// HandleChangeCooldown is the minimum time between two handle changes. The
+// first change away from the auto-generated handle is not limited.
+const HandleChangeCooldown = 30 * 24 * time.Hour
+
+// BlockedName is a term that may not appear in handles or display names.
+type BlockedName struct {
+	ID        string          `json:"id" db:"id"`
+	Term      string          `json:"term" db:"term"`
+	Reason    NameBlockReason `json:"reason" db:"reason"`
+	CreatedBy *string         `json:"created_by" db:"created_by"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
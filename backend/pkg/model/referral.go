@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// ReferralStatus represents the status of a referral.
+type ReferralStatus string
+
+const (
+	ReferralStatusPending  ReferralStatus = "pending"
+	ReferralStatusRewarded ReferralStatus = "rewarded"
+)
+
+const (
+	// ReferralQualifyingBets is the number of settled bets a referee must
+	// reach before both sides of the referral are paid.
+	ReferralQualifyingBets = 3
+
+	// ReferralBonus is the credit amount paid to each side of a referral.
+	ReferralBonus int64 = 1000
+
+	// ReferralClaimWindow is how long after sign-up a user may still enter a
+	// referral code.
+	ReferralClaimWindow = 7 * 24 * time.Hour
+)
+
+// Referral links a referee to the user whose code they signed up with.
+type Referral struct {
+	ID         string         `json:"id" db:"id"`
+	ReferrerID string         `json:"referrer_id" db:"referrer_id"`
+	RefereeID  string         `json:"referee_id" db:"referee_id"`
+	Code       string         `json:"code" db:"code"`
+	Status     ReferralStatus `json:"status" db:"status"`
+	RewardedAt *time.Time     `json:"rewarded_at" db:"rewarded_at"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
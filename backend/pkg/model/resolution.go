@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Resolutions stored in an event's resolved_outcome in place of a winning
+// outcome label.
+const (
+	// ResolutionVoid cancels the market: every stake is refunded.
+	ResolutionVoid = "void"
+	// ResolutionSplit settles the market at its final outcome prices: a bet
+	// pays its shares, amount / locked_odds, times its outcome's price.
+	ResolutionSplit = "split"
+)
+
+// splitTolerance is how far the prices of a split may sum from 1.
+const splitTolerance = 0.001
+
+// Resolution is how the pending bets of a resolved event settle.
+type Resolution struct {
+	// Outcome is the winning outcome label, ResolutionVoid or
+	// ResolutionSplit.
+	Outcome string
+	// Prices is what a share of each outcome pays in a split, keyed by the
+	// lower-cased outcome label.
+	Prices map[string]float64
+}
+
+// NewResolution returns the resolution of an event resolved as
+// resolvedOutcome. For a split it reads the event's outcomes and outcome
+// prices, stored as JSON string arrays, and fails unless they make a valid
+// split.
+func NewResolution(resolvedOutcome string, outcomes, prices json.RawMessage) (Resolution, error) {
+	r := Resolution{Outcome: resolvedOutcome}
+	if resolvedOutcome != ResolutionSplit {
+		return r, nil
+	}
+
+	var labels, values []string
+	if err := json.Unmarshal(outcomes, &labels); err != nil {
+		return r, fmt.Errorf("decoding outcomes: %w", err)
+	}
+	if err := json.Unmarshal(prices, &values); err != nil {
+		return r, fmt.Errorf("decoding outcome prices: %w", err)
+	}
+
+	var err error
+	r.Prices, err = SplitPrices(labels, values)
+	return r, err
+}
+
+// SplitPrices pairs outcome labels with the prices a split settles them at,
+// keyed by the lower-cased label. Every outcome needs a price between 0 and
+// 1, and the prices must sum to 1.
+func SplitPrices(outcomes, prices []string) (map[string]float64, error) {
+	if len(prices) != len(outcomes) {
+		return nil, fmt.Errorf("%d prices for %d outcomes", len(prices), len(outcomes))
+	}
+
+	byOutcome := make(map[string]float64, len(outcomes))
+	var sum float64
+	for i, raw := range prices {
+		p, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(p >= 0 && p <= 1) {
+			return nil, fmt.Errorf("price %q for %s is not between 0 and 1", raw, outcomes[i])
+		}
+		byOutcome[strings.ToLower(outcomes[i])] = p
+		sum += p
+	}
+	if math.Abs(sum-1) > splitTolerance {
+		return nil, fmt.Errorf("prices sum to %g, not 1", sum)
+	}
+
+	return byOutcome, nil
+}
+
+// Settle returns the status a pending bet settles with and its payout. In a
+// void every bet is refunded its stake. In a split a bet is won if it pays
+// back more than its stake and lost otherwise, keeping what it pays either
+// way. Otherwise bets on the winning outcome, compared case-insensitively as
+// bets store it as the user sent it, win their potential payout; an empty
+// outcome wins nothing.
+func (r Resolution) Settle(b *Bet) (BetStatus, int64) {
+	switch r.Outcome {
+	case ResolutionVoid:
+		return BetStatusRefunded, b.Amount
+	case ResolutionSplit:
+		// Rounded, as the division leaves 100 / 0.3 * 0.3 just short of 100.
+		payout := int64(math.Round(float64(b.Amount) / b.LockedOdds * r.Prices[strings.ToLower(b.Outcome)]))
+		if payout > b.Amount {
+			return BetStatusWon, payout
+		}
+		return BetStatusLost, payout
+	}
+
+	if r.Outcome != "" && strings.EqualFold(b.Outcome, r.Outcome) {
+		return BetStatusWon, b.PotentialPayout
+	}
+	return BetStatusLost, 0
+}
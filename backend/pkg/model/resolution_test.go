@@ -0,0 +1,99 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSplitPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []string
+		prices   []string
+		want     map[string]float64
+	}{
+		{"50/50", []string{"Yes", "No"}, []string{"0.5", "0.5"}, map[string]float64{"yes": 0.5, "no": 0.5}},
+		{"multi-outcome", []string{"Madrid", "Arsenal", "PSG"}, []string{"0.2", "0.3", "0.5"}, map[string]float64{"madrid": 0.2, "arsenal": 0.3, "psg": 0.5}},
+		{"rounded prices", []string{"A", "B", "C"}, []string{"0.3333", "0.3333", "0.3334"}, map[string]float64{"a": 0.3333, "b": 0.3333, "c": 0.3334}},
+		{"missing price", []string{"Yes", "No"}, []string{"1"}, nil},
+		{"not a number", []string{"Yes", "No"}, []string{"half", "0.5"}, nil},
+		{"NaN", []string{"Yes", "No"}, []string{"NaN", "0.5"}, nil},
+		{"out of range", []string{"Yes", "No"}, []string{"1.5", "-0.5"}, nil},
+		{"does not sum to 1", []string{"Yes", "No"}, []string{"0.5", "0.4"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitPrices(tt.outcomes, tt.prices)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("SplitPrices(%v, %v) = %v, want an error", tt.outcomes, tt.prices, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("price of %s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestNewResolution(t *testing.T) {
+	outcomes := json.RawMessage(`["Yes", "No"]`)
+
+	r, err := NewResolution("Yes", outcomes, json.RawMessage(`["1", "0"]`))
+	if err != nil || r.Outcome != "Yes" || r.Prices != nil {
+		t.Errorf("winning outcome: %+v, %v", r, err)
+	}
+	r, err = NewResolution(ResolutionVoid, outcomes, json.RawMessage(`["0", "0"]`))
+	if err != nil || r.Outcome != ResolutionVoid {
+		t.Errorf("void: %+v, %v", r, err)
+	}
+	r, err = NewResolution(ResolutionSplit, outcomes, json.RawMessage(`["0.5", "0.5"]`))
+	if err != nil || r.Prices["yes"] != 0.5 || r.Prices["no"] != 0.5 {
+		t.Errorf("split: %+v, %v", r, err)
+	}
+	if _, err := NewResolution(ResolutionSplit, outcomes, json.RawMessage(`["0.9", "0.5"]`)); err == nil {
+		t.Error("split with prices summing to 1.4 accepted")
+	}
+}
+
+func TestResolutionSettle(t *testing.T) {
+	// 100 credits at 0.4 buys 250 shares.
+	b := &Bet{Outcome: "yes", Amount: 100, LockedOdds: 0.4, PotentialPayout: 250}
+
+	tests := []struct {
+		res        Resolution
+		wantStatus BetStatus
+		wantPayout int64
+	}{
+		{Resolution{Outcome: "Yes"}, BetStatusWon, 250},
+		{Resolution{Outcome: "No"}, BetStatusLost, 0},
+		{Resolution{Outcome: ResolutionVoid}, BetStatusRefunded, 100},
+		{Resolution{Outcome: ResolutionSplit, Prices: map[string]float64{"yes": 0.5, "no": 0.5}}, BetStatusWon, 125},
+		{Resolution{Outcome: ResolutionSplit, Prices: map[string]float64{"yes": 0.2, "no": 0.8}}, BetStatusLost, 50},
+		{Resolution{Outcome: ResolutionSplit, Prices: map[string]float64{"yes": 0.4, "no": 0.6}}, BetStatusLost, 100},
+	}
+
+	for _, tt := range tests {
+		status, payout := tt.res.Settle(b)
+		if status != tt.wantStatus || payout != tt.wantPayout {
+			t.Errorf("%+v: %s paying %d, want %s paying %d", tt.res, status, payout, tt.wantStatus, tt.wantPayout)
+		}
+	}
+
+	// Shares bought at 0.3 and paid at 0.3 return the stake exactly.
+	if _, payout := (Resolution{Outcome: ResolutionSplit, Prices: map[string]float64{"no": 0.3}}).Settle(
+		&Bet{Outcome: "No", Amount: 100, LockedOdds: 0.3},
+	); payout != 100 {
+		t.Errorf("payout %d, want the 100 staked", payout)
+	}
+}
@@ -0,0 +1,161 @@
+// Package moderation validates user-chosen names against the format rules
+// and the admin-managed blocklist.
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+const (
+	// MaxDisplayNameLength is the maximum display name length in characters.
+	MaxDisplayNameLength = 32
+
+	// MinBlockedTermLength is the shortest term the blocklist accepts. Shorter
+	// terms would match inside too many innocent names.
+	MinBlockedTermLength = 3
+)
+
+var (
+	ErrInvalidHandle      = invalid("handle", "handle must be 3-20 characters of letters, digits and underscores, starting with a letter")
+	ErrInvalidDisplayName = invalid("display_name", "display name must be 1-32 characters with no control characters")
+	ErrInvalidTerm        = invalid("term", "blocked term must contain at least 3 letters or digits")
+)
+
+// invalid returns a validation error naming the offending field.
+func invalid(field, message string) *apperr.Error {
+	return apperr.ErrValidation.WithMessage(message).WithDetails(map[string]string{"field": field})
+}
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{2,19}$`)
+
+// ValidateHandle checks a handle's format. Uniqueness is case-insensitive and
+// enforced by the database.
+func ValidateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return ErrInvalidHandle
+	}
+	return nil
+}
+
+// CleanDisplayName trims surrounding space, collapses internal runs of
+// whitespace and checks the result's length and characters.
+func CleanDisplayName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	n := utf8.RuneCountInString(name)
+	if n < 1 || n > MaxDisplayNameLength || !utf8.ValidString(name) {
+		return "", ErrInvalidDisplayName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return "", ErrInvalidDisplayName
+		}
+	}
+
+	return name, nil
+}
+
+// confusables maps look-alike characters to the letter they imitate.
+var confusables = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l',
+}
+
+// Normalize reduces a name to lowercase letters and digits, folding common
+// look-alike substitutions, so "P0ly_Predict" and "polypredict" compare equal.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeTerm normalizes a blocklist term for storage, rejecting terms too
+// short to match safely.
+func NormalizeTerm(term string) (string, error) {
+	term = Normalize(term)
+	if utf8.RuneCountInString(term) < MinBlockedTermLength {
+		return "", ErrInvalidTerm
+	}
+	return term, nil
+}
+
+// Match returns the first blocked term a name spells out, comparing both in
+// normalized form. A term must cover whole words of the name, on its own or
+// run together with its neighbours, so "Site_Admin", "PolyPredict" and
+// "4dm1n" match "admin" or "polypredict" while "badminton_fan" and
+// "supportive" match nothing. Terms are expected to be normalized already.
+func Match(name string, terms []string) (string, bool) {
+	words := splitWords(name)
+	for i, w := range words {
+		words[i] = Normalize(w)
+	}
+
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for i := range words {
+			joined := ""
+			for _, w := range words[i:] {
+				joined += w
+				if joined == term {
+					return term, true
+				}
+				if !strings.HasPrefix(term, joined) {
+					break
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// splitWords splits a name into words at spaces and punctuation, where a
+// lowercase letter is followed by an uppercase one, and between letters and
+// digits. Look-alike symbols such as '@' count as letters, and a run of
+// digits is a word of its own so "admin2024" still contains "admin".
+func splitWords(name string) []string {
+	const (
+		separator = iota
+		letter
+		digit
+	)
+	class := func(r rune) int {
+		switch {
+		case unicode.IsDigit(r):
+			return digit
+		case unicode.IsLetter(r) || confusables[r] != 0:
+			return letter
+		}
+		return separator
+	}
+
+	var words []string
+	start, prevClass, prev := 0, separator, rune(0)
+	for i, r := range name {
+		c := class(r)
+		if c != prevClass || (unicode.IsLower(prev) && unicode.IsUpper(r)) {
+			if prevClass != separator {
+				words = append(words, name[start:i])
+			}
+			start = i
+		}
+		prevClass, prev = c, r
+	}
+	if prevClass != separator {
+		words = append(words, name[start:])
+	}
+	return words
+}
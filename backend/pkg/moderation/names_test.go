@@ -0,0 +1,65 @@
+package moderation
+
+import "testing"
+
+func TestValidateHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		ok     bool
+	}{
+		{"alice", true},
+		{"Bob_99", true},
+		{"ab", false},
+		{"9lives", false},
+		{"has space", false},
+		{"this_handle_is_far_too_long", false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateHandle(tt.handle); (err == nil) != tt.ok {
+			t.Errorf("ValidateHandle(%q) = %v, want ok=%v", tt.handle, err, tt.ok)
+		}
+	}
+}
+
+func TestCleanDisplayName(t *testing.T) {
+	got, err := CleanDisplayName("  Jane   Doe ")
+	if err != nil || got != "Jane Doe" {
+		t.Errorf("CleanDisplayName = %q, %v, want %q", got, err, "Jane Doe")
+	}
+
+	for _, name := range []string{"   ", "bad\u0000name", "zero\u200bwidth"} {
+		if _, err := CleanDisplayName(name); err == nil {
+			t.Errorf("CleanDisplayName(%q) returned nil error", name)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	terms := []string{"admin", "support", "polypredict"}
+
+	tests := []struct {
+		name  string
+		match string
+	}{
+		{"Site_Admin", "admin"},
+		{"SiteAdmin", "admin"},
+		{"admin2024", "admin"},
+		{"P0ly Pr3dict Team", "polypredict"},
+		{"PolyPredict", "polypredict"},
+		{"4dm1n", "admin"},
+		{"@dmin", "admin"},
+		{"alice", ""},
+		{"badminton_fan", ""},
+		{"BadmintonFan", ""},
+		{"supportive", ""},
+		{"polypredictions", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := Match(tt.name, terms)
+		if got != tt.match || ok != (tt.match != "") {
+			t.Errorf("Match(%q) = %q, %v, want %q", tt.name, got, ok, tt.match)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+// FieldError describes one invalid field of a request body.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+func init() {
+	// Report fields by their JSON names rather than Go struct field names.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// BindError responds with HTTP 422 for an error returned by gin's binding.
+// Validation failures are listed per field in details; the raw binding error
+// is never shown since it exposes Go type names.
+func BindError(c *gin.Context, err error) {
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		details := make([]FieldError, len(fields))
+		for i, f := range fields {
+			details[i] = FieldError{Field: f.Field(), Rule: f.Tag(), Param: f.Param()}
+		}
+		Fail(c, apperr.ErrValidation.WithMessage("invalid request body").WithDetails(details))
+		return
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		Fail(c, apperr.ErrValidation.WithMessage("invalid request body").WithDetails([]FieldError{
+			{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()},
+		}))
+		return
+	}
+
+	Fail(c, apperr.New(http.StatusBadRequest, apperr.CodeBadRequest, "request body is not valid JSON"))
+}
@@ -0,0 +1,132 @@
+package response
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or was issued
+// for a different ordering.
+var ErrInvalidCursor = apperr.ErrInvalidCursor
+
+// cursor is the decoded form of an opaque pagination cursor.
+type cursor struct {
+	Sort   string   `json:"s,omitempty"`
+	Values []string `json:"v"`
+}
+
+// cursorPaginatedEnvelope wraps keyset-paginated responses.
+type cursorPaginatedEnvelope struct {
+	Success    bool             `json:"success"`
+	Data       interface{}      `json:"data"`
+	Pagination cursorPagination `json:"pagination"`
+}
+
+// cursorPagination holds keyset pagination metadata.
+type cursorPagination struct {
+	PageSize   int     `json:"page_size"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
+
+// EncodeCursor builds an opaque cursor from the sort-key values of the last
+// row on a page.
+func EncodeCursor(sort string, values []string) string {
+	raw, _ := json.Marshal(cursor{Sort: sort, Values: values})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses an opaque cursor and checks it was issued for sort.
+func DecodeCursor(s, sort string) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(raw, &cur); err != nil || len(cur.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	if cur.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	return cur.Values, nil
+}
+
+// ParsePage reads the page, page_size and cursor query parameters. Passing a
+// cursor parameter, even an empty one for the first page, selects keyset
+// mode; otherwise the page/offset compatibility mode is used. sort names the
+// ordering the caller will apply.
+func ParsePage(c *gin.Context, sort string) (db.Page, error) {
+	pageSize, err := strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		pageSize = defaultPageSize
+	}
+
+	page := db.Page{Limit: pageSize, Sort: sort}
+
+	if raw, ok := c.GetQuery("cursor"); ok {
+		page.Keyset = true
+		if raw != "" {
+			values, err := DecodeCursor(raw, sort)
+			if err != nil {
+				return db.Page{}, err
+			}
+			page.After = values
+		}
+		return page, nil
+	}
+
+	number, err := strconv.Atoi(c.Query("page"))
+	if err != nil || number < 1 {
+		number = 1
+	}
+	page.Offset = (number - 1) * pageSize
+
+	return page, nil
+}
+
+// CursorPaginated responds with HTTP 200, the given data, and keyset
+// pagination metadata. An empty next means there are no further pages.
+func CursorPaginated(c *gin.Context, data interface{}, next string, pageSize int) {
+	meta := cursorPagination{PageSize: pageSize}
+	if next != "" {
+		meta.NextCursor = &next
+		meta.HasMore = true
+	}
+
+	c.JSON(http.StatusOK, cursorPaginatedEnvelope{
+		Success:    true,
+		Data:       data,
+		Pagination: meta,
+	})
+}
+
+// List responds with whichever pagination envelope matches the mode page was
+// parsed in. total is ignored in keyset mode and next in offset mode.
+func List(c *gin.Context, data interface{}, page db.Page, total int64, next []string) {
+	if !page.Keyset {
+		Paginated(c, data, total, page.Number(), page.Limit)
+		return
+	}
+
+	var nextCursor string
+	if next != nil {
+		nextCursor = EncodeCursor(page.Sort, next)
+	}
+	CursorPaginated(c, data, nextCursor, page.Limit)
+}
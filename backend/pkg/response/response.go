@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/logging"
 )
 
 // envelope is the standard JSON response wrapper.
@@ -13,9 +16,12 @@ type envelope struct {
 	Error   *errorBody  `json:"error,omitempty"`
 }
 
-// errorBody carries error details in a response.
+// errorBody carries error details in a response. Code is stable and meant
+// for programs; Message is for humans.
 type errorBody struct {
-	Message string `json:"message"`
+	Code    apperr.Code `json:"code"`
+	Message string      `json:"message"`
+	Details any         `json:"details,omitempty"`
 }
 
 // paginatedEnvelope wraps paginated responses.
@@ -49,11 +55,12 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
-// Error responds with the given HTTP status code and error message.
+// Error responds with the given HTTP status code and error message, using the
+// generic code for the status.
 func Error(c *gin.Context, status int, message string) {
 	c.JSON(status, envelope{
 		Success: false,
-		Error:   &errorBody{Message: message},
+		Error:   &errorBody{Code: apperr.CodeForStatus(status), Message: message},
 	})
 }
 
@@ -61,7 +68,25 @@ func Error(c *gin.Context, status int, message string) {
 func ValidationError(c *gin.Context, message string) {
 	c.JSON(http.StatusUnprocessableEntity, envelope{
 		Success: false,
-		Error:   &errorBody{Message: message},
+		Error:   &errorBody{Code: apperr.CodeValidation, Message: message},
+	})
+}
+
+// Fail responds with err. An *apperr.Error in err's chain is reported with
+// its own status, code, message and details; any other error is logged and
+// reported as a generic internal error, so internal details never reach
+// clients.
+func Fail(c *gin.Context, err error) {
+	e, ok := apperr.As(err)
+	if !ok {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str("method", c.Request.Method).Str("route", c.FullPath()).Msg("request failed")
+		Error(c, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	c.JSON(e.Status, envelope{
+		Success: false,
+		Error:   &errorBody{Code: e.Code, Message: e.Message, Details: e.Details},
 	})
 }
 
@@ -0,0 +1,75 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+func errorResponse(t *testing.T, handler gin.HandlerFunc, body string) (int, errorBody) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	var env struct {
+		Error errorBody `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return w.Code, env.Error
+}
+
+func TestFail(t *testing.T) {
+	status, body := errorResponse(t, func(c *gin.Context) {
+		Fail(c, apperr.ErrInsufficientBalance.WithDetails(map[string]int{"balance": 5}))
+	}, "")
+	if status != http.StatusUnprocessableEntity || body.Code != apperr.CodeInsufficientBalance || body.Details == nil {
+		t.Errorf("Fail(domain error) = %d %+v", status, body)
+	}
+
+	status, body = errorResponse(t, func(c *gin.Context) {
+		Fail(c, errors.New("pq: relation \"bets\" does not exist"))
+	}, "")
+	if status != http.StatusInternalServerError || body.Code != apperr.CodeInternal || strings.Contains(body.Message, "bets") {
+		t.Errorf("Fail(internal error) = %d %+v", status, body)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type request struct {
+		Amount int64 `json:"amount" binding:"required,gt=0"`
+	}
+	bind := func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindError(c, err)
+		}
+	}
+
+	status, body := errorResponse(t, bind, `{"amount": -1}`)
+	if status != http.StatusUnprocessableEntity || body.Code != apperr.CodeValidation {
+		t.Fatalf("BindError = %d %+v", status, body)
+	}
+	details, _ := json.Marshal(body.Details)
+	if want := `[{"field":"amount","param":"0","rule":"gt"}]`; string(details) != want {
+		t.Errorf("details = %s, want %s", details, want)
+	}
+
+	status, body = errorResponse(t, bind, `{"amount":`)
+	if status != http.StatusBadRequest || body.Code != apperr.CodeBadRequest {
+		t.Errorf("BindError(malformed) = %d %+v", status, body)
+	}
+}
@@ -0,0 +1,73 @@
+package settle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// Bet settles a pending bet on the event titled question as status, paying
+// payout as model.Resolution.Settle decided. The stake leaves the user's
+// frozen balance, payout is credited to their balance and the credit is
+// logged with the amount it added, so the ledger reconciles with balances:
+//
+//   - won: a bet_won transaction, a win and a longer streak;
+//   - lost: a bet_lost transaction, which only a split makes non-zero, and
+//     the streak reset;
+//   - refunded: a bet_refund transaction of the stake, and the bet no longer
+//     counts towards the user's total_bets, leaving wins and the streak alone.
+//
+// Call it within the settlement's tx, with the bet row locked.
+func Bet(ctx context.Context, tx pgx.Tx, bet *model.Bet, status model.BetStatus, payout int64, question string) error {
+	var txType, desc, counters string
+	switch status {
+	case model.BetStatusWon:
+		txType, desc = "bet_won", "Won bet on: "+question
+		counters = `total_wins = total_wins + 1,
+		            current_streak = current_streak + 1,
+		            max_streak = GREATEST(max_streak, current_streak + 1),`
+	case model.BetStatusLost:
+		txType, desc = "bet_lost", "Lost bet on: "+question
+		counters = `current_streak = 0,`
+	case model.BetStatusRefunded:
+		txType, desc = "bet_refund", "Refunded bet on voided market: "+question
+		counters = `total_bets = total_bets - 1,`
+	default:
+		return fmt.Errorf("cannot settle a bet as %s", status)
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE bets SET status = $1, payout = $2, settled_at = NOW() WHERE id = $3
+	`, status, payout, bet.ID)
+	if err != nil {
+		return fmt.Errorf("update bet: %w", err)
+	}
+
+	// total_bets was already counted when the bet was placed.
+	var newBalance int64
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET frozen_balance = frozen_balance - $1,
+		    balance = balance + $2,
+		    `+counters+`
+		    updated_at = NOW()
+		WHERE id = $3
+		RETURNING balance
+	`, bet.Amount, payout, bet.UserID).Scan(&newBalance)
+	if err != nil {
+		return fmt.Errorf("update user balance: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, bet.UserID, txType, payout, newBalance, bet.ID, desc)
+	if err != nil {
+		return fmt.Errorf("insert credit_transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package settle holds the ledger writes shared by the settler and the
+// admin force-settle, so both settle an event the same way.
+package settle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// PayReferralBonuses credits both sides of every pending referral whose
+// referee is among userIDs and has reached model.ReferralQualifyingBets
+// settled bets, marking the referral rewarded and logging a referral_bonus
+// credit transaction for each side. Call it within the settlement's tx, after
+// the bets are settled.
+func PayReferralBonuses(ctx context.Context, tx pgx.Tx, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT r.id, r.referrer_id, r.referee_id
+		FROM referrals r
+		WHERE r.status = 'pending' AND r.referee_id = ANY($1)
+		  AND (SELECT COUNT(*) FROM bets b
+		       WHERE b.user_id = r.referee_id AND b.status IN ('won', 'lost')) >= $2
+		FOR UPDATE OF r
+	`, userIDs, model.ReferralQualifyingBets)
+	if err != nil {
+		return fmt.Errorf("query qualifying referrals: %w", err)
+	}
+
+	var referrals []model.Referral
+	for rows.Next() {
+		var r model.Referral
+		if err := rows.Scan(&r.ID, &r.ReferrerID, &r.RefereeID); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan referral row: %w", err)
+		}
+		referrals = append(referrals, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate referral rows: %w", err)
+	}
+
+	for _, r := range referrals {
+		_, err := tx.Exec(ctx, `
+			UPDATE referrals SET status = 'rewarded', rewarded_at = NOW() WHERE id = $1
+		`, r.ID)
+		if err != nil {
+			return fmt.Errorf("update referral: %w", err)
+		}
+
+		credits := []struct {
+			userID string
+			desc   string
+		}{
+			{r.ReferrerID, "Referral bonus - referred a new player"},
+			{r.RefereeID, "Referral bonus - joined with a referral code"},
+		}
+		for _, credit := range credits {
+			var newBalance int64
+			err = tx.QueryRow(ctx, `
+				UPDATE users SET balance = balance + $1, updated_at = NOW()
+				WHERE id = $2
+				RETURNING balance
+			`, model.ReferralBonus, credit.userID).Scan(&newBalance)
+			if err != nil {
+				return fmt.Errorf("credit referral bonus: %w", err)
+			}
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description)
+				VALUES ($1, 'referral_bonus', $2, $3, $4, $5)
+			`, credit.userID, model.ReferralBonus, newBalance, r.ID, credit.desc)
+			if err != nil {
+				return fmt.Errorf("insert credit_transaction: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "fmt"
+
+// AvatarSizes are the square edge lengths, in pixels, avatars are stored at.
+// users.avatar_url references the first.
+var AvatarSizes = []int{256, 128, 64}
+
+// AvatarKey returns the object key of a user's avatar at size. Keys are
+// stable across uploads, so a new avatar replaces the old objects.
+func AvatarKey(userID string, size int) string {
+	return fmt.Sprintf("avatars/%s/%d.jpg", userID, size)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Local stores objects as files under a directory on the local filesystem.
+type Local struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocal creates a Local backend rooted at dir whose files are served from
+// publicURL.
+func NewLocal(dir, publicURL string) *Local {
+	return &Local{dir: dir, publicURL: publicURL}
+}
+
+// Dir returns the directory objects are written under.
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// Put writes data to the file for key. The file is written to a temporary
+// name and renamed so readers never see a partial object.
+func (l *Local) Put(_ context.Context, key string, data []byte, _ string) (string, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("write object: %w", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("rename object: %w", err)
+	}
+
+	return joinURL(l.publicURL, key), nil
+}
+
+// Delete removes the file for key.
+func (l *Local) Delete(_ context.Context, key string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete object: %w", err)
+	}
+	return nil
+}
+
+// path maps key to a file under dir. Cleaning the key as an absolute path
+// first keeps ".." segments from escaping dir.
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
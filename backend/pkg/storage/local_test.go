@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPutDelete(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir, "http://localhost:8080/media/")
+	ctx := context.Background()
+
+	url, err := store.Put(ctx, "avatars/u1/256.jpg", []byte("jpeg"), "image/jpeg")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if want := "http://localhost:8080/media/avatars/u1/256.jpg"; url != want {
+		t.Errorf("Put URL = %q, want %q", url, want)
+	}
+
+	file := filepath.Join(dir, "avatars", "u1", "256.jpg")
+	if data, err := os.ReadFile(file); err != nil || string(data) != "jpeg" {
+		t.Fatalf("stored file = %q, %v", data, err)
+	}
+
+	if err := store.Delete(ctx, "avatars/u1/256.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete")
+	}
+	if err := store.Delete(ctx, "avatars/u1/256.jpg"); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+}
+
+func TestLocalPathStaysInDir(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir, "")
+
+	got, err := store.path("../../etc/passwd")
+	if err != nil {
+		t.Fatalf("path: %v", err)
+	}
+	if want := filepath.Join(dir, "etc", "passwd"); got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+
+	if _, err := store.path(""); err == nil {
+		t.Error("empty key accepted")
+	}
+}
@@ -0,0 +1,55 @@
+// Package storage stores uploaded files behind a backend-agnostic interface.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/poly-predict/backend/pkg/config"
+)
+
+// LocalRoute is the path the API serves the local backend's files under.
+const LocalRoute = "/media"
+
+// Storage puts and deletes objects addressed by slash-separated keys.
+type Storage interface {
+	// Put stores data under key, replacing any existing object, and returns
+	// the public URL it is served from.
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete removes the object under key. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the backend selected by cfg.Storage.Backend. Files stored
+// locally are served by the API, so their default URL uses the API port.
+func New(cfg *config.Config) (Storage, error) {
+	sc := cfg.Storage
+	switch sc.Backend {
+	case "local":
+		publicURL := sc.PublicURL
+		if publicURL == "" {
+			publicURL = "http://localhost:" + cfg.API.Port + LocalRoute
+		}
+		return NewLocal(sc.LocalDir, publicURL), nil
+	case "s3":
+		if sc.S3.Endpoint == "" || sc.S3.Bucket == "" || sc.S3.AccessKeyID == "" || sc.S3.SecretAccessKey == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 storage backend")
+		}
+		return NewS3(S3Options{
+			Endpoint:        sc.S3.Endpoint,
+			Region:          sc.S3.Region,
+			Bucket:          sc.S3.Bucket,
+			AccessKeyID:     sc.S3.AccessKeyID,
+			SecretAccessKey: sc.S3.SecretAccessKey,
+			PublicURL:       sc.PublicURL,
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", sc.Backend)
+}
+
+// joinURL appends key to a base URL.
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
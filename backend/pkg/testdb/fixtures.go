@@ -0,0 +1,156 @@
+package testdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var eventSeq atomic.Int64
+
+// CreateUser inserts a user with the given balance and returns its ID.
+func CreateUser(t testing.TB, pool *pgxpool.Pool, balance int64) string {
+	t.Helper()
+	var id string
+	err := pool.QueryRow(context.Background(), `
+		INSERT INTO users (id, display_name, balance)
+		VALUES (gen_random_uuid(), 'Test User', $1)
+		RETURNING id
+	`, balance).Scan(&id)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return id
+}
+
+// CreateEvent inserts an open Yes/No event with the given outcome prices,
+// which must be two, and returns its ID.
+func CreateEvent(t testing.TB, pool *pgxpool.Pool, prices ...string) string {
+	t.Helper()
+	if len(prices) != 2 {
+		t.Fatalf("create event: want 2 prices, got %d", len(prices))
+	}
+	pricesJSON, _ := json.Marshal(prices)
+
+	id := fmt.Sprintf("0xtest%d", eventSeq.Add(1))
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO events (id, slug, question, category, outcomes, outcome_prices)
+		VALUES ($1, $1, 'Will the test pass?', 'Testing', '["Yes", "No"]', $2)
+	`, id, pricesJSON)
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	return id
+}
+
+// PlaceBet records a pending bet the way the API places one: the stake moves
+// from the user's balance to their frozen balance and a bet_placed credit
+// transaction is logged. It returns the bet ID.
+func PlaceBet(t testing.TB, pool *pgxpool.Pool, userID, eventID, outcome string, amount int64, odds float64) string {
+	t.Helper()
+	var id string
+	err := pgx.BeginFunc(context.Background(), pool, func(tx pgx.Tx) error {
+		ctx := context.Background()
+		var balance int64
+		err := tx.QueryRow(ctx, `
+			UPDATE users
+			SET balance = balance - $1, frozen_balance = frozen_balance + $1, total_bets = total_bets + 1
+			WHERE id = $2
+			RETURNING balance
+		`, amount, userID).Scan(&balance)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO bets (user_id, event_id, outcome, amount, locked_odds, potential_payout)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, userID, eventID, outcome, amount, odds, int64(float64(amount)/odds)).Scan(&id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id)
+			VALUES ($1, 'bet_placed', $2, $3, $4)
+		`, userID, -amount, balance, id)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("place bet: %v", err)
+	}
+	return id
+}
+
+// CheckBalances fails the test unless every user's frozen balance equals the
+// stake of their pending bets, their balance equals the balance recorded by
+// their latest credit transaction, and every later transaction's amount is
+// what it changed the balance by.
+func CheckBalances(t testing.TB, pool *pgxpool.Pool) {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), `
+		SELECT u.id, u.balance, u.frozen_balance,
+		       (SELECT COALESCE(SUM(b.amount), 0) FROM bets b
+		        WHERE b.user_id = u.id AND b.status = 'pending'),
+		       (SELECT ct.balance_after FROM credit_transactions ct
+		        WHERE ct.user_id = u.id ORDER BY ct.created_at DESC, ct.id DESC LIMIT 1)
+		FROM users u
+	`)
+	if err != nil {
+		t.Fatalf("check balances: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var balance, frozen, pending int64
+		var ledger *int64
+		if err := rows.Scan(&id, &balance, &frozen, &pending, &ledger); err != nil {
+			t.Fatalf("check balances: %v", err)
+		}
+		if balance < 0 || frozen < 0 {
+			t.Errorf("user %s: negative balance %d or frozen balance %d", id, balance, frozen)
+		}
+		if frozen != pending {
+			t.Errorf("user %s: frozen balance %d, but pending bets stake %d", id, frozen, pending)
+		}
+		if ledger != nil && *ledger != balance {
+			t.Errorf("user %s: balance %d, but the latest credit transaction recorded %d", id, balance, *ledger)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("check balances: %v", err)
+	}
+
+	// The user row is locked for every ledger write, so IDs follow each
+	// user's balance.
+	rows, err = pool.Query(context.Background(), `
+		SELECT user_id, id, type, amount, balance_after - prev
+		FROM (
+			SELECT user_id, id, type, amount, balance_after,
+			       LAG(balance_after) OVER (PARTITION BY user_id ORDER BY id) AS prev
+			FROM credit_transactions
+		) ct
+		WHERE prev IS NOT NULL AND balance_after - prev <> amount
+	`)
+	if err != nil {
+		t.Fatalf("check ledger: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, txType string
+		var id, amount, change int64
+		if err := rows.Scan(&userID, &id, &txType, &amount, &change); err != nil {
+			t.Fatalf("check ledger: %v", err)
+		}
+		t.Errorf("user %s: %s transaction %d records %d, but changed the balance by %d", userID, txType, id, amount, change)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("check ledger: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package testdb gives integration tests a real, fully migrated Postgres
+// database. Main migrates a template database once per test binary, and New
+// copies it into a fresh database for each test, so tests can run in
+// parallel without seeing each other's rows.
+//
+// The server is the one named by TEST_DATABASE_URL, which must allow
+// creating databases (e.g. the docker compose Postgres). Without it, Main
+// starts a throwaway server with the initdb and pg_ctl found in PG_BIN, on
+// PATH or in the usual install locations, and removes it afterwards. If
+// neither works, tests calling New are skipped.
+package testdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/poly-predict/backend/pkg/migrate"
+)
+
+var (
+	// admin is connected to the server's maintenance database.
+	admin *pgxpool.Pool
+	// template is the migrated database every test database is copied from.
+	template string
+	// unavailable explains why there is no server, if there is none.
+	unavailable string
+
+	// createMu serializes CREATE DATABASE, which fails if the template is
+	// being copied by another session.
+	createMu sync.Mutex
+	created  atomic.Int64
+)
+
+// Main runs the tests of a package that calls New. Use it as the package's
+// TestMain.
+func Main(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	// Keep the output to test results and real problems.
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+
+	srv, err := startServer()
+	if err != nil {
+		unavailable = err.Error()
+		return m.Run()
+	}
+	defer srv.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := setup(ctx, srv.url); err != nil {
+		fmt.Fprintf(os.Stderr, "testdb: %v\n", err)
+		return 1
+	}
+	defer teardown()
+
+	return m.Run()
+}
+
+func setup(ctx context.Context, url string) error {
+	var err error
+	admin, err = pgxpool.New(ctx, url)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", url, err)
+	}
+
+	template = fmt.Sprintf("polypredict_template_%d", os.Getpid())
+	if _, err := admin.Exec(ctx, "DROP DATABASE IF EXISTS "+template); err != nil {
+		return fmt.Errorf("drop stale template: %w", err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+template); err != nil {
+		return fmt.Errorf("create template: %w", err)
+	}
+
+	pool, err := connect(ctx, template)
+	if err != nil {
+		return fmt.Errorf("connect to template: %w", err)
+	}
+	defer pool.Close()
+	if err := migrate.ApplyEmbedded(ctx, pool); err != nil {
+		return fmt.Errorf("migrate template: %w", err)
+	}
+	return nil
+}
+
+func teardown() {
+	if admin == nil {
+		return
+	}
+	admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+template+" WITH (FORCE)")
+	admin.Close()
+}
+
+// New returns a pool connected to a new, fully migrated database that is
+// dropped when the test ends. It skips the test if there is no server.
+func New(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	if admin == nil {
+		if unavailable == "" {
+			t.Fatal("testdb: call testdb.Main from TestMain")
+		}
+		t.Skipf("no Postgres for integration tests: %s", unavailable)
+	}
+
+	ctx := context.Background()
+	name := fmt.Sprintf("polypredict_test_%d_%d", os.Getpid(), created.Add(1))
+	createMu.Lock()
+	_, err := admin.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", name, template))
+	createMu.Unlock()
+	if err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+
+	pool, err := connect(ctx, name)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)"); err != nil {
+			t.Errorf("drop test database: %v", err)
+		}
+	})
+	return pool
+}
+
+// connect opens a pool on another database of the admin pool's server.
+func connect(ctx context.Context, database string) (*pgxpool.Pool, error) {
+	cfg := admin.Config()
+	cfg.ConnConfig.Database = database
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+// server is the Postgres server the tests run against.
+type server struct {
+	url   string
+	dir   string // set when Main started the server
+	pgCtl string
+}
+
+func startServer() (*server, error) {
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		return &server{url: url}, nil
+	}
+
+	bin, err := findBinaries()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "testdb")
+	if err != nil {
+		return nil, err
+	}
+	srv := &server{dir: dir, pgCtl: filepath.Join(bin, "pg_ctl")}
+	data := filepath.Join(dir, "data")
+
+	initdb := exec.Command(filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	// Durability is pointless for a server that is deleted afterwards.
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c synchronous_commit=off -c full_page_writes=off", port, dir)
+	start := exec.Command(srv.pgCtl, "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", opts, "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		log, _ := os.ReadFile(filepath.Join(dir, "postgres.log"))
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %v: %s%s", err, out, log)
+	}
+
+	srv.url = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	return srv, nil
+}
+
+func (s *server) stop() {
+	if s.dir == "" {
+		return
+	}
+	exec.Command(s.pgCtl, "-D", filepath.Join(s.dir, "data"), "-m", "immediate", "stop").Run()
+	os.RemoveAll(s.dir)
+}
+
+// findBinaries returns the directory holding initdb and pg_ctl.
+func findBinaries() (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return dir, nil
+	}
+	if path, err := exec.LookPath("pg_ctl"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	for _, pattern := range []string{
+		"/usr/lib/postgresql/*/bin",
+		"/usr/pgsql-*/bin",
+		"/opt/homebrew/opt/postgresql*/bin",
+		"/usr/local/opt/postgresql*/bin",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		for i := len(matches) - 1; i >= 0; i-- {
+			if _, err := os.Stat(filepath.Join(matches[i], "pg_ctl")); err == nil {
+				return matches[i], nil
+			}
+		}
+	}
+	return "", errors.New("set TEST_DATABASE_URL, or install Postgres so that initdb and pg_ctl are on PATH or in PG_BIN")
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("find a free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
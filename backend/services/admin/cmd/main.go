@@ -10,11 +10,15 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/poly-predict/backend/pkg/config"
 	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/health"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/metrics"
+	"github.com/poly-predict/backend/pkg/migrate"
+	"github.com/poly-predict/backend/pkg/storage"
 	"github.com/poly-predict/backend/services/admin/internal/auth"
 	"github.com/poly-predict/backend/services/admin/internal/handler"
 	"github.com/poly-predict/backend/services/admin/internal/repository"
@@ -22,19 +26,16 @@ import (
 )
 
 func main() {
-	// Configure zerolog.
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
 	// Load configuration.
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.ServiceAdmin)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
 
-	// Default port for admin service is 8081.
-	if os.Getenv("PORT") == "" {
-		cfg.Port = "8081"
+	logging.Setup("admin", cfg.Environment, cfg.LogLevel)
+
+	if cfg.Admin.JWTSecret == config.DevAdminJWTSecret {
+		log.Warn().Msg("ADMIN_JWT_SECRET is not set; using the insecure development secret")
 	}
 
 	// Initialise database pool.
@@ -47,26 +48,37 @@ func main() {
 
 	log.Info().Msg("database connection established")
 
-	// JWT secret.
-	jwtSecret := cfg.AdminJWTSecret
-	if jwtSecret == "" {
-		jwtSecret = "admin-secret-change-me"
+	if cfg.MigrateOnStart {
+		if err := migrate.ApplyEmbedded(ctx, pool); err != nil {
+			log.Fatal().Err(err).Msg("failed to apply database migrations")
+		}
+	}
+	metrics.RegisterPool(pool)
+
+	// Object storage, for removing uploaded avatars.
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialise storage")
 	}
 
 	// Auth.
-	adminAuth := auth.NewAdminAuth(pool, jwtSecret)
+	adminAuth := auth.NewAdminAuth(pool, cfg.Admin.JWTSecret)
 
 	// Repositories.
 	userRepo := repository.NewUserRepository(pool)
 	eventRepo := repository.NewEventRepository(pool)
-	settlementRepo := repository.NewSettlementRepository(pool)
+	settlementRepo := repository.NewSettlementRepository(pool, cfg.StartingBalance)
 	dashboardRepo := repository.NewDashboardRepository(pool)
+	referralRepo := repository.NewReferralRepository(pool)
+	blocklistRepo := repository.NewBlocklistRepository(pool)
 
 	// Services.
-	userSvc := service.NewUserService(userRepo)
+	userSvc := service.NewUserService(userRepo, store)
 	eventSvc := service.NewEventService(eventRepo)
 	settlementSvc := service.NewSettlementService(settlementRepo)
 	dashboardSvc := service.NewDashboardService(dashboardRepo)
+	referralSvc := service.NewReferralService(referralRepo)
+	blocklistSvc := service.NewBlocklistService(blocklistRepo)
 
 	// Handlers.
 	authHandler := handler.NewAuthHandler(adminAuth)
@@ -74,28 +86,41 @@ func main() {
 	eventHandler := handler.NewEventHandler(eventSvc, settlementSvc)
 	settlementHandler := handler.NewSettlementHandler(settlementSvc)
 	dashboardHandler := handler.NewDashboardHandler(dashboardSvc)
+	referralHandler := handler.NewReferralHandler(referralSvc)
+	blocklistHandler := handler.NewBlocklistHandler(blocklistSvc)
 
 	// Router.
-	if cfg.Environment == "production" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
-	router.Use(gin.Recovery())
+	router.Use(logging.RequestID(), logging.AccessLog(), metrics.Middleware(), gin.Recovery())
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
+		AllowOrigins:     cfg.Admin.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", logging.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", logging.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Health check.
+	// Health check, kept for existing probes; prefer /livez and /readyz.
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Liveness and readiness probes. Readiness fails on database problems
+	// and reports stale market data or a settlement backlog as degraded.
+	readiness := health.NewChecker(
+		health.Database(pool),
+		health.PoolSaturation(pool, 0.9),
+		health.SyncFreshness(pool, cfg.Health.SyncStaleAfter, false),
+		health.SettlementBacklog(pool, cfg.Health.SettlementStaleAfter, false),
+	)
+	router.GET("/livez", gin.WrapH(health.Live()))
+	router.GET("/readyz", gin.WrapH(readiness))
+
 	// Public routes.
 	api := router.Group("/api/v1")
 	api.POST("/auth/login", authHandler.Login)
@@ -109,22 +134,35 @@ func main() {
 		protected.GET("/users", userHandler.ListUsers)
 		protected.GET("/users/:id", userHandler.GetUser)
 		protected.PATCH("/users/:id", userHandler.PatchUser)
+		protected.DELETE("/users/:id/avatar", userHandler.ResetAvatar)
+		protected.POST("/users/:id/reset-name", userHandler.ResetName)
 
 		protected.GET("/events", eventHandler.ListEvents)
 		protected.PATCH("/events/:id", eventHandler.PatchEvent)
 		protected.POST("/events/:id/settle", eventHandler.SettleEvent)
 
 		protected.GET("/settlements", settlementHandler.ListSettlements)
+
+		protected.GET("/referrals/report", referralHandler.GetReport)
+
+		protected.GET("/blocklist", blocklistHandler.ListBlocklist)
+		protected.POST("/blocklist", blocklistHandler.CreateBlockedName)
+		protected.DELETE("/blocklist/:id", blocklistHandler.DeleteBlockedName)
 	}
 
+	// Prometheus metrics, on a port of their own to keep them off the public
+	// listener.
+	metricsSrv := metrics.Serve(":"+cfg.MetricsPort(), nil)
+	defer metricsSrv.Close()
+
 	// Start server with graceful shutdown.
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
+		Addr:    ":" + cfg.Port(),
 		Handler: router,
 	}
 
 	go func() {
-		log.Info().Str("port", cfg.Port).Msg("admin service starting")
+		log.Info().Str("port", cfg.Port()).Msg("admin service starting")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("server failed")
 		}
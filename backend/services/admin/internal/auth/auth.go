@@ -12,10 +12,16 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/logging"
 	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/pkg/response"
 )
 
+// ErrInvalidCredentials is returned by Login for an unknown email or a wrong
+// password; the two cases are deliberately indistinguishable.
+var ErrInvalidCredentials = apperr.New(http.StatusUnauthorized, apperr.CodeInvalidCredentials, "invalid email or password")
+
 // AdminAuth provides authentication functionality for admin users.
 type AdminAuth struct {
 	pool      *pgxpool.Pool
@@ -39,11 +45,11 @@ func (a *AdminAuth) Login(ctx context.Context, email, password string) (string,
 		 WHERE email = $1`, email,
 	).Scan(&admin.ID, &admin.Email, &admin.PasswordHash, &admin.Role, &admin.CreatedAt)
 	if err != nil {
-		return "", nil, fmt.Errorf("invalid email or password")
+		return "", nil, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)); err != nil {
-		return "", nil, fmt.Errorf("invalid email or password")
+		return "", nil, ErrInvalidCredentials
 	}
 
 	// Generate JWT.
@@ -112,6 +118,7 @@ func (a *AdminAuth) Middleware() gin.HandlerFunc {
 		}
 
 		c.Set("admin_id", adminID)
+		logging.SetField(c, "admin_id", adminID)
 		c.Next()
 	}
 }
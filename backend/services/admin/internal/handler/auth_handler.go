@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/poly-predict/backend/pkg/response"
@@ -34,7 +32,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	token, admin, err := h.adminAuth.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, err.Error())
+		response.Fail(c, err)
 		return
 	}
 
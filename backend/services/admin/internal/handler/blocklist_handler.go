@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/services/admin/internal/service"
+)
+
+// BlocklistHandler handles admin name blocklist endpoints.
+type BlocklistHandler struct {
+	svc *service.BlocklistService
+}
+
+// NewBlocklistHandler creates a new BlocklistHandler.
+func NewBlocklistHandler(svc *service.BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{svc: svc}
+}
+
+// ListBlocklist returns every blocked term.
+func (h *BlocklistHandler) ListBlocklist(c *gin.Context) {
+	names, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list blocklist")
+		return
+	}
+
+	response.Success(c, names)
+}
+
+type createBlockedNameRequest struct {
+	Term   string                `json:"term"`
+	Reason model.NameBlockReason `json:"reason"`
+}
+
+// CreateBlockedName adds a term to the blocklist. The term is stored in
+// normalized form: lowercase letters and digits with look-alikes folded.
+func (h *BlocklistHandler) CreateBlockedName(c *gin.Context) {
+	var req createBlockedNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "invalid request body")
+		return
+	}
+
+	if req.Reason == "" {
+		req.Reason = model.NameBlockProfanity
+	}
+
+	name, err := h.svc.Add(c.Request.Context(), req.Term, req.Reason, c.GetString("admin_id"))
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	response.Created(c, name)
+}
+
+// DeleteBlockedName removes a term from the blocklist.
+func (h *BlocklistHandler) DeleteBlockedName(c *gin.Context) {
+	removed, err := h.svc.Remove(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to remove blocked name")
+		return
+	}
+
+	if !removed {
+		response.Error(c, http.StatusNotFound, "blocked name not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -25,18 +25,22 @@ func NewEventHandler(eventSvc *service.EventService, settlementSvc *service.Sett
 
 // ListEvents returns a paginated list of events with optional filters.
 func (h *EventHandler) ListEvents(c *gin.Context) {
-	page, pageSize := parsePagination(c)
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
 	status := c.Query("status")
 	category := c.Query("category")
 	search := c.Query("search")
 
-	events, total, err := h.eventSvc.List(c.Request.Context(), status, category, search, page, pageSize)
+	events, total, next, err := h.eventSvc.List(c.Request.Context(), status, category, search, page)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to list events")
+		response.Fail(c, err)
 		return
 	}
 
-	response.Paginated(c, events, total, page, pageSize)
+	response.List(c, events, page, total, next)
 }
 
 type patchEventRequest struct {
@@ -69,10 +73,13 @@ func (h *EventHandler) PatchEvent(c *gin.Context) {
 }
 
 type settleEventRequest struct {
-	Outcome string `json:"outcome" binding:"required"`
+	Outcome string   `json:"outcome" binding:"required"`
+	Prices  []string `json:"prices"`
 }
 
-// SettleEvent force-settles an event with the given outcome.
+// SettleEvent force-settles an event with the given outcome. An outcome of
+// "void" refunds every bet, and "split" pays out at the given prices, one
+// per event outcome.
 func (h *EventHandler) SettleEvent(c *gin.Context) {
 	id := c.Param("id")
 
@@ -82,9 +89,9 @@ func (h *EventHandler) SettleEvent(c *gin.Context) {
 		return
 	}
 
-	settlement, err := h.settlementSvc.ForceSettle(c.Request.Context(), id, req.Outcome)
+	settlement, err := h.settlementSvc.ForceSettle(c.Request.Context(), id, req.Outcome, req.Prices)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+		response.Fail(c, err)
 		return
 	}
 
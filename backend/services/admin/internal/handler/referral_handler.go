@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/services/admin/internal/service"
+)
+
+// ReferralHandler handles admin referral endpoints.
+type ReferralHandler struct {
+	svc *service.ReferralService
+}
+
+// NewReferralHandler creates a new ReferralHandler.
+func NewReferralHandler(svc *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{svc: svc}
+}
+
+// GetReport returns per-referrer referral activity with farming flags.
+// Query params: days (window, default 30, max 365), limit (default 50, max
+// 200) and flagged (only flagged referrers).
+func (h *ReferralHandler) GetReport(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		response.ValidationError(c, "days must be between 1 and 365")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		response.ValidationError(c, "limit must be between 1 and 200")
+		return
+	}
+
+	flagged := c.Query("flagged") == "true"
+
+	report, err := h.svc.Report(c.Request.Context(), time.Duration(days)*24*time.Hour, limit, flagged)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to load referral report")
+		return
+	}
+
+	response.Success(c, report)
+}
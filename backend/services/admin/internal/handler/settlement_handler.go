@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/poly-predict/backend/pkg/response"
@@ -21,13 +19,17 @@ func NewSettlementHandler(svc *service.SettlementService) *SettlementHandler {
 
 // ListSettlements returns a paginated list of settlement records.
 func (h *SettlementHandler) ListSettlements(c *gin.Context) {
-	page, pageSize := parsePagination(c)
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
 
-	settlements, total, err := h.svc.List(c.Request.Context(), page, pageSize)
+	settlements, total, next, err := h.svc.List(c.Request.Context(), page)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to list settlements")
+		response.Fail(c, err)
 		return
 	}
 
-	response.Paginated(c, settlements, total, page, pageSize)
+	response.List(c, settlements, page, total, next)
 }
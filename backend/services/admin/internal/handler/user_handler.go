@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -22,17 +21,22 @@ func NewUserHandler(svc *service.UserService) *UserHandler {
 
 // ListUsers returns a paginated list of users.
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	page, pageSize := parsePagination(c)
 	search := c.Query("search")
 	sortBy := c.Query("sort_by")
 
-	users, total, err := h.svc.List(c.Request.Context(), search, sortBy, page, pageSize)
+	page, err := response.ParsePage(c, sortBy)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to list users")
+		response.Fail(c, err)
 		return
 	}
 
-	response.Paginated(c, users, total, page, pageSize)
+	users, total, next, err := h.svc.List(c.Request.Context(), search, sortBy, page)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	response.List(c, users, page, total, next)
 }
 
 // GetUser returns a single user by ID.
@@ -76,17 +80,29 @@ func (h *UserHandler) PatchUser(c *gin.Context) {
 	response.Success(c, user)
 }
 
-// parsePagination extracts page and page_size from query parameters with defaults.
-func parsePagination(c *gin.Context) (int, int) {
-	page := 1
-	pageSize := 20
+// ResetAvatar removes a user's avatar, e.g. when it is offensive.
+func (h *UserHandler) ResetAvatar(c *gin.Context) {
+	id := c.Param("id")
 
-	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
-		page = p
+	user, err := h.svc.ResetAvatar(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, err)
+		return
 	}
-	if ps, err := strconv.Atoi(c.Query("page_size")); err == nil && ps > 0 && ps <= 100 {
-		pageSize = ps
+
+	response.Success(c, user)
+}
+
+// ResetName replaces a user's handle and display name with a generated
+// handle, e.g. when either breaks the naming rules.
+func (h *UserHandler) ResetName(c *gin.Context) {
+	id := c.Param("id")
+
+	user, err := h.svc.ResetName(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, err)
+		return
 	}
 
-	return page, pageSize
+	response.Success(c, user)
 }
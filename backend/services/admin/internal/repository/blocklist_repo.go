@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// BlocklistRepository handles database operations for the name blocklist.
+type BlocklistRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBlocklistRepository creates a new BlocklistRepository.
+func NewBlocklistRepository(pool *pgxpool.Pool) *BlocklistRepository {
+	return &BlocklistRepository{pool: pool}
+}
+
+// List returns every blocked term, alphabetically.
+func (r *BlocklistRepository) List(ctx context.Context) ([]model.BlockedName, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, term, reason, created_by, created_at
+		FROM name_blocklist
+		ORDER BY term`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocklist: %w", err)
+	}
+	defer rows.Close()
+
+	names := []model.BlockedName{}
+	for rows.Next() {
+		var n model.BlockedName
+		if err := rows.Scan(&n.ID, &n.Term, &n.Reason, &n.CreatedBy, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked name: %w", err)
+		}
+		names = append(names, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate blocklist: %w", err)
+	}
+
+	return names, nil
+}
+
+// Create adds a term to the blocklist. term must already be normalized.
+func (r *BlocklistRepository) Create(ctx context.Context, term string, reason model.NameBlockReason, adminID string) (*model.BlockedName, error) {
+	n := &model.BlockedName{}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO name_blocklist (term, reason, created_by)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		RETURNING id, term, reason, created_by, created_at`,
+		term, reason, adminID,
+	).Scan(&n.ID, &n.Term, &n.Reason, &n.CreatedBy, &n.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blocked name: %w", err)
+	}
+	return n, nil
+}
+
+// Delete removes a term from the blocklist. It reports false if no term has
+// the given ID.
+func (r *BlocklistRepository) Delete(ctx context.Context, id string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM name_blocklist WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete blocked name: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 )
 
@@ -20,10 +21,13 @@ func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
 	return &EventRepository{pool: pool}
 }
 
-// List returns a paginated list of events with optional filters.
-func (r *EventRepository) List(ctx context.Context, status, category, search string, page, pageSize int) ([]model.Event, int64, error) {
-	offset := (page - 1) * pageSize
+// eventKeyset orders events newest first.
+var eventKeyset = db.Keyset{{Expr: "created_at", Desc: true}, {Expr: "id"}}
 
+// List returns a page of events with optional filters. In keyset mode the
+// total is not counted and the cursor values for the next page are returned
+// instead.
+func (r *EventRepository) List(ctx context.Context, status, category, search string, page db.Page) ([]model.Event, int64, []string, error) {
 	var conditions []string
 	var args []interface{}
 	argIdx := 1
@@ -46,17 +50,24 @@ func (r *EventRepository) List(ctx context.Context, status, category, search str
 		argIdx++
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	// Count total.
 	var total int64
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM events %s", whereClause)
-	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count events: %w", err)
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := eventKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("failed to apply cursor: %w", err)
+			}
+			conditions = append(conditions, after)
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		// Count total.
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM events %s", whereSQL(conditions))
+		err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count events: %w", err)
+		}
 	}
 
 	// Fetch page.
@@ -64,39 +75,49 @@ func (r *EventRepository) List(ctx context.Context, status, category, search str
 		`SELECT id, polymarket_event_id, slug, question, description, category,
 			image_url, outcomes, outcome_prices, clob_token_ids, status,
 			resolved_outcome, resolved_at, volume, volume_24h, liquidity,
-			end_date, created_at, updated_at, synced_at
+			end_date, created_at, updated_at, synced_at %s
 		FROM events
 		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`, whereClause, argIdx, argIdx+1,
+		%s
+		LIMIT $%d OFFSET $%d`,
+		eventKeyset.Select(), whereSQL(conditions), eventKeyset.OrderBy(), argIdx, argIdx+1,
 	)
-	args = append(args, pageSize, offset)
+	args = append(args, page.FetchLimit(), page.Offset)
 
 	rows, err := r.pool.Query(ctx, dataQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query events: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to query events: %w", page.CursorError(err))
 	}
 	defer rows.Close()
 
 	var events []model.Event
+	var cursors [][]string
 	for rows.Next() {
 		var e model.Event
-		if err := rows.Scan(
+		cur := make([]string, len(eventKeyset))
+		dest := append([]interface{}{
 			&e.ID, &e.PolymarketEventID, &e.Slug, &e.Question, &e.Description, &e.Category,
 			&e.ImageURL, &e.Outcomes, &e.OutcomePrices, &e.ClobTokenIDs, &e.Status,
 			&e.ResolvedOutcome, &e.ResolvedAt, &e.Volume, &e.Volume24h, &e.Liquidity,
 			&e.EndDate, &e.CreatedAt, &e.UpdatedAt, &e.SyncedAt,
-		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan event: %w", err)
+		}, eventKeyset.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to scan event: %w", err)
 		}
 		events = append(events, e)
+		cursors = append(cursors, cur)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to iterate events: %w", page.CursorError(err))
 	}
 
+	events, next := db.TrimPage(page, events, cursors)
 	if events == nil {
 		events = []model.Event{}
 	}
 
-	return events, total, nil
+	return events, total, next, nil
 }
 
 // GetByID returns a single event by ID.
@@ -129,6 +150,12 @@ func (r *EventRepository) Update(ctx context.Context, id string, category, statu
 
 	if category != nil {
 		setClauses = append(setClauses, fmt.Sprintf("category = $%d", argIdx))
+		// Keep the full-text search vector in step with the new category.
+		setClauses = append(setClauses, fmt.Sprintf(
+			`search_vector = setweight(to_tsvector('english', question), 'A') ||
+				setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+				setweight(to_tsvector('english', $%d), 'C')`, argIdx,
+		))
 		args = append(args, *category)
 		argIdx++
 	}
@@ -168,3 +195,11 @@ func (r *EventRepository) Update(ctx context.Context, id string, category, statu
 
 	return e, nil
 }
+
+// whereSQL joins conditions into a WHERE clause, or returns "" if there are none.
+func whereSQL(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReferrerActivity aggregates the referrals made with one user's code, with
+// the signals used to spot referral farming.
+type ReferrerActivity struct {
+	UserID       string `json:"user_id"`
+	DisplayName  string `json:"display_name"`
+	ReferralCode string `json:"referral_code"`
+	Referrals    int    `json:"referrals"`
+	Referrals24h int    `json:"referrals_24h"`
+	Rewarded     int    `json:"rewarded"`
+	BonusPaid    int64  `json:"bonus_paid"`
+
+	// DistinctIPs is the number of distinct addresses referees claimed from
+	// and MaxPerIP the most referees sharing a single address.
+	DistinctIPs int `json:"distinct_ips"`
+	MaxPerIP    int `json:"max_per_ip"`
+
+	// Churned counts rewarded referees who have not bet since the reward.
+	Churned         int     `json:"churned"`
+	AvgRefereeStake float64 `json:"avg_referee_stake"`
+
+	FirstReferralAt time.Time `json:"first_referral_at"`
+	LastReferralAt  time.Time `json:"last_referral_at"`
+	Flags           []string  `json:"flags"`
+}
+
+// ReferralRepository handles database operations for referrals.
+type ReferralRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewReferralRepository creates a new ReferralRepository.
+func NewReferralRepository(pool *pgxpool.Pool) *ReferralRepository {
+	return &ReferralRepository{pool: pool}
+}
+
+// ActivityByReferrer aggregates referrals created since the given time per
+// referrer, most prolific referrers first. BonusPaid and Flags are left for
+// the caller to fill in.
+func (r *ReferralRepository) ActivityByReferrer(ctx context.Context, since time.Time, limit int) ([]ReferrerActivity, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH recent AS (
+			SELECT * FROM referrals WHERE created_at >= $1
+		),
+		ip_counts AS (
+			SELECT referrer_id, MAX(n) AS max_per_ip
+			FROM (
+				SELECT referrer_id, claim_ip, COUNT(*) AS n
+				FROM recent
+				WHERE claim_ip IS NOT NULL
+				GROUP BY referrer_id, claim_ip
+			) per_ip
+			GROUP BY referrer_id
+		)
+		SELECT u.id, u.display_name, u.referral_code,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE r.created_at >= NOW() - INTERVAL '24 hours'),
+			COUNT(*) FILTER (WHERE r.status = 'rewarded'),
+			COUNT(DISTINCT r.claim_ip),
+			COALESCE(MAX(ic.max_per_ip), 0),
+			COUNT(*) FILTER (WHERE r.status = 'rewarded' AND NOT EXISTS (
+				SELECT 1 FROM bets b WHERE b.user_id = r.referee_id AND b.created_at > r.rewarded_at
+			)),
+			COALESCE(AVG(stake.avg_amount), 0)::float8,
+			MIN(r.created_at), MAX(r.created_at)
+		FROM recent r
+		JOIN users u ON u.id = r.referrer_id
+		LEFT JOIN ip_counts ic ON ic.referrer_id = r.referrer_id
+		LEFT JOIN LATERAL (
+			SELECT AVG(b.amount) AS avg_amount FROM bets b WHERE b.user_id = r.referee_id
+		) stake ON true
+		GROUP BY u.id
+		ORDER BY COUNT(*) DESC, u.id
+		LIMIT $2`,
+		since, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral activity: %w", err)
+	}
+	defer rows.Close()
+
+	var activity []ReferrerActivity
+	for rows.Next() {
+		var a ReferrerActivity
+		if err := rows.Scan(
+			&a.UserID, &a.DisplayName, &a.ReferralCode,
+			&a.Referrals, &a.Referrals24h, &a.Rewarded,
+			&a.DistinctIPs, &a.MaxPerIP, &a.Churned, &a.AvgRefereeStake,
+			&a.FirstReferralAt, &a.LastReferralAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan referral activity: %w", err)
+		}
+		activity = append(activity, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate referral activity: %w", err)
+	}
+
+	return activity, nil
+}
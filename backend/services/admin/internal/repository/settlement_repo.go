@@ -2,30 +2,44 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/settle"
 )
 
 // SettlementRepository handles database operations for settlements.
 type SettlementRepository struct {
-	pool *pgxpool.Pool
+	pool            *pgxpool.Pool
+	startingBalance int64
 }
 
-// NewSettlementRepository creates a new SettlementRepository.
-func NewSettlementRepository(pool *pgxpool.Pool) *SettlementRepository {
-	return &SettlementRepository{pool: pool}
+// NewSettlementRepository creates a new SettlementRepository. Rankings
+// measure each user's profit against startingBalance.
+func NewSettlementRepository(pool *pgxpool.Pool, startingBalance int64) *SettlementRepository {
+	return &SettlementRepository{pool: pool, startingBalance: startingBalance}
 }
 
 // ForceSettle atomically settles an event with the given outcome.
 // It updates the event, resolves all pending bets, adjusts user balances,
 // logs credit transactions, and inserts a settlement record -- all within
-// a single database transaction.
-func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome string) (*model.Settlement, error) {
+// a single database transaction. The outcome is the winning outcome label,
+// model.ResolutionVoid to refund every stake, or model.ResolutionSplit to
+// pay out at prices, one per outcome, which are then stored as the event's
+// outcome prices.
+func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome string, prices []string) (*model.Settlement, error) {
+	if len(prices) > 0 && outcome != model.ResolutionSplit {
+		return nil, apperr.ErrValidation.WithMessage("prices are only accepted for a split")
+	}
+
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -34,23 +48,46 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 
 	// 1. Check event exists and is not already resolved.
 	var currentStatus model.EventStatus
+	var question string
+	var outcomesJSON, pricesJSON []byte
 	err = tx.QueryRow(ctx,
-		`SELECT status FROM events WHERE id = $1 FOR UPDATE`, eventID,
-	).Scan(&currentStatus)
+		`SELECT status, question, outcomes, outcome_prices FROM events WHERE id = $1 FOR UPDATE`, eventID,
+	).Scan(&currentStatus, &question, &outcomesJSON, &pricesJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperr.ErrNotFound.WithMessage("event not found")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("event not found: %w", err)
+		return nil, fmt.Errorf("failed to lock event: %w", err)
 	}
 	if currentStatus == model.EventStatusResolved {
-		return nil, fmt.Errorf("event is already resolved")
+		return nil, apperr.ErrEventResolved
+	}
+
+	if outcome == model.ResolutionSplit {
+		var outcomes []string
+		if err := json.Unmarshal(outcomesJSON, &outcomes); err != nil {
+			return nil, fmt.Errorf("failed to decode event outcomes: %w", err)
+		}
+		if _, err := model.SplitPrices(outcomes, prices); err != nil {
+			return nil, apperr.ErrValidation.WithMessage("invalid split prices: " + err.Error())
+		}
+		if pricesJSON, err = json.Marshal(prices); err != nil {
+			return nil, fmt.Errorf("failed to encode split prices: %w", err)
+		}
+	}
+	res, err := model.NewResolution(outcome, outcomesJSON, pricesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resolution: %w", err)
 	}
 
 	// 2. Update event to resolved.
 	now := time.Now()
 	_, err = tx.Exec(ctx,
 		`UPDATE events
-		 SET status = 'resolved', resolved_outcome = $1, resolved_at = $2, updated_at = $2
+		 SET status = 'resolved', resolved_outcome = $1, resolved_at = $2, updated_at = $2,
+		     outcome_prices = $4
 		 WHERE id = $3`,
-		outcome, now, eventID,
+		outcome, now, eventID, pricesJSON,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update event status: %w", err)
@@ -58,7 +95,7 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 
 	// 3. Get all pending bets for this event (lock rows).
 	rows, err := tx.Query(ctx,
-		`SELECT id, user_id, outcome, amount, potential_payout
+		`SELECT id, user_id, outcome, amount, locked_odds, potential_payout
 		 FROM bets
 		 WHERE event_id = $1 AND status = 'pending'
 		 FOR UPDATE`, eventID,
@@ -67,18 +104,10 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 		return nil, fmt.Errorf("failed to fetch pending bets: %w", err)
 	}
 
-	type betRow struct {
-		ID              string
-		UserID          string
-		Outcome         string
-		Amount          int64
-		PotentialPayout int64
-	}
-
-	var bets []betRow
+	var bets []model.Bet
 	for rows.Next() {
-		var b betRow
-		if err := rows.Scan(&b.ID, &b.UserID, &b.Outcome, &b.Amount, &b.PotentialPayout); err != nil {
+		var b model.Bet
+		if err := rows.Scan(&b.ID, &b.UserID, &b.Outcome, &b.Amount, &b.LockedOdds, &b.PotentialPayout); err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("failed to scan bet: %w", err)
 		}
@@ -86,90 +115,27 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 	}
 	rows.Close()
 
-	// 4. Resolve each bet.
+	// 4. Resolve each bet the way the settler does.
 	var totalPayouts int64
 	for _, b := range bets {
-		if b.Outcome == outcome {
-			// Winner: set status=won, payout=potential_payout, credit user balance.
-			payout := b.PotentialPayout
-			totalPayouts += payout
-
-			_, err = tx.Exec(ctx,
-				`UPDATE bets SET status = 'won', payout = $1, settled_at = $2 WHERE id = $3`,
-				payout, now, b.ID,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to update winning bet: %w", err)
-			}
-
-			// Release frozen balance and add payout to available balance.
-			var balanceAfter int64
-			err = tx.QueryRow(ctx,
-				`UPDATE users
-				 SET balance = balance + $1,
-				     frozen_balance = frozen_balance - $2,
-				     total_wins = total_wins + 1,
-				     updated_at = NOW()
-				 WHERE id = $3
-				 RETURNING balance`,
-				payout, b.Amount, b.UserID,
-			).Scan(&balanceAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to credit winner balance: %w", err)
-			}
-
-			// Log credit transaction for the win.
-			refID := b.ID
-			desc := "Bet won - settlement payout"
-			_, err = tx.Exec(ctx,
-				`INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description)
-				 VALUES ($1, $2, $3, $4, $5, $6)`,
-				b.UserID, "bet_win", payout, balanceAfter, refID, desc,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to log winner credit transaction: %w", err)
-			}
-		} else {
-			// Loser: set status=lost, payout=0, release frozen balance.
-			var zeroPayout int64
-			_, err = tx.Exec(ctx,
-				`UPDATE bets SET status = 'lost', payout = $1, settled_at = $2 WHERE id = $3`,
-				zeroPayout, now, b.ID,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to update losing bet: %w", err)
-			}
-
-			// Release frozen balance (the amount was already deducted from available balance
-			// when the bet was placed, so we just remove it from frozen).
-			var balanceAfter int64
-			err = tx.QueryRow(ctx,
-				`UPDATE users
-				 SET frozen_balance = frozen_balance - $1,
-				     updated_at = NOW()
-				 WHERE id = $2
-				 RETURNING balance`,
-				b.Amount, b.UserID,
-			).Scan(&balanceAfter)
-			if err != nil {
-				return nil, fmt.Errorf("failed to release frozen balance: %w", err)
-			}
+		status, payout := res.Settle(&b)
+		totalPayouts += payout
 
-			// Log credit transaction for the loss.
-			refID := b.ID
-			desc := "Bet lost - settlement"
-			_, err = tx.Exec(ctx,
-				`INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description)
-				 VALUES ($1, $2, $3, $4, $5, $6)`,
-				b.UserID, "bet_loss", -b.Amount, balanceAfter, refID, desc,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to log loser credit transaction: %w", err)
-			}
+		if err := settle.Bet(ctx, tx, &b, status, payout, question); err != nil {
+			return nil, fmt.Errorf("failed to settle bet %s: %w", b.ID, err)
 		}
 	}
 
-	// 5. Insert settlement record.
+	// 5. Pay referral bonuses to referees who have now settled enough bets.
+	bettors := make([]string, 0, len(bets))
+	for _, b := range bets {
+		bettors = append(bettors, b.UserID)
+	}
+	if err := settle.PayReferralBonuses(ctx, tx, bettors); err != nil {
+		return nil, fmt.Errorf("failed to pay referral bonuses: %w", err)
+	}
+
+	// 6. Insert settlement record.
 	settlement := &model.Settlement{}
 	err = tx.QueryRow(ctx,
 		`INSERT INTO settlements (event_id, resolved_outcome, total_bets, total_payouts, settled_at)
@@ -184,8 +150,10 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 		return nil, fmt.Errorf("failed to insert settlement record: %w", err)
 	}
 
-	// 6. Recalculate rankings (all_time, weekly, monthly).
-	_, err = tx.Exec(ctx, `
+	// 7. Recalculate rankings (all_time, weekly, monthly).
+	// The statements run as one batch, which cannot take bind parameters, so
+	// the starting balance (an integer) is formatted in.
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
 		DELETE FROM rankings WHERE category IS NULL;
 
 		-- All time rankings from user stats
@@ -197,17 +165,18 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 		SELECT
 			u.id, 'all_time',
 			u.balance + u.frozen_balance,
-			(u.balance + u.frozen_balance) - 10000,
+			(u.balance + u.frozen_balance) - %[1]d,
 			u.total_wins,
 			u.total_bets - u.total_wins,
 			CASE WHEN u.total_bets > 0 THEN u.total_wins::numeric / u.total_bets ELSE 0 END,
-			CASE WHEN u.total_bets > 0 THEN ((u.balance + u.frozen_balance) - 10000)::numeric / 10000 ELSE 0 END,
+			CASE WHEN u.total_bets > 0 THEN ((u.balance + u.frozen_balance) - %[1]d)::numeric / %[1]d ELSE 0 END,
 			u.current_streak,
 			ROW_NUMBER() OVER (ORDER BY (u.balance + u.frozen_balance) DESC)
 		FROM users u
 		WHERE u.total_bets > 0;
 
-		-- Weekly rankings from bets settled in the last 7 days
+		-- Weekly rankings from bets settled in the last 7 days. Lost bets
+		-- pay nothing except in a split, so profit counts every payout.
 		INSERT INTO rankings (
 			user_id, period, total_assets, total_profit,
 			win_count, loss_count, win_rate, roi,
@@ -215,16 +184,16 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 		)
 		SELECT
 			b.user_id, 'weekly',
-			COALESCE(SUM(CASE WHEN b.status = 'won' THEN b.payout ELSE 0 END), 0),
-			COALESCE(SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END), 0),
+			COALESCE(SUM(b.payout), 0),
+			COALESCE(SUM(COALESCE(b.payout, 0) - b.amount), 0),
 			COUNT(*) FILTER (WHERE b.status = 'won'),
 			COUNT(*) FILTER (WHERE b.status = 'lost'),
 			CASE WHEN COUNT(*) > 0 THEN COUNT(*) FILTER (WHERE b.status = 'won')::numeric / COUNT(*) ELSE 0 END,
 			CASE WHEN SUM(b.amount) > 0 THEN
-				SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END)::numeric / SUM(b.amount)
+				SUM(COALESCE(b.payout, 0) - b.amount)::numeric / SUM(b.amount)
 			ELSE 0 END,
 			0,
-			ROW_NUMBER() OVER (ORDER BY SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END) DESC)
+			ROW_NUMBER() OVER (ORDER BY SUM(COALESCE(b.payout, 0) - b.amount) DESC)
 		FROM bets b
 		WHERE b.status IN ('won', 'lost') AND b.settled_at >= NOW() - INTERVAL '7 days'
 		GROUP BY b.user_id;
@@ -237,25 +206,25 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 		)
 		SELECT
 			b.user_id, 'monthly',
-			COALESCE(SUM(CASE WHEN b.status = 'won' THEN b.payout ELSE 0 END), 0),
-			COALESCE(SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END), 0),
+			COALESCE(SUM(b.payout), 0),
+			COALESCE(SUM(COALESCE(b.payout, 0) - b.amount), 0),
 			COUNT(*) FILTER (WHERE b.status = 'won'),
 			COUNT(*) FILTER (WHERE b.status = 'lost'),
 			CASE WHEN COUNT(*) > 0 THEN COUNT(*) FILTER (WHERE b.status = 'won')::numeric / COUNT(*) ELSE 0 END,
 			CASE WHEN SUM(b.amount) > 0 THEN
-				SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END)::numeric / SUM(b.amount)
+				SUM(COALESCE(b.payout, 0) - b.amount)::numeric / SUM(b.amount)
 			ELSE 0 END,
 			0,
-			ROW_NUMBER() OVER (ORDER BY SUM(CASE WHEN b.status = 'won' THEN b.payout - b.amount ELSE -b.amount END) DESC)
+			ROW_NUMBER() OVER (ORDER BY SUM(COALESCE(b.payout, 0) - b.amount) DESC)
 		FROM bets b
 		WHERE b.status IN ('won', 'lost') AND b.settled_at >= NOW() - INTERVAL '30 days'
 		GROUP BY b.user_id;
-	`)
+	`, r.startingBalance))
 	if err != nil {
 		return nil, fmt.Errorf("failed to recalculate rankings: %w", err)
 	}
 
-	// 7. Commit transaction.
+	// 8. Commit transaction.
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit settlement transaction: %w", err)
 	}
@@ -263,39 +232,66 @@ func (r *SettlementRepository) ForceSettle(ctx context.Context, eventID, outcome
 	return settlement, nil
 }
 
-// List returns a paginated list of settlement records.
-func (r *SettlementRepository) List(ctx context.Context, page, pageSize int) ([]model.Settlement, int64, error) {
-	offset := (page - 1) * pageSize
+// settlementKeyset orders settlements most recent first.
+var settlementKeyset = db.Keyset{{Expr: "settled_at", Desc: true}, {Expr: "id", Desc: true}}
+
+// List returns a page of settlement records. In keyset mode the total is not
+// counted and the cursor values for the next page are returned instead.
+func (r *SettlementRepository) List(ctx context.Context, page db.Page) ([]model.Settlement, int64, []string, error) {
+	whereClause := ""
+	var args []interface{}
+	argIdx := 1
 
 	var total int64
-	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM settlements`).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count settlements: %w", err)
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := settlementKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("failed to apply cursor: %w", err)
+			}
+			whereClause = "WHERE " + after
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM settlements`).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count settlements: %w", err)
+		}
 	}
 
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, event_id, resolved_outcome, total_bets, total_payouts, settled_at
+		fmt.Sprintf(`SELECT id, event_id, resolved_outcome, total_bets, total_payouts, settled_at %s
 		 FROM settlements
-		 ORDER BY settled_at DESC
-		 LIMIT $1 OFFSET $2`, pageSize, offset,
+		 %s
+		 %s
+		 LIMIT $%d OFFSET $%d`, settlementKeyset.Select(), whereClause, settlementKeyset.OrderBy(), argIdx, argIdx+1),
+		append(args, page.FetchLimit(), page.Offset)...,
 	)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query settlements: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to query settlements: %w", page.CursorError(err))
 	}
 	defer rows.Close()
 
+	var cursors [][]string
 	settlements, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Settlement, error) {
 		var s model.Settlement
-		err := row.Scan(&s.ID, &s.EventID, &s.ResolvedOutcome, &s.TotalBets, &s.TotalPayouts, &s.SettledAt)
+		cur := make([]string, len(settlementKeyset))
+		dest := append([]interface{}{
+			&s.ID, &s.EventID, &s.ResolvedOutcome, &s.TotalBets, &s.TotalPayouts, &s.SettledAt,
+		}, settlementKeyset.ScanDest(cur)...)
+		err := row.Scan(dest...)
+		cursors = append(cursors, cur)
 		return s, err
 	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to collect settlements: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to collect settlements: %w", page.CursorError(err))
 	}
 
+	settlements, next := db.TrimPage(page, settlements, cursors)
 	if settlements == nil {
 		settlements = []model.Settlement{}
 	}
 
-	return settlements, total, nil
+	return settlements, total, next, nil
 }
@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 )
 
@@ -19,99 +23,99 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	return &UserRepository{pool: pool}
 }
 
-// List returns a paginated list of users, optionally filtered by display_name.
-func (r *UserRepository) List(ctx context.Context, search, sortBy string, page, pageSize int) ([]model.User, int64, error) {
-	offset := (page - 1) * pageSize
-
-	// Validate sort column.
-	allowedSorts := map[string]string{
-		"created_at":   "created_at DESC",
-		"balance":      "balance DESC",
-		"display_name": "display_name ASC",
-		"total_bets":   "total_bets DESC",
-	}
-	orderClause := "created_at DESC"
-	if s, ok := allowedSorts[sortBy]; ok {
-		orderClause = s
+// userKeyset returns the ordering for a sort_by value, ending with the user
+// ID so it is total and usable for keyset pagination.
+func userKeyset(sortBy string) db.Keyset {
+	id := db.SortKey{Expr: "id"}
+
+	switch sortBy {
+	case "balance":
+		return db.Keyset{{Expr: "balance", Desc: true}, id}
+	case "display_name":
+		return db.Keyset{{Expr: "display_name"}, id}
+	case "total_bets":
+		return db.Keyset{{Expr: "total_bets", Desc: true}, id}
 	}
 
-	var total int64
-	var users []model.User
+	return db.Keyset{{Expr: "created_at", Desc: true}, id}
+}
+
+// List returns a page of users, optionally filtered by display name or
+// handle. In keyset mode the total is not counted and the cursor values for
+// the next page are returned instead.
+func (r *UserRepository) List(ctx context.Context, search, sortBy string, page db.Page) ([]model.User, int64, []string, error) {
+	var conditions []string
+	var args []interface{}
+	argIdx := 1
 
 	if search != "" {
-		searchPattern := "%" + search + "%"
+		conditions = append(conditions, fmt.Sprintf("(display_name ILIKE $%d OR handle ILIKE $%d)", argIdx, argIdx))
+		args = append(args, "%"+search+"%")
+		argIdx++
+	}
 
-		err := r.pool.QueryRow(ctx,
-			`SELECT COUNT(*) FROM users WHERE display_name ILIKE $1`, searchPattern,
-		).Scan(&total)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count users: %w", err)
-		}
+	keys := userKeyset(sortBy)
 
-		rows, err := r.pool.Query(ctx,
-			fmt.Sprintf(`SELECT id, display_name, avatar_url, balance, frozen_balance,
-				level, xp, current_streak, max_streak, total_bets, total_wins,
-				created_at, updated_at
-			FROM users
-			WHERE display_name ILIKE $1
-			ORDER BY %s
-			LIMIT $2 OFFSET $3`, orderClause),
-			searchPattern, pageSize, offset,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to query users: %w", err)
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var u model.User
-			if err := rows.Scan(
-				&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
-				&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins,
-				&u.CreatedAt, &u.UpdatedAt,
-			); err != nil {
-				return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := keys.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("failed to apply cursor: %w", err)
 			}
-			users = append(users, u)
+			conditions = append(conditions, after)
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
 		}
 	} else {
-		err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&total)
+		err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM users "+whereSQL(conditions), args...).Scan(&total)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count users: %w", err)
+			return nil, 0, nil, fmt.Errorf("failed to count users: %w", err)
 		}
+	}
 
-		rows, err := r.pool.Query(ctx,
-			fmt.Sprintf(`SELECT id, display_name, avatar_url, balance, frozen_balance,
-				level, xp, current_streak, max_streak, total_bets, total_wins,
-				created_at, updated_at
-			FROM users
-			ORDER BY %s
-			LIMIT $1 OFFSET $2`, orderClause),
-			pageSize, offset,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to query users: %w", err)
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var u model.User
-			if err := rows.Scan(
-				&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
-				&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins,
-				&u.CreatedAt, &u.UpdatedAt,
-			); err != nil {
-				return nil, 0, fmt.Errorf("failed to scan user: %w", err)
-			}
-			users = append(users, u)
+	rows, err := r.pool.Query(ctx,
+		fmt.Sprintf(`SELECT id, display_name, avatar_url, balance, frozen_balance,
+			level, xp, current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at,
+			created_at, updated_at %s
+		FROM users
+		%s
+		%s
+		LIMIT $%d OFFSET $%d`, keys.Select(), whereSQL(conditions), keys.OrderBy(), argIdx, argIdx+1),
+		append(args, page.FetchLimit(), page.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to query users: %w", page.CursorError(err))
+	}
+	defer rows.Close()
+
+	var users []model.User
+	var cursors [][]string
+	for rows.Next() {
+		var u model.User
+		cur := make([]string, len(keys))
+		dest := append([]interface{}{
+			&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
+			&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt,
+			&u.CreatedAt, &u.UpdatedAt,
+		}, keys.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to scan user: %w", err)
 		}
+		users = append(users, u)
+		cursors = append(cursors, cur)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to iterate users: %w", page.CursorError(err))
+	}
+
+	users, next := db.TrimPage(page, users, cursors)
 	if users == nil {
 		users = []model.User{}
 	}
 
-	return users, total, nil
+	return users, total, next, nil
 }
 
 // GetByID returns a single user by ID.
@@ -119,13 +123,13 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, e
 	u := &model.User{}
 	err := r.pool.QueryRow(ctx,
 		`SELECT id, display_name, avatar_url, balance, frozen_balance,
-			level, xp, current_streak, max_streak, total_bets, total_wins,
+			level, xp, current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at,
 			created_at, updated_at
 		FROM users
 		WHERE id = $1`, id,
 	).Scan(
 		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
-		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins,
+		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt,
 		&u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
@@ -149,12 +153,12 @@ func (r *UserRepository) AdjustBalance(ctx context.Context, id string, adjustmen
 		 SET balance = balance + $1, updated_at = NOW()
 		 WHERE id = $2
 		 RETURNING id, display_name, avatar_url, balance, frozen_balance,
-			level, xp, current_streak, max_streak, total_bets, total_wins,
+			level, xp, current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at,
 			created_at, updated_at`,
 		adjustment, id,
 	).Scan(
 		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
-		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins,
+		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt,
 		&u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
@@ -179,3 +183,62 @@ func (r *UserRepository) AdjustBalance(ctx context.Context, id string, adjustmen
 
 	return u, nil
 }
+
+// ClearAvatar removes a user's avatar URL. Unknown users are
+// apperr.ErrNotFound.
+func (r *UserRepository) ClearAvatar(ctx context.Context, id string) (*model.User, error) {
+	u := &model.User{}
+	err := r.pool.QueryRow(ctx,
+		`UPDATE users
+		 SET avatar_url = NULL, updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING id, display_name, avatar_url, balance, frozen_balance,
+			level, xp, current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at,
+			created_at, updated_at`,
+		id,
+	).Scan(
+		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
+		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt,
+		&u.CreatedAt, &u.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperr.ErrNotFound.WithMessage("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear avatar: %w", err)
+	}
+	return u, nil
+}
+
+// ResetName replaces a user's handle with a generated one and sets their
+// display name to match. The rename cooldown is cleared so the user can pick
+// a new handle straight away. Unknown and deleted users are
+// apperr.ErrNotFound.
+func (r *UserRepository) ResetName(ctx context.Context, id string) (*model.User, error) {
+	u := &model.User{}
+	err := r.pool.QueryRow(ctx,
+		`WITH generated AS (
+			SELECT 'player_' || substr(md5(gen_random_uuid()::text), 1, 10) AS handle
+		)
+		UPDATE users
+		 SET handle = generated.handle, display_name = generated.handle,
+			handle_changed_at = NULL, updated_at = NOW()
+		 FROM generated
+		 WHERE id = $1 AND deleted_at IS NULL
+		 RETURNING id, display_name, avatar_url, balance, frozen_balance,
+			level, xp, current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at,
+			created_at, updated_at`,
+		id,
+	).Scan(
+		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
+		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets, &u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt,
+		&u.CreatedAt, &u.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperr.ErrNotFound.WithMessage("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset name: %w", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/moderation"
+	"github.com/poly-predict/backend/services/admin/internal/repository"
+)
+
+var (
+	// ErrInvalidBlockReason is returned for a reason other than profanity or
+	// impersonation.
+	ErrInvalidBlockReason = apperr.ErrValidation.WithMessage("reason must be profanity or impersonation")
+	// ErrTermExists is returned when the normalized term is already blocked.
+	ErrTermExists = apperr.New(http.StatusConflict, apperr.CodeConflict, "term is already blocked")
+)
+
+// BlocklistService manages the terms that may not appear in user handles or
+// display names. Terms apply to names chosen after they are added; existing
+// names can be cleared with UserService.ResetName.
+type BlocklistService struct {
+	repo *repository.BlocklistRepository
+}
+
+// NewBlocklistService creates a new BlocklistService.
+func NewBlocklistService(repo *repository.BlocklistRepository) *BlocklistService {
+	return &BlocklistService{repo: repo}
+}
+
+// List returns every blocked term.
+func (s *BlocklistService) List(ctx context.Context) ([]model.BlockedName, error) {
+	return s.repo.List(ctx)
+}
+
+// Add normalizes term and adds it to the blocklist.
+func (s *BlocklistService) Add(ctx context.Context, term string, reason model.NameBlockReason, adminID string) (*model.BlockedName, error) {
+	if reason != model.NameBlockProfanity && reason != model.NameBlockImpersonation {
+		return nil, ErrInvalidBlockReason
+	}
+
+	normalized, err := moderation.NormalizeTerm(term)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := s.repo.Create(ctx, normalized, reason, adminID)
+	if db.IsUniqueViolation(err, "") {
+		return nil, ErrTermExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add blocked name: %w", err)
+	}
+	return name, nil
+}
+
+// Remove deletes a term from the blocklist. It reports false if the term
+// does not exist.
+func (s *BlocklistService) Remove(ctx context.Context, id string) (bool, error) {
+	return s.repo.Delete(ctx, id)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/services/admin/internal/repository"
 )
@@ -17,9 +18,9 @@ func NewEventService(repo *repository.EventRepository) *EventService {
 	return &EventService{repo: repo}
 }
 
-// List returns a paginated list of events.
-func (s *EventService) List(ctx context.Context, status, category, search string, page, pageSize int) ([]model.Event, int64, error) {
-	return s.repo.List(ctx, status, category, search, page, pageSize)
+// List returns a page of events.
+func (s *EventService) List(ctx context.Context, status, category, search string, page db.Page) ([]model.Event, int64, []string, error) {
+	return s.repo.List(ctx, status, category, search, page)
 }
 
 // GetByID returns a single event.
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/admin/internal/repository"
+)
+
+// Thresholds at which a referrer is flagged in the farming report.
+const (
+	burstReferrals24h = 10 // referrals claimed within the last 24 hours
+	sharedIPReferees  = 3  // referees claiming from one address
+	churnMinRewarded  = 3  // rewarded referees before churn is considered
+	minStakeAverage   = 10 // average referee stake, in credits
+)
+
+// ReferralService wraps the ReferralRepository.
+type ReferralService struct {
+	repo *repository.ReferralRepository
+}
+
+// NewReferralService creates a new ReferralService.
+func NewReferralService(repo *repository.ReferralRepository) *ReferralService {
+	return &ReferralService{repo: repo}
+}
+
+// Report returns referral activity per referrer over the given window, with
+// farming heuristics evaluated. When flaggedOnly is set, referrers without
+// any flags are omitted.
+func (s *ReferralService) Report(ctx context.Context, window time.Duration, limit int, flaggedOnly bool) ([]repository.ReferrerActivity, error) {
+	activity, err := s.repo.ActivityByReferrer(ctx, time.Now().Add(-window), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]repository.ReferrerActivity, 0, len(activity))
+	for _, a := range activity {
+		a.BonusPaid = int64(a.Rewarded) * model.ReferralBonus
+		a.Flags = farmingFlags(a)
+		if flaggedOnly && len(a.Flags) == 0 {
+			continue
+		}
+		report = append(report, a)
+	}
+
+	return report, nil
+}
+
+// farmingFlags names the farming heuristics a referrer trips.
+func farmingFlags(a repository.ReferrerActivity) []string {
+	flags := []string{}
+	if a.Referrals24h >= burstReferrals24h {
+		flags = append(flags, "burst")
+	}
+	if a.MaxPerIP >= sharedIPReferees {
+		flags = append(flags, "shared_ip")
+	}
+	if a.Rewarded >= churnMinRewarded && a.Churned*2 >= a.Rewarded {
+		flags = append(flags, "churn_after_reward")
+	}
+	if a.Referrals >= sharedIPReferees && a.AvgRefereeStake > 0 && a.AvgRefereeStake <= minStakeAverage {
+		flags = append(flags, "minimum_stakes")
+	}
+	return flags
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/services/admin/internal/repository"
 )
@@ -17,12 +18,13 @@ func NewSettlementService(repo *repository.SettlementRepository) *SettlementServ
 	return &SettlementService{repo: repo}
 }
 
-// ForceSettle atomically settles an event with the given outcome.
-func (s *SettlementService) ForceSettle(ctx context.Context, eventID, outcome string) (*model.Settlement, error) {
-	return s.repo.ForceSettle(ctx, eventID, outcome)
+// ForceSettle atomically settles an event with the given outcome, which may
+// be model.ResolutionVoid, or model.ResolutionSplit at the given prices.
+func (s *SettlementService) ForceSettle(ctx context.Context, eventID, outcome string, prices []string) (*model.Settlement, error) {
+	return s.repo.ForceSettle(ctx, eventID, outcome, prices)
 }
 
-// List returns a paginated list of settlements.
-func (s *SettlementService) List(ctx context.Context, page, pageSize int) ([]model.Settlement, int64, error) {
-	return s.repo.List(ctx, page, pageSize)
+// List returns a page of settlements.
+func (s *SettlementService) List(ctx context.Context, page db.Page) ([]model.Settlement, int64, []string, error) {
+	return s.repo.List(ctx, page)
 }
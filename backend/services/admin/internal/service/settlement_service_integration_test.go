@@ -0,0 +1,179 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/testdb"
+	"github.com/poly-predict/backend/services/admin/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestForceSettle(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	svc := NewSettlementService(repository.NewSettlementRepository(pool, 1000))
+
+	winner := testdb.CreateUser(t, pool, 1000)
+	loser := testdb.CreateUser(t, pool, 1000)
+	bystander := testdb.CreateUser(t, pool, 1000)
+	event := testdb.CreateEvent(t, pool, "0.5", "0.5")
+	other := testdb.CreateEvent(t, pool, "0.5", "0.5")
+	// The API stores outcomes as sent, so a lowercase "yes" must still win.
+	testdb.PlaceBet(t, pool, winner, event, "yes", 100, 0.5)
+	testdb.PlaceBet(t, pool, loser, event, "No", 300, 0.5)
+	testdb.PlaceBet(t, pool, bystander, other, "Yes", 50, 0.5)
+
+	settlement, err := svc.ForceSettle(ctx, event, "Yes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settlement.TotalBets != 2 || settlement.TotalPayouts != 200 {
+		t.Errorf("settlement of %d bets paying %d, want 2 paying 200", settlement.TotalBets, settlement.TotalPayouts)
+	}
+
+	want := map[string][2]int64{
+		winner:    {1000 - 100 + 200, 0},
+		loser:     {1000 - 300, 0},
+		bystander: {1000 - 50, 50},
+	}
+	for user, w := range want {
+		var balance, frozen int64
+		if err := pool.QueryRow(ctx,
+			`SELECT balance, frozen_balance FROM users WHERE id = $1`, user,
+		).Scan(&balance, &frozen); err != nil {
+			t.Fatal(err)
+		}
+		if balance != w[0] || frozen != w[1] {
+			t.Errorf("user %s: balance %d, frozen %d; want %d, %d", user, balance, frozen, w[0], w[1])
+		}
+	}
+
+	var status, outcome string
+	if err := pool.QueryRow(ctx,
+		`SELECT status, resolved_outcome FROM events WHERE id = $1`, event,
+	).Scan(&status, &outcome); err != nil {
+		t.Fatal(err)
+	}
+	if status != "resolved" || outcome != "Yes" {
+		t.Errorf("event %s with outcome %q, want resolved with Yes", status, outcome)
+	}
+
+	var ranked int
+	if err := pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM rankings WHERE period = 'all_time' AND category IS NULL`,
+	).Scan(&ranked); err != nil {
+		t.Fatal(err)
+	}
+	if ranked != 3 {
+		t.Errorf("%d all-time rankings, want one per user with bets (3)", ranked)
+	}
+	testdb.CheckBalances(t, pool)
+
+	// A second settlement must be refused and change nothing.
+	if _, err := svc.ForceSettle(ctx, event, "No", nil); !errors.Is(err, apperr.ErrEventResolved) {
+		t.Errorf("expected ErrEventResolved, got %v", err)
+	}
+	var settlements int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM settlements`).Scan(&settlements); err != nil {
+		t.Fatal(err)
+	}
+	if settlements != 1 {
+		t.Errorf("%d settlements, want 1", settlements)
+	}
+	testdb.CheckBalances(t, pool)
+}
+
+func TestForceSettleUnknownEvent(t *testing.T) {
+	pool := testdb.New(t)
+	svc := NewSettlementService(repository.NewSettlementRepository(pool, 1000))
+
+	if _, err := svc.ForceSettle(context.Background(), "0xmissing", "Yes", nil); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestForceSettleVoidAndSplit(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	svc := NewSettlementService(repository.NewSettlementRepository(pool, 1000))
+
+	yes := testdb.CreateUser(t, pool, 1000)
+	no := testdb.CreateUser(t, pool, 1000)
+	voided := testdb.CreateEvent(t, pool, "0.5", "0.5")
+	split := testdb.CreateEvent(t, pool, "0.5", "0.5")
+	testdb.PlaceBet(t, pool, yes, voided, "Yes", 100, 0.5)
+	testdb.PlaceBet(t, pool, no, voided, "No", 300, 0.5)
+	// 200 shares of yes and 500 of no, each paid 0.5.
+	testdb.PlaceBet(t, pool, yes, split, "yes", 100, 0.5)
+	testdb.PlaceBet(t, pool, no, split, "No", 200, 0.4)
+
+	// Prices must come with a split, one per outcome, summing to 1.
+	for _, prices := range [][]string{nil, {"0.5"}, {"0.7", "0.7"}} {
+		if _, err := svc.ForceSettle(ctx, split, "split", prices); !errors.Is(err, apperr.ErrValidation) {
+			t.Errorf("split at %v: expected ErrValidation, got %v", prices, err)
+		}
+	}
+	if _, err := svc.ForceSettle(ctx, voided, "void", []string{"0.5", "0.5"}); !errors.Is(err, apperr.ErrValidation) {
+		t.Errorf("void with prices: expected ErrValidation, got %v", err)
+	}
+
+	settlement, err := svc.ForceSettle(ctx, voided, "void", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settlement.TotalBets != 2 || settlement.TotalPayouts != 400 {
+		t.Errorf("void settlement of %d bets paying %d, want 2 paying 400", settlement.TotalBets, settlement.TotalPayouts)
+	}
+	settlement, err = svc.ForceSettle(ctx, split, "split", []string{"0.5", "0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settlement.TotalBets != 2 || settlement.TotalPayouts != 350 {
+		t.Errorf("split settlement of %d bets paying %d, want 2 paying 350", settlement.TotalBets, settlement.TotalPayouts)
+	}
+
+	want := map[string]struct {
+		balance, totalBets int64
+		statuses           string
+	}{
+		// Paid back exactly its stake, the yes bet does not count as a win.
+		yes: {1000, 1, "lost,refunded"},
+		no:  {1000 - 200 + 250, 1, "refunded,won"},
+	}
+	for user, w := range want {
+		var balance, totalBets int64
+		var statuses string
+		if err := pool.QueryRow(ctx, `
+			SELECT u.balance, u.total_bets,
+			       (SELECT string_agg(status::text, ',' ORDER BY status::text) FROM bets WHERE user_id = u.id)
+			FROM users u WHERE u.id = $1`, user,
+		).Scan(&balance, &totalBets, &statuses); err != nil {
+			t.Fatal(err)
+		}
+		if balance != w.balance || totalBets != w.totalBets || statuses != w.statuses {
+			t.Errorf("user %s: balance %d, %d bets counted, bets %s; want %d, %d, %s",
+				user, balance, totalBets, statuses, w.balance, w.totalBets, w.statuses)
+		}
+	}
+
+	// The ledger records what each bet paid, as the settler writes it.
+	var ledger string
+	if err := pool.QueryRow(ctx, `
+		SELECT string_agg(type || ':' || amount, ',' ORDER BY id)
+		FROM credit_transactions WHERE user_id = $1 AND type <> 'bet_placed'`, yes,
+	).Scan(&ledger); err != nil {
+		t.Fatal(err)
+	}
+	if ledger != "bet_refund:100,bet_lost:100" {
+		t.Errorf("ledger %s, want bet_refund:100,bet_lost:100", ledger)
+	}
+	testdb.CheckBalances(t, pool)
+}
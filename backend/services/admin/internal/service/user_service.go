@@ -2,24 +2,28 @@ package service
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/storage"
 	"github.com/poly-predict/backend/services/admin/internal/repository"
 )
 
 // UserService wraps the UserRepository.
 type UserService struct {
-	repo *repository.UserRepository
+	repo  *repository.UserRepository
+	store storage.Storage
 }
 
 // NewUserService creates a new UserService.
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repository.UserRepository, store storage.Storage) *UserService {
+	return &UserService{repo: repo, store: store}
 }
 
-// List returns a paginated list of users.
-func (s *UserService) List(ctx context.Context, search, sortBy string, page, pageSize int) ([]model.User, int64, error) {
-	return s.repo.List(ctx, search, sortBy, page, pageSize)
+// List returns a page of users.
+func (s *UserService) List(ctx context.Context, search, sortBy string, page db.Page) ([]model.User, int64, []string, error) {
+	return s.repo.List(ctx, search, sortBy, page)
 }
 
 // GetByID returns a single user.
@@ -31,3 +35,24 @@ func (s *UserService) GetByID(ctx context.Context, id string) (*model.User, erro
 func (s *UserService) AdjustBalance(ctx context.Context, id string, adjustment int64) (*model.User, error) {
 	return s.repo.AdjustBalance(ctx, id, adjustment)
 }
+
+// ResetAvatar removes a user's avatar, deleting the stored images and
+// clearing avatar_url so clients fall back to the default. The images are
+// deleted first so a failure leaves the user with an avatar_url that can be
+// reset again, never with a cleared URL and orphaned images. Unknown users
+// are apperr.ErrNotFound.
+func (s *UserService) ResetAvatar(ctx context.Context, id string) (*model.User, error) {
+	for _, size := range storage.AvatarSizes {
+		if err := s.store.Delete(ctx, storage.AvatarKey(id, size)); err != nil {
+			return nil, fmt.Errorf("failed to delete avatar: %w", err)
+		}
+	}
+
+	return s.repo.ClearAvatar(ctx, id)
+}
+
+// ResetName replaces an offensive or impersonating handle and display name
+// with a generated handle.
+func (s *UserService) ResetName(ctx context.Context, id string) (*model.User, error) {
+	return s.repo.ResetName(ctx, id)
+}
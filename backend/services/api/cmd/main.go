@@ -10,29 +10,33 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/poly-predict/backend/pkg/config"
 	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/health"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/metrics"
+	"github.com/poly-predict/backend/pkg/migrate"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/storage"
 	"github.com/poly-predict/backend/services/api/internal/auth"
 	"github.com/poly-predict/backend/services/api/internal/handler"
+	"github.com/poly-predict/backend/services/api/internal/ratelimit"
 	"github.com/poly-predict/backend/services/api/internal/repository"
 	"github.com/poly-predict/backend/services/api/internal/service"
 )
 
 func main() {
-	// Set up zerolog.
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
 	// Load configuration.
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.ServiceAPI)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load configuration")
 	}
 
-	if cfg.Environment == "production" {
+	logging.Setup("api", cfg.Environment, cfg.LogLevel)
+
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
@@ -46,84 +50,168 @@ func main() {
 
 	log.Info().Msg("connected to database")
 
+	if cfg.MigrateOnStart {
+		if err := migrate.ApplyEmbedded(ctx, pool); err != nil {
+			log.Fatal().Err(err).Msg("failed to apply database migrations")
+		}
+	}
+	metrics.RegisterPool(pool)
+
+	// Object storage for uploads.
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize storage")
+	}
+
 	// Repositories.
-	eventRepo := repository.NewEventRepository(pool)
+	eventRepo := repository.NewEventRepository(pool, repository.PriceRetention{
+		Raw:    cfg.Prices.RawRetention,
+		Hourly: cfg.Prices.HourlyRetention,
+	})
 	betRepo := repository.NewBetRepository(pool)
-	userRepo := repository.NewUserRepository(pool)
+	userRepo := repository.NewUserRepository(pool, cfg.StartingBalance)
+	groupRepo := repository.NewGroupRepository(pool)
+	referralRepo := repository.NewReferralRepository(pool)
+	accountRepo := repository.NewAccountRepository(pool, userRepo)
+	blocklistRepo := repository.NewBlocklistRepository(pool)
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+	rankingRepo := repository.NewRankingRepository(pool)
 
 	// Services.
-	eventService := service.NewEventService(eventRepo)
-	betService := service.NewBetService(pool, betRepo, userRepo)
-	rankingService := service.NewRankingService(pool)
+	transactor := db.NewTransactor(pool)
+	eventService := service.NewEventService(eventRepo, betRepo)
+	betService := service.NewBetService(transactor, betRepo, userRepo, eventRepo)
+	rankingService := service.NewRankingService(rankingRepo)
+	groupService := service.NewGroupService(groupRepo, eventRepo)
+	referralService := service.NewReferralService(transactor, referralRepo)
+	accountService := service.NewAccountService(accountRepo, store)
+	avatarService := service.NewAvatarService(userRepo, store)
+	profileService := service.NewProfileService(transactor, userRepo, blocklistRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
 
 	// Handlers.
 	eventHandler := handler.NewEventHandler(eventService)
 	betHandler := handler.NewBetHandler(betService)
-	userHandler := handler.NewUserHandler(userRepo)
+	userHandler := handler.NewUserHandler(profileService, referralService, accountService, avatarService)
 	rankingHandler := handler.NewRankingHandler(rankingService)
+	groupHandler := handler.NewGroupHandler(groupService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 
 	// Auth middleware.
-	authMiddleware := auth.NewMiddleware(cfg.SupabaseJWTSecret, cfg.SupabaseURL)
+	authMiddleware := auth.NewMiddleware(cfg.API.SupabaseJWTSecret, cfg.API.SupabaseURL, apiKeyService)
 
-	// Set up Gin router.
-	router := gin.Default()
+	// Rate limiting, after authentication so requests are bucketed per user
+	// or API key rather than per address.
+	rateLimit := ratelimit.Middleware(
+		ratelimit.New(cfg.API.RateLimitPerMinute),
+		ratelimit.New(cfg.API.APIKeyRateLimitPerMinute),
+	)
+
+	// Set up Gin router. Every request gets an ID and an access log line.
+	router := gin.New()
+	router.Use(logging.RequestID(), logging.AccessLog(), metrics.Middleware(), gin.Recovery())
 
 	// CORS configuration.
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
+		AllowOrigins:     cfg.API.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", logging.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", logging.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Health check.
+	// Health check, kept for existing probes; prefer /livez and /readyz.
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Liveness and readiness probes. Readiness fails on database problems
+	// and reports stale market data or a settlement backlog as degraded.
+	readiness := health.NewChecker(
+		health.Database(pool),
+		health.PoolSaturation(pool, 0.9),
+		health.SyncFreshness(pool, cfg.Health.SyncStaleAfter, false),
+		health.SettlementBacklog(pool, cfg.Health.SettlementStaleAfter, false),
+	)
+	router.GET("/livez", gin.WrapH(health.Live()))
+	router.GET("/readyz", gin.WrapH(readiness))
+
+	// Uploaded files, when stored on the local filesystem.
+	if local, ok := store.(*storage.Local); ok {
+		router.Static(storage.LocalRoute, local.Dir())
+	}
+
 	// API routes.
 	api := router.Group("/api/v1")
 
 	// Public routes.
 	events := api.Group("/events")
-	events.Use(authMiddleware.OptionalAuth())
+	events.Use(authMiddleware.OptionalAuth(), rateLimit)
 	{
 		events.GET("", eventHandler.ListEvents)
+		events.GET("/suggest", eventHandler.SuggestEvents)
 		events.GET("/:id", eventHandler.GetEvent)
 		events.GET("/:id/prices", eventHandler.GetPriceHistory)
+		events.GET("/:id/candles", eventHandler.GetCandles)
 	}
 
-	api.GET("/categories", eventHandler.GetCategories)
+	api.GET("/categories", rateLimit, eventHandler.GetCategories)
+
+	groups := api.Group("/groups")
+	groups.Use(rateLimit)
+	{
+		groups.GET("", groupHandler.ListGroups)
+		groups.GET("/:id", groupHandler.GetGroup)
+	}
 
 	rankings := api.Group("/rankings")
+	rankings.Use(rateLimit)
 	{
 		rankings.GET("", rankingHandler.GetRankings)
 	}
 
-	// Authenticated routes.
+	// Authenticated routes. API keys reach only the routes granted to their
+	// scopes; account management needs a signed-in session.
 	authenticated := api.Group("")
-	authenticated.Use(authMiddleware.RequireAuth())
+	authenticated.Use(authMiddleware.RequireAuth(), rateLimit)
 	{
-		authenticated.POST("/bets", betHandler.PlaceBet)
-		authenticated.GET("/bets", betHandler.ListBets)
-		authenticated.GET("/bets/:id", betHandler.GetBet)
-
-		authenticated.GET("/users/me", userHandler.GetProfile)
-		authenticated.PATCH("/users/me", userHandler.UpdateProfile)
-		authenticated.GET("/users/me/transactions", userHandler.GetTransactions)
+		read := auth.RequireScope(model.APIKeyScopeRead)
+		trade := auth.RequireScope(model.APIKeyScopeTrade)
+		session := auth.RequireSession()
+
+		authenticated.POST("/bets", trade, betHandler.PlaceBet)
+		authenticated.GET("/bets", read, betHandler.ListBets)
+		authenticated.GET("/bets/:id", read, betHandler.GetBet)
+
+		authenticated.GET("/users/me", read, userHandler.GetProfile)
+		authenticated.PATCH("/users/me", session, userHandler.UpdateProfile)
+		authenticated.DELETE("/users/me", session, userHandler.DeleteAccount)
+		authenticated.POST("/users/me/export", session, userHandler.ExportAccount)
+		authenticated.PUT("/users/me/avatar", session, userHandler.UploadAvatar)
+		authenticated.GET("/users/me/transactions", read, userHandler.GetTransactions)
+		authenticated.POST("/users/me/referral", session, userHandler.ClaimReferral)
+		authenticated.GET("/users/me/referrals", read, userHandler.ListReferrals)
+
+		authenticated.POST("/users/me/api-keys", session, apiKeyHandler.CreateAPIKey)
+		authenticated.GET("/users/me/api-keys", session, apiKeyHandler.ListAPIKeys)
+		authenticated.DELETE("/users/me/api-keys/:id", session, apiKeyHandler.RevokeAPIKey)
 	}
 
+	// Prometheus metrics, on a port of their own to keep them off the public
+	// listener.
+	metricsSrv := metrics.Serve(":"+cfg.MetricsPort(), nil)
+	defer metricsSrv.Close()
+
 	// Create HTTP server.
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
+		Addr:    ":" + cfg.Port(),
 		Handler: router,
 	}
 
 	// Start server in a goroutine.
 	go func() {
-		log.Info().Str("port", cfg.Port).Msg("starting API server")
+		log.Info().Str("port", cfg.Port()).Msg("starting API server")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("server failed")
 		}
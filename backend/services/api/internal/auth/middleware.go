@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/base64"
@@ -15,6 +16,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rs/zerolog/log"
+
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/response"
 )
 
 // jwksKey represents a single key from a JWKS response.
@@ -32,10 +37,26 @@ type jwksResponse struct {
 	Keys []jwksKey `json:"keys"`
 }
 
-// Middleware provides JWT authentication middleware for Gin.
+// APIKey is the identity behind a verified personal API key.
+type APIKey struct {
+	ID     string
+	UserID string
+	Scopes []model.APIKeyScope
+}
+
+// APIKeyVerifier looks up personal API keys. VerifyAPIKey returns nil and no
+// error for keys that are unknown or revoked.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*APIKey, error)
+}
+
+// Middleware provides authentication middleware for Gin. Requests carry
+// either a Supabase JWT ("Authorization: Bearer ...") or a personal API key
+// ("Authorization: ApiKey ...").
 type Middleware struct {
 	jwtSecret []byte
 	jwksURL   string
+	apiKeys   APIKeyVerifier
 
 	mu      sync.RWMutex
 	keys    map[string]*ecdsa.PublicKey
@@ -45,9 +66,11 @@ type Middleware struct {
 // NewMiddleware creates a new auth Middleware.
 // jwtSecret is used for HS256 tokens (legacy).
 // supabaseURL is used to fetch JWKS for ES256 tokens.
-func NewMiddleware(jwtSecret string, supabaseURL string) *Middleware {
+// apiKeys verifies personal API keys.
+func NewMiddleware(jwtSecret string, supabaseURL string, apiKeys APIKeyVerifier) *Middleware {
 	m := &Middleware{
 		jwtSecret: []byte(jwtSecret),
+		apiKeys:   apiKeys,
 		keys:      make(map[string]*ecdsa.PublicKey),
 	}
 	if supabaseURL != "" {
@@ -56,69 +79,153 @@ func NewMiddleware(jwtSecret string, supabaseURL string) *Middleware {
 	return m
 }
 
-// RequireAuth returns a Gin middleware that requires a valid Supabase JWT.
+// RequireAuth returns a Gin middleware that requires a valid Supabase JWT or
+// personal API key.
 func (m *Middleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, ok := extractBearerToken(c)
+		scheme, credential, ok := extractCredential(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   gin.H{"message": "missing or malformed authorization header"},
-			})
+			response.Error(c, http.StatusUnauthorized, "missing or malformed authorization header")
+			c.Abort()
 			return
 		}
 
-		userID, err := m.validateToken(tokenString)
+		if scheme == schemeAPIKey {
+			key, err := m.apiKeys.VerifyAPIKey(c.Request.Context(), credential)
+			if err != nil {
+				logging.Ctx(c.Request.Context()).Error().Err(err).Msg("verify api key")
+				response.Error(c, http.StatusInternalServerError, "failed to verify api key")
+				c.Abort()
+				return
+			}
+			if key == nil {
+				response.Error(c, http.StatusUnauthorized, "invalid or revoked api key")
+				c.Abort()
+				return
+			}
+
+			setAPIKey(c, key)
+			c.Next()
+			return
+		}
+
+		userID, err := m.validateToken(credential)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   gin.H{"message": "invalid or expired token"},
-			})
+			response.Error(c, http.StatusUnauthorized, "invalid or expired token")
+			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID)
+		logging.SetField(c, "user_id", userID)
 		c.Next()
 	}
 }
 
-// OptionalAuth returns a Gin middleware that extracts user_id if a valid JWT is present.
+// OptionalAuth returns a Gin middleware that extracts user_id if a valid JWT
+// or API key is present.
 func (m *Middleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, ok := extractBearerToken(c)
+		scheme, credential, ok := extractCredential(c)
 		if !ok {
 			c.Next()
 			return
 		}
 
-		userID, err := m.validateToken(tokenString)
+		if scheme == schemeAPIKey {
+			if key, err := m.apiKeys.VerifyAPIKey(c.Request.Context(), credential); err == nil && key != nil {
+				setAPIKey(c, key)
+			}
+			c.Next()
+			return
+		}
+
+		userID, err := m.validateToken(credential)
 		if err != nil {
 			c.Next()
 			return
 		}
 
 		c.Set("user_id", userID)
+		logging.SetField(c, "user_id", userID)
+		c.Next()
+	}
+}
+
+// RequireScope returns a Gin middleware that rejects API keys lacking scope.
+// JWT sessions carry every scope. It must run after RequireAuth.
+func RequireScope(scope model.APIKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); !ok {
+			c.Next()
+			return
+		}
+
+		scopes, _ := c.Get("api_key_scopes")
+		for _, s := range scopes.([]model.APIKeyScope) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+
+		response.Error(c, http.StatusForbidden, "api key lacks the "+string(scope)+" scope")
+		c.Abort()
+	}
+}
+
+// RequireSession returns a Gin middleware that rejects API keys, for
+// account-management routes only a signed-in user may call. It must run
+// after RequireAuth.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); ok {
+			response.Error(c, http.StatusForbidden, "not available to api keys")
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
-func extractBearerToken(c *gin.Context) (string, bool) {
+const (
+	schemeBearer = "bearer"
+	schemeAPIKey = "apikey"
+)
+
+// extractCredential splits the Authorization header into its lowercased
+// scheme, either "bearer" or "apikey", and credential.
+func extractCredential(c *gin.Context) (string, string, bool) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		return "", false
+		return "", "", false
 	}
 
 	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return "", false
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	scheme := strings.ToLower(parts[0])
+	if scheme != schemeBearer && scheme != schemeAPIKey {
+		return "", "", false
 	}
 
-	token := strings.TrimSpace(parts[1])
-	if token == "" {
-		return "", false
+	credential := strings.TrimSpace(parts[1])
+	if credential == "" {
+		return "", "", false
 	}
 
-	return token, true
+	return scheme, credential, true
+}
+
+// setAPIKey records an API key's identity on the request context.
+func setAPIKey(c *gin.Context, key *APIKey) {
+	c.Set("user_id", key.UserID)
+	c.Set("api_key_id", key.ID)
+	c.Set("api_key_scopes", key.Scopes)
+	logging.SetField(c, "user_id", key.UserID)
+	logging.SetField(c, "api_key_id", key.ID)
 }
 
 // validateToken tries ES256 (via JWKS) first, then falls back to HS256.
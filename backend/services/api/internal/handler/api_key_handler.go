@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/services/api/internal/service"
+)
+
+// createAPIKeyRequest is the JSON body for creating a personal API key.
+type createAPIKeyRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Scopes []model.APIKeyScope `json:"scopes" binding:"required"`
+}
+
+// APIKeyHandler handles personal API key HTTP requests.
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKey handles POST /api/v1/users/me/api-keys
+// The response is the only time the key's secret is returned.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	key, err := h.service.Create(c.Request.Context(), userID, req.Name, req.Scopes)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	response.Created(c, key)
+}
+
+// ListAPIKeys handles GET /api/v1/users/me/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	keys, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list api keys")
+		return
+	}
+
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	response.Success(c, keys)
+}
+
+// RevokeAPIKey handles DELETE /api/v1/users/me/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := h.service.Revoke(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
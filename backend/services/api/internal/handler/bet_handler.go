@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,9 +12,11 @@ import (
 
 // placeBetRequest is the JSON body for placing a bet.
 type placeBetRequest struct {
-	EventID string `json:"event_id" binding:"required"`
+	EventID string `json:"event_id" binding:"required,max=100"`
 	Outcome string `json:"outcome" binding:"required"`
 	Amount  int64  `json:"amount" binding:"required,gt=0"`
+	// MaxOdds, if set, refuses the bet when the current odds are higher.
+	MaxOdds *float64 `json:"max_odds" binding:"omitempty,gt=0,lte=1"`
 }
 
 // BetHandler handles bet-related HTTP requests.
@@ -38,13 +39,13 @@ func (h *BetHandler) PlaceBet(c *gin.Context) {
 
 	var req placeBetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, "invalid request: "+err.Error())
+		response.BindError(c, err)
 		return
 	}
 
-	bet, err := h.service.PlaceBet(c.Request.Context(), userID, req.EventID, req.Outcome, req.Amount)
+	bet, err := h.service.PlaceBet(c.Request.Context(), userID, req.EventID, req.Outcome, req.Amount, req.MaxOdds)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+		response.Fail(c, err)
 		return
 	}
 
@@ -59,20 +60,17 @@ func (h *BetHandler) ListBets(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.Query("page"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize, _ := strconv.Atoi(c.Query("page_size"))
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
 	}
 
 	status := c.Query("status")
 
-	bets, total, err := h.service.ListByUser(c.Request.Context(), userID, status, page, pageSize)
+	bets, total, next, err := h.service.ListByUser(c.Request.Context(), userID, status, page)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to list bets")
+		response.Fail(c, err)
 		return
 	}
 
@@ -80,7 +78,7 @@ func (h *BetHandler) ListBets(c *gin.Context) {
 		bets = []model.Bet{}
 	}
 
-	response.Paginated(c, bets, total, page, pageSize)
+	response.List(c, bets, page, total, next)
 }
 
 // GetBet handles GET /api/v1/bets/:id
@@ -0,0 +1,77 @@
+//go:build integration
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/pkg/testdb"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+	"github.com/poly-predict/backend/services/api/internal/service"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// TestListBetsEditedCursor checks that a cursor which decodes cleanly but
+// carries values the ordering cannot use is a 422, not a 500.
+func TestListBetsEditedCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pool := testdb.New(t)
+	user := testdb.CreateUser(t, pool, 1000)
+
+	h := NewBetHandler(service.NewBetService(
+		db.NewTransactor(pool),
+		repository.NewBetRepository(pool),
+		repository.NewUserRepository(pool, 1000),
+		repository.NewEventRepository(pool, repository.PriceRetention{}),
+	))
+	router := gin.New()
+	router.GET("/bets", func(c *gin.Context) {
+		c.Set("user_id", user)
+		h.ListBets(c)
+	})
+
+	tests := []struct {
+		name   string
+		values []string
+	}{
+		{"too few values", []string{"2026-01-01T00:00:00Z"}},
+		{"too many values", []string{"2026-01-01T00:00:00Z", "6f1c2a8e-0a4b-4c1e-9a57-3b0f8f4f2d11", "x"}},
+		{"not a timestamp", []string{"abc", "6f1c2a8e-0a4b-4c1e-9a57-3b0f8f4f2d11"}},
+		{"not a uuid", []string{"2026-01-01T00:00:00Z", "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := url.QueryEscape(response.EncodeCursor("", tt.values))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bets?cursor="+cursor, nil))
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+			}
+			var resp struct {
+				Error struct {
+					Code apperr.Code `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error.Code != apperr.CodeInvalidCursor {
+				t.Errorf("error code = %q, want %q", resp.Error.Code, apperr.CodeInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/service"
+)
+
+// Event IDs are Polymarket condition IDs, not UUIDs.
+const conditionID = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+
+// The fakes implement only what placing a bet uses; anything else panics
+// through the nil embedded interface.
+type fakeUsers struct{ service.UserRepository }
+
+func (fakeUsers) LockBalance(ctx context.Context, tx pgx.Tx, id string) (int64, bool, error) {
+	return 1000, true, nil
+}
+
+func (fakeUsers) FreezeStake(ctx context.Context, tx pgx.Tx, id string, amount int64) error {
+	return nil
+}
+
+func (fakeUsers) AddTransaction(ctx context.Context, tx pgx.Tx, t *model.CreditTransaction) error {
+	return nil
+}
+
+type fakeEvents struct{ service.EventRepository }
+
+func (fakeEvents) GetMarket(ctx context.Context, tx pgx.Tx, id string) (*model.Event, error) {
+	if id != conditionID {
+		return nil, nil
+	}
+	return &model.Event{
+		ID:            id,
+		Status:        model.EventStatusOpen,
+		Outcomes:      json.RawMessage(`["Yes", "No"]`),
+		OutcomePrices: json.RawMessage(`["0.4", "0.6"]`),
+	}, nil
+}
+
+type fakeBets struct{ service.BetRepository }
+
+func (fakeBets) Create(ctx context.Context, tx pgx.Tx, bet *model.Bet) error {
+	return nil
+}
+
+func placeBet(t *testing.T, body string) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	h := NewBetHandler(service.NewBetService(fakeTransactor{}, fakeBets{}, fakeUsers{}, fakeEvents{}))
+	router := gin.New()
+	router.POST("/bets", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		h.PlaceBet(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bets", strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+func TestPlaceBet(t *testing.T) {
+	status, body := placeBet(t, `{"event_id": "`+conditionID+`", "outcome": "yes", "amount": 100}`)
+	if status != http.StatusCreated {
+		t.Fatalf("bet on a condition ID: status %d: %s", status, body)
+	}
+	var resp struct {
+		Data model.Bet `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.EventID != conditionID || resp.Data.PotentialPayout != 250 {
+		t.Errorf("placed %+v", resp.Data)
+	}
+
+	for name, body := range map[string]string{
+		"missing event":  `{"outcome": "yes", "amount": 100}`,
+		"event too long": `{"event_id": "` + strings.Repeat("a", 101) + `", "outcome": "yes", "amount": 100}`,
+	} {
+		if status, resp := placeBet(t, body); status != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status %d, want 422: %s", name, status, resp)
+		}
+	}
+
+	if status, resp := placeBet(t, `{"event_id": "0xunknown", "outcome": "yes", "amount": 100}`); status != http.StatusNotFound {
+		t.Errorf("unknown event: status %d, want 404: %s", status, resp)
+	}
+}
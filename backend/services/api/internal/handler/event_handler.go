@@ -23,36 +23,46 @@ func NewEventHandler(service *service.EventService) *EventHandler {
 }
 
 // ListEvents handles GET /api/v1/events
+// Authenticated callers get their position on each event, and may pass
+// mine=true to list only events they have bet on.
 func (h *EventHandler) ListEvents(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize, _ := strconv.Atoi(c.Query("page_size"))
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
+	userID := c.GetString("user_id")
 
 	filters := repository.EventFilters{
 		Status:   c.Query("status"),
 		Category: c.Query("category"),
 		Search:   c.Query("search"),
 		Sort:     c.Query("sort"),
-		Page:     page,
-		PageSize: pageSize,
 	}
 
-	events, total, err := h.service.List(c.Request.Context(), filters)
+	if c.Query("mine") == "true" {
+		if userID == "" {
+			response.Error(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		filters.BettorID = userID
+	}
+
+	page, err := response.ParsePage(c, filters.Sort)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to list events")
+		response.Fail(c, err)
+		return
+	}
+	filters.Page = page
+
+	events, total, next, err := h.service.List(c.Request.Context(), filters)
+	if err != nil {
+		response.Fail(c, err)
 		return
 	}
 
-	if events == nil {
-		events = []model.Event{}
+	withPositions, err := h.service.WithPositions(c.Request.Context(), userID, events)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to list events")
+		return
 	}
 
-	response.Paginated(c, events, total, page, pageSize)
+	response.List(c, withPositions, page, total, next)
 }
 
 // GetEvent handles GET /api/v1/events/:id
@@ -70,18 +80,46 @@ func (h *EventHandler) GetEvent(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, event)
+	withPositions, err := h.service.WithPositions(c.Request.Context(), c.GetString("user_id"), []model.Event{*event})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get event")
+		return
+	}
+
+	response.Success(c, withPositions[0])
+}
+
+// SuggestEvents handles GET /api/v1/events/suggest
+func (h *EventHandler) SuggestEvents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	suggestions, err := h.service.Suggest(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get suggestions")
+		return
+	}
+
+	if suggestions == nil {
+		suggestions = []repository.EventSuggestion{}
+	}
+
+	response.Success(c, suggestions)
 }
 
-// GetPriceHistory handles GET /api/v1/events/:id/price-history
+// GetPriceHistory handles GET /api/v1/events/:id/prices
+// An optional max_points parameter downsamples each outcome's series.
 func (h *EventHandler) GetPriceHistory(c *gin.Context) {
 	eventID := c.Param("id")
 	period := c.Query("period")
 	if period == "" {
 		period = "24h"
 	}
+	maxPoints, _ := strconv.Atoi(c.Query("max_points"))
 
-	history, err := h.service.GetPriceHistory(c.Request.Context(), eventID, period)
+	history, err := h.service.GetPriceHistory(c.Request.Context(), eventID, period, maxPoints)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get price history")
 		return
@@ -94,6 +132,24 @@ func (h *EventHandler) GetPriceHistory(c *gin.Context) {
 	response.Success(c, history)
 }
 
+// GetCandles handles GET /api/v1/events/:id/candles
+func (h *EventHandler) GetCandles(c *gin.Context) {
+	interval := c.DefaultQuery("interval", "1h")
+	period := c.DefaultQuery("period", "24h")
+
+	candles, err := h.service.GetCandles(c.Request.Context(), c.Param("id"), interval, period)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	if candles == nil {
+		candles = []service.OutcomeCandles{}
+	}
+
+	response.Success(c, candles)
+}
+
 // GetCategories handles GET /api/v1/events/categories
 func (h *EventHandler) GetCategories(c *gin.Context) {
 	categories, err := h.service.GetCategories(c.Request.Context())
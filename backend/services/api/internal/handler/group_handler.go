@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/services/api/internal/service"
+)
+
+// GroupHandler handles market group HTTP requests.
+type GroupHandler struct {
+	service *service.GroupService
+}
+
+// NewGroupHandler creates a new GroupHandler.
+func NewGroupHandler(service *service.GroupService) *GroupHandler {
+	return &GroupHandler{service: service}
+}
+
+// ListGroups handles GET /api/v1/groups
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	groups, total, next, err := h.service.List(c.Request.Context(), c.Query("tag"), page)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	if groups == nil {
+		groups = []service.GroupWithMarkets{}
+	}
+
+	response.List(c, groups, page, total, next)
+}
+
+// GetGroup handles GET /api/v1/groups/:id
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	group, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "failed to get group")
+		return
+	}
+
+	if group == nil {
+		response.Error(c, http.StatusNotFound, "group not found")
+		return
+	}
+
+	response.Success(c, group)
+}
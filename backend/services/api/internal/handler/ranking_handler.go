@@ -1,12 +1,10 @@
 package handler
 
 import (
-	"net/http"
-	"strconv"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/poly-predict/backend/pkg/response"
+	"github.com/poly-predict/backend/services/api/internal/repository"
 	"github.com/poly-predict/backend/services/api/internal/service"
 )
 
@@ -22,28 +20,25 @@ func NewRankingHandler(service *service.RankingService) *RankingHandler {
 
 // GetRankings handles GET /api/v1/rankings
 func (h *RankingHandler) GetRankings(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize, _ := strconv.Atoi(c.Query("page_size"))
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
-
 	period := c.Query("period")
 	category := c.Query("category")
 	sortBy := c.Query("sort_by")
 
-	rankings, total, err := h.service.GetRankings(c.Request.Context(), period, category, sortBy, page, pageSize)
+	page, err := response.ParsePage(c, sortBy)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	rankings, total, next, err := h.service.GetRankings(c.Request.Context(), period, category, sortBy, page)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to get rankings")
+		response.Fail(c, err)
 		return
 	}
 
 	if rankings == nil {
-		rankings = []service.RankingEntry{}
+		rankings = []repository.RankingEntry{}
 	}
 
-	response.Paginated(c, rankings, total, page, pageSize)
+	response.List(c, rankings, page, total, next)
 }
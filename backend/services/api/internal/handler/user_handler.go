@@ -1,33 +1,51 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/poly-predict/backend/pkg/logging"
 	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/pkg/response"
 	"github.com/poly-predict/backend/services/api/internal/repository"
+	"github.com/poly-predict/backend/services/api/internal/service"
 )
 
-// updateProfileRequest is the JSON body for updating a user profile.
+// updateProfileRequest is the JSON body for updating a user profile. Omitted
+// fields are left unchanged.
 type updateProfileRequest struct {
-	DisplayName string `json:"display_name" binding:"required,min=1,max=100"`
+	DisplayName *string `json:"display_name"`
+	Handle      *string `json:"handle"`
+}
+
+// claimReferralRequest is the JSON body for claiming a referral code.
+type claimReferralRequest struct {
+	Code string `json:"code" binding:"required,max=16"`
 }
 
 // UserHandler handles user-related HTTP requests.
 type UserHandler struct {
-	repo *repository.UserRepository
+	profiles  *service.ProfileService
+	referrals *service.ReferralService
+	accounts  *service.AccountService
+	avatars   *service.AvatarService
 }
 
 // NewUserHandler creates a new UserHandler.
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(profiles *service.ProfileService, referrals *service.ReferralService, accounts *service.AccountService, avatars *service.AvatarService) *UserHandler {
+	return &UserHandler{profiles: profiles, referrals: referrals, accounts: accounts, avatars: avatars}
 }
 
 // GetProfile handles GET /api/v1/users/me
-// Auto-creates the user on first authenticated request with 10,000 credits.
+// Auto-creates the user on first authenticated request with the starting balance.
+// A ref query parameter on that first request claims a referral code; an
+// invalid code does not block sign-up.
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
@@ -35,22 +53,35 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	// Auto-create user with default display name if they don't exist yet.
-	user, err := h.repo.GetOrCreate(c.Request.Context(), userID, "Player")
+	// Auto-create user with a generated handle if they don't exist yet.
+	user, created, err := h.profiles.GetOrCreate(c.Request.Context(), userID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "failed to get user profile")
 		return
 	}
 
+	if code := c.Query("ref"); created && code != "" {
+		if _, err := h.referrals.Claim(c.Request.Context(), userID, code, c.ClientIP()); err != nil {
+			logging.Ctx(c.Request.Context()).Warn().Err(err).Msg("referral claim on sign-up failed")
+		}
+	}
+
 	if user == nil {
 		response.Error(c, http.StatusNotFound, "user not found")
 		return
 	}
 
+	if user.DeletedAt != nil {
+		response.Error(c, http.StatusGone, "account has been deleted")
+		return
+	}
+
 	response.Success(c, user)
 }
 
 // UpdateProfile handles PATCH /api/v1/users/me
+// Changes the display name and/or handle. Handles are unique regardless of
+// letter case and can only be changed once per cooldown period.
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
@@ -60,13 +91,18 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	var req updateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, "invalid request: "+err.Error())
+		response.BindError(c, err)
 		return
 	}
 
-	user, err := h.repo.UpdateDisplayName(c.Request.Context(), userID, req.DisplayName)
+	if req.DisplayName == nil && req.Handle == nil {
+		response.ValidationError(c, "display_name or handle is required")
+		return
+	}
+
+	user, err := h.profiles.Update(c.Request.Context(), userID, req.DisplayName, req.Handle)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to update profile")
+		response.Fail(c, err)
 		return
 	}
 
@@ -86,18 +122,15 @@ func (h *UserHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.Query("page"))
-	if page < 1 {
-		page = 1
-	}
-	pageSize, _ := strconv.Atoi(c.Query("page_size"))
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
 	}
 
-	transactions, total, err := h.repo.GetTransactions(c.Request.Context(), userID, page, pageSize)
+	transactions, total, next, err := h.profiles.Transactions(c.Request.Context(), userID, page)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "failed to get transactions")
+		response.Fail(c, err)
 		return
 	}
 
@@ -105,5 +138,141 @@ func (h *UserHandler) GetTransactions(c *gin.Context) {
 		transactions = []model.CreditTransaction{}
 	}
 
-	response.Paginated(c, transactions, total, page, pageSize)
+	response.List(c, transactions, page, total, next)
+}
+
+// ClaimReferral handles POST /api/v1/users/me/referral
+func (h *UserHandler) ClaimReferral(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req claimReferralRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	referral, err := h.referrals.Claim(c.Request.Context(), userID, req.Code, c.ClientIP())
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	response.Created(c, referral)
+}
+
+// ListReferrals handles GET /api/v1/users/me/referrals
+func (h *UserHandler) ListReferrals(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := response.ParsePage(c, "")
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	referrals, total, next, err := h.referrals.ListByReferrer(c.Request.Context(), userID, page)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	if referrals == nil {
+		referrals = []repository.ReferralProgress{}
+	}
+
+	response.List(c, referrals, page, total, next)
+}
+
+// ExportAccount handles POST /api/v1/users/me/export
+// Responds with a zip archive of the user's profile, bets, credit
+// transactions, rankings and referrals as JSON and CSV.
+func (h *UserHandler) ExportAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var buf bytes.Buffer
+	err := h.accounts.Export(c.Request.Context(), userID, &buf)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("poly-predict-export-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// DeleteAccount handles DELETE /api/v1/users/me
+// Anonymizes the user rather than removing the row, so their bets,
+// transactions and rankings keep a valid reference.
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := h.accounts.Delete(c.Request.Context(), userID)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UploadAvatar handles PUT /api/v1/users/me/avatar
+// Expects a multipart form with the image in the avatar field.
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Leave headroom over the image limit for the multipart envelope.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxAvatarBytes+64<<10)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Fail(c, service.ErrImageTooLarge)
+			return
+		}
+		response.ValidationError(c, "avatar file is required")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		response.ValidationError(c, "avatar file is unreadable")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxAvatarBytes+1))
+	if err != nil {
+		response.ValidationError(c, "avatar file is unreadable")
+		return
+	}
+
+	user, err := h.avatars.Upload(c.Request.Context(), userID, data)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+
+	response.Success(c, user)
 }
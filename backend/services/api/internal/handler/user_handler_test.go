@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/service"
+)
+
+// fakeProfiles serves profiles and ledgers from memory. Users missing from
+// the map are created on GetOrCreate.
+type fakeProfiles struct {
+	service.UserRepository
+	users map[string]*model.User
+}
+
+func (f fakeProfiles) GetOrCreate(ctx context.Context, id string) (*model.User, bool, error) {
+	if u, ok := f.users[id]; ok {
+		return u, false, nil
+	}
+	u := &model.User{ID: id, Handle: "player_1", DisplayName: "player_1", Balance: 1000}
+	f.users[id] = u
+	return u, true, nil
+}
+
+func (f fakeProfiles) GetTransactions(ctx context.Context, userID string, page db.Page) ([]model.CreditTransaction, int64, []string, error) {
+	return nil, 0, nil, nil
+}
+
+func TestGetProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deletedAt := time.Now()
+	users := fakeProfiles{users: map[string]*model.User{
+		"deleted": {ID: "deleted", DeletedAt: &deletedAt},
+	}}
+	h := NewUserHandler(service.NewProfileService(fakeTransactor{}, users, nil), nil, nil, nil)
+	router := gin.New()
+	router.GET("/users/me", func(c *gin.Context) {
+		c.Set("user_id", c.Query("as"))
+		h.GetProfile(c)
+	})
+	router.GET("/users/me/transactions", func(c *gin.Context) {
+		c.Set("user_id", c.Query("as"))
+		h.GetTransactions(c)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"new user is created", "/users/me?as=new", http.StatusOK},
+		{"deleted user", "/users/me?as=deleted", http.StatusGone},
+		{"unauthenticated", "/users/me", http.StatusUnauthorized},
+		{"empty ledger", "/users/me/transactions?as=new", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+	if users.users["new"] == nil {
+		t.Error("GetProfile did not create the new user")
+	}
+}
@@ -0,0 +1,108 @@
+// Package ratelimit provides in-memory token-bucket rate limiting for the API.
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/poly-predict/backend/pkg/response"
+)
+
+// sweepInterval is how often buckets that have refilled completely are
+// dropped, bounding memory to recently active clients.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter allows each key up to perMinute requests a minute, refilled
+// continuously, with bursts of up to a full minute's budget.
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a Limiter allowing perMinute requests per key per minute.
+func New(perMinute int) *Limiter {
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it reports
+// false and how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that would have refilled completely by now.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Middleware returns a Gin middleware enforcing the limits. Requests made
+// with a personal API key draw from that key's bucket in apiKeys; other
+// signed-in requests draw from the user's bucket in users, and anonymous
+// requests from the client address's. It must run after authentication.
+func Middleware(users, apiKeys *Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter, key := users, "ip:"+c.ClientIP()
+		if id := c.GetString("api_key_id"); id != "" {
+			limiter, key = apiKeys, "key:"+id
+		} else if id := c.GetString("user_id"); id != "" {
+			key = "user:" + id
+		}
+
+		if ok, wait := limiter.Allow(key); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "rate limit exceeded")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
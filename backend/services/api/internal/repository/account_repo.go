@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// DeletedDisplayName replaces the display name of an anonymized user.
+const DeletedDisplayName = "Deleted user"
+
+// AccountExport is everything stored about a user, as handed out by a
+// personal data export.
+type AccountExport struct {
+	ExportedAt   time.Time                 `json:"exported_at"`
+	Profile      model.User                `json:"profile"`
+	Bets         []model.Bet               `json:"bets"`
+	Transactions []model.CreditTransaction `json:"transactions"`
+	Rankings     []model.Ranking           `json:"rankings"`
+	Referrals    []model.Referral          `json:"referrals"`
+}
+
+// AccountRepository provides whole-account data access for exports and
+// deletion.
+type AccountRepository struct {
+	pool  *pgxpool.Pool
+	users *UserRepository
+}
+
+// NewAccountRepository creates a new AccountRepository.
+func NewAccountRepository(pool *pgxpool.Pool, users *UserRepository) *AccountRepository {
+	return &AccountRepository{pool: pool, users: users}
+}
+
+// Export collects a user's profile, bets, credit transactions, rankings and
+// referrals (made and received). It returns nil if the user does not exist.
+func (r *AccountRepository) Export(ctx context.Context, userID string) (*AccountExport, error) {
+	user, err := r.users.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	export := &AccountExport{
+		ExportedAt:   time.Now().UTC(),
+		Profile:      *user,
+		Bets:         []model.Bet{},
+		Transactions: []model.CreditTransaction{},
+		Rankings:     []model.Ranking{},
+		Referrals:    []model.Referral{},
+	}
+
+	// Bets.
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, user_id, event_id, outcome, amount, locked_odds, potential_payout,
+		        status, payout, settled_at, created_at
+		 FROM bets WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export bets: %w", err)
+	}
+	export.Bets, err = collect(rows, func(row pgx.Rows, b *model.Bet) error {
+		return row.Scan(&b.ID, &b.UserID, &b.EventID, &b.Outcome, &b.Amount, &b.LockedOdds,
+			&b.PotentialPayout, &b.Status, &b.Payout, &b.SettledAt, &b.CreatedAt)
+	}, export.Bets)
+	if err != nil {
+		return nil, fmt.Errorf("export bets: %w", err)
+	}
+
+	// Credit transactions.
+	rows, err = r.pool.Query(ctx,
+		`SELECT id, user_id, type, amount, balance_after, reference_id, description, created_at
+		 FROM credit_transactions WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export transactions: %w", err)
+	}
+	export.Transactions, err = collect(rows, func(row pgx.Rows, t *model.CreditTransaction) error {
+		return row.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter,
+			&t.ReferenceID, &t.Description, &t.CreatedAt)
+	}, export.Transactions)
+	if err != nil {
+		return nil, fmt.Errorf("export transactions: %w", err)
+	}
+
+	// Rankings.
+	rows, err = r.pool.Query(ctx,
+		`SELECT id, user_id, period, COALESCE(category, ''), total_assets, total_profit,
+		        win_count, loss_count, win_rate, roi, consecutive_wins,
+		        COALESCE(rank_position, 0), calculated_at
+		 FROM rankings WHERE user_id = $1 ORDER BY calculated_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export rankings: %w", err)
+	}
+	export.Rankings, err = collect(rows, func(row pgx.Rows, k *model.Ranking) error {
+		return row.Scan(&k.ID, &k.UserID, &k.Period, &k.Category, &k.TotalAssets, &k.TotalProfit,
+			&k.WinCount, &k.LossCount, &k.WinRate, &k.ROI, &k.ConsecutiveWins,
+			&k.RankPosition, &k.CalculatedAt)
+	}, export.Rankings)
+	if err != nil {
+		return nil, fmt.Errorf("export rankings: %w", err)
+	}
+
+	// Referrals.
+	rows, err = r.pool.Query(ctx,
+		`SELECT id, referrer_id, referee_id, code, status, rewarded_at, created_at
+		 FROM referrals WHERE referrer_id = $1 OR referee_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export referrals: %w", err)
+	}
+	export.Referrals, err = collect(rows, func(row pgx.Rows, f *model.Referral) error {
+		return row.Scan(&f.ID, &f.ReferrerID, &f.RefereeID, &f.Code, &f.Status, &f.RewardedAt, &f.CreatedAt)
+	}, export.Referrals)
+	if err != nil {
+		return nil, fmt.Errorf("export referrals: %w", err)
+	}
+
+	return export, nil
+}
+
+// Anonymize turns a user into a tombstone: personal fields are cleared, the
+// handle is replaced so it can be taken again, the referral code is replaced
+// with one no lookup can match, API keys are revoked and
+// deleted_at is set, while the row itself stays so bets, transactions and
+// rankings keep a valid reference. It reports false if the user does not
+// exist or was already deleted.
+func (r *AccountRepository) Anonymize(ctx context.Context, userID string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE users
+		 SET display_name = $2, handle = 'deleted_' || substr(md5(gen_random_uuid()::text), 1, 12),
+		     -- Lookups upper-case the code they are given, so a lower-case
+		     -- code can never be claimed.
+		     referral_code = 'deleted_' || substr(md5(gen_random_uuid()::text), 1, 8),
+		     avatar_url = NULL, deleted_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NULL`,
+		userID, DeletedDisplayName,
+	)
+	if err != nil {
+		return false, fmt.Errorf("anonymize user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return false, fmt.Errorf("revoke api keys: %w", err)
+	}
+
+	// Claim addresses are only kept for farming detection.
+	_, err = tx.Exec(ctx,
+		`UPDATE referrals SET claim_ip = NULL WHERE referrer_id = $1 OR referee_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("anonymize referrals: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// collect scans every row into a new T and appends it to dst.
+func collect[T any](rows pgx.Rows, scan func(pgx.Rows, *T) error, dst []T) ([]T, error) {
+	defer rows.Close()
+	for rows.Next() {
+		var v T
+		if err := scan(rows, &v); err != nil {
+			return nil, err
+		}
+		dst = append(dst, v)
+	}
+	return dst, rows.Err()
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// APIKeyRepository provides database access for personal API keys.
+type APIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(pool *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{pool: pool}
+}
+
+// Create stores a new key for key.UserID, identified by the SHA-256 hex
+// digest of its secret, unless the user already holds limit active keys. It
+// returns nil if the limit is reached, and an error wrapping pgx.ErrNoRows if
+// the user does not exist.
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey, hash string, limit int) (*model.APIKey, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user so concurrent requests cannot both pass the limit.
+	var active int
+	err = tx.QueryRow(ctx,
+		`SELECT (SELECT COUNT(*) FROM api_keys WHERE user_id = u.id AND revoked_at IS NULL)
+		 FROM users u WHERE u.id = $1 AND u.deleted_at IS NULL FOR UPDATE`,
+		key.UserID,
+	).Scan(&active)
+	if err != nil {
+		return nil, fmt.Errorf("count api keys: %w", err)
+	}
+	if active >= limit {
+		return nil, nil
+	}
+
+	created := *key
+	err = tx.QueryRow(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		key.UserID, key.Name, key.Prefix, hash, scopeStrings(key.Scopes),
+	).Scan(&created.ID, &created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create api key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return &created, nil
+}
+
+// ListByUser returns the user's active keys, newest first.
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, user_id, name, prefix, scopes, last_used_at, created_at
+		 FROM api_keys
+		 WHERE user_id = $1 AND revoked_at IS NULL
+		 ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var k model.APIKey
+		var scopes []string
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.LastUsedAt, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		k.Scopes = toScopes(scopes)
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes one of the user's keys. It reports false if the user has no
+// active key with that ID.
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("revoke api key: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetActiveByHash returns the active key with the given hash, or nil if there
+// is none or its owner has been deleted.
+func (r *APIKeyRepository) GetActiveByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var k model.APIKey
+	var scopes []string
+	err := r.pool.QueryRow(ctx,
+		`SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.last_used_at, k.created_at
+		 FROM api_keys k
+		 JOIN users u ON u.id = k.user_id
+		 WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND u.deleted_at IS NULL`,
+		hash,
+	).Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.LastUsedAt, &k.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	k.Scopes = toScopes(scopes)
+	return &k, nil
+}
+
+// TouchLastUsed records that the key was used, skipping the write if it was
+// already recorded within resolution.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, resolution time.Duration) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE api_keys SET last_used_at = NOW()
+		 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - $2::interval)`,
+		id, resolution,
+	)
+	if err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
+
+func scopeStrings(scopes []model.APIKeyScope) []string {
+	out := make([]string, len(scopes))
+	for i, s := range scopes {
+		out[i] = string(s)
+	}
+	return out
+}
+
+func toScopes(scopes []string) []model.APIKeyScope {
+	out := make([]model.APIKeyScope, len(scopes))
+	for i, s := range scopes {
+		out[i] = model.APIKeyScope(s)
+	}
+	return out
+}
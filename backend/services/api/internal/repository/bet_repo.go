@@ -7,9 +7,33 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 )
 
+// OutcomePosition aggregates a user's bets on a single outcome of an event.
+type OutcomePosition struct {
+	Outcome         string `json:"outcome"`
+	BetCount        int    `json:"bet_count"`
+	Stake           int64  `json:"stake"`
+	PotentialPayout int64  `json:"potential_payout"`
+	Payout          int64  `json:"payout"`
+	Pending         int    `json:"pending"`
+}
+
+// Position aggregates a user's bets on an event across all outcomes.
+// PotentialPayout covers pending bets only and Payout covers settled bets only.
+// Result is "pending" while any bet is unsettled, "refunded" if every bet
+// was refunded because the market was voided, and otherwise "won" or "lost"
+// depending on whether settled payouts exceeded the stake.
+type Position struct {
+	Outcomes        []OutcomePosition `json:"outcomes"`
+	Stake           int64             `json:"stake"`
+	PotentialPayout int64             `json:"potential_payout"`
+	Payout          int64             `json:"payout"`
+	Result          string            `json:"result"`
+}
+
 // BetRepository provides database access for bets.
 type BetRepository struct {
 	pool *pgxpool.Pool
@@ -20,12 +44,12 @@ func NewBetRepository(pool *pgxpool.Pool) *BetRepository {
 	return &BetRepository{pool: pool}
 }
 
-// Create inserts a new bet into the database.
-func (r *BetRepository) Create(ctx context.Context, bet *model.Bet) error {
+// Create inserts a new bet within tx.
+func (r *BetRepository) Create(ctx context.Context, tx pgx.Tx, bet *model.Bet) error {
 	query := `INSERT INTO bets (id, user_id, event_id, outcome, amount, locked_odds, potential_payout, status, created_at)
 	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := tx.Exec(ctx, query,
 		bet.ID, bet.UserID, bet.EventID, bet.Outcome, bet.Amount,
 		bet.LockedOdds, bet.PotentialPayout, bet.Status, bet.CreatedAt,
 	)
@@ -36,8 +60,13 @@ func (r *BetRepository) Create(ctx context.Context, bet *model.Bet) error {
 	return nil
 }
 
-// ListByUser retrieves a paginated list of bets for a given user, optionally filtered by status.
-func (r *BetRepository) ListByUser(ctx context.Context, userID string, status string, page, pageSize int) ([]model.Bet, int64, error) {
+// betKeyset orders a user's bets newest first.
+var betKeyset = db.Keyset{{Expr: "created_at", Desc: true}, {Expr: "id", Desc: true}}
+
+// ListByUser retrieves a page of bets for a given user, optionally filtered by
+// status. In keyset mode the total is not counted and the cursor values for
+// the next page are returned instead.
+func (r *BetRepository) ListByUser(ctx context.Context, userID string, status string, page db.Page) ([]model.Bet, int64, []string, error) {
 	whereClause := "WHERE user_id = $1"
 	args := []interface{}{userID}
 	argIdx := 2
@@ -48,49 +77,64 @@ func (r *BetRepository) ListByUser(ctx context.Context, userID string, status st
 		argIdx++
 	}
 
-	// Count.
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM bets %s", whereClause)
 	var total int64
-	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("count bets: %w", err)
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := betKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			whereClause += " AND " + after
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		// Count.
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM bets %s", whereClause)
+		err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("count bets: %w", err)
+		}
 	}
 
 	// Data.
-	offset := (page - 1) * pageSize
 	dataQuery := fmt.Sprintf(
 		`SELECT id, user_id, event_id, outcome, amount, locked_odds, potential_payout,
-		        status, payout, settled_at, created_at
-		 FROM bets %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`,
-		whereClause, argIdx, argIdx+1,
+		        status, payout, settled_at, created_at %s
+		 FROM bets %s %s LIMIT $%d OFFSET $%d`,
+		betKeyset.Select(), whereClause, betKeyset.OrderBy(), argIdx, argIdx+1,
 	)
-	args = append(args, pageSize, offset)
+	args = append(args, page.FetchLimit(), page.Offset)
 
 	rows, err := r.pool.Query(ctx, dataQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("list bets: %w", err)
+		return nil, 0, nil, fmt.Errorf("list bets: %w", page.CursorError(err))
 	}
 	defer rows.Close()
 
 	var bets []model.Bet
+	var cursors [][]string
 	for rows.Next() {
 		var b model.Bet
-		err := rows.Scan(
+		cur := make([]string, len(betKeyset))
+		dest := append([]interface{}{
 			&b.ID, &b.UserID, &b.EventID, &b.Outcome, &b.Amount,
 			&b.LockedOdds, &b.PotentialPayout, &b.Status, &b.Payout,
 			&b.SettledAt, &b.CreatedAt,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("scan bet: %w", err)
+		}, betKeyset.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan bet: %w", err)
 		}
 		bets = append(bets, b)
+		cursors = append(cursors, cur)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("iterate bets: %w", err)
+		return nil, 0, nil, fmt.Errorf("iterate bets: %w", page.CursorError(err))
 	}
 
-	return bets, total, nil
+	bets, next := db.TrimPage(page, bets, cursors)
+	return bets, total, next, nil
 }
 
 // GetByID retrieves a single bet by its ID.
@@ -114,3 +158,73 @@ func (r *BetRepository) GetByID(ctx context.Context, id string) (*model.Bet, err
 
 	return &b, nil
 }
+
+// PositionsByEvents aggregates the user's bets on each of the given events.
+// Events the user has not bet on are absent from the returned map.
+func (r *BetRepository) PositionsByEvents(ctx context.Context, userID string, eventIDs []string) (map[string]*Position, error) {
+	positions := make(map[string]*Position)
+	if userID == "" || len(eventIDs) == 0 {
+		return positions, nil
+	}
+
+	query := `SELECT event_id, outcome, COUNT(*), SUM(amount),
+	                 COALESCE(SUM(potential_payout) FILTER (WHERE status = 'pending'), 0),
+	                 COALESCE(SUM(payout) FILTER (WHERE status <> 'pending'), 0),
+	                 COUNT(*) FILTER (WHERE status = 'pending'),
+	                 COUNT(*) FILTER (WHERE status = 'refunded')
+	          FROM bets
+	          WHERE user_id = $1 AND event_id = ANY($2)
+	          GROUP BY event_id, outcome
+	          ORDER BY event_id, outcome`
+
+	rows, err := r.pool.Query(ctx, query, userID, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate positions: %w", err)
+	}
+	defer rows.Close()
+
+	// Bets on each event that were not refunded.
+	unrefunded := make(map[string]int)
+	for rows.Next() {
+		var eventID string
+		var op OutcomePosition
+		var refunded int
+		err := rows.Scan(&eventID, &op.Outcome, &op.BetCount, &op.Stake, &op.PotentialPayout, &op.Payout, &op.Pending, &refunded)
+		if err != nil {
+			return nil, fmt.Errorf("scan position: %w", err)
+		}
+
+		p, ok := positions[eventID]
+		if !ok {
+			p = &Position{}
+			positions[eventID] = p
+		}
+		p.Outcomes = append(p.Outcomes, op)
+		p.Stake += op.Stake
+		p.PotentialPayout += op.PotentialPayout
+		p.Payout += op.Payout
+		unrefunded[eventID] += op.BetCount - refunded
+		if op.Pending > 0 {
+			p.Result = "pending"
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate positions: %w", err)
+	}
+
+	for eventID, p := range positions {
+		if p.Result == "pending" {
+			continue
+		}
+		if unrefunded[eventID] == 0 {
+			p.Result = "refunded"
+		} else if p.Payout > p.Stake {
+			p.Result = "won"
+		} else {
+			p.Result = "lost"
+		}
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BlocklistRepository provides read access to the name blocklist, which is
+// managed from the admin service.
+type BlocklistRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBlocklistRepository creates a new BlocklistRepository.
+func NewBlocklistRepository(pool *pgxpool.Pool) *BlocklistRepository {
+	return &BlocklistRepository{pool: pool}
+}
+
+// Terms returns every blocked term in normalized form.
+func (r *BlocklistRepository) Terms(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, "SELECT term FROM name_blocklist")
+	if err != nil {
+		return nil, fmt.Errorf("list blocked names: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("scan blocked name: %w", err)
+		}
+		terms = append(terms, term)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate blocked names: %w", err)
+	}
+
+	return terms, nil
+}
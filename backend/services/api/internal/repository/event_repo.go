@@ -2,12 +2,16 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 )
 
@@ -17,8 +21,10 @@ type EventFilters struct {
 	Category string
 	Search   string
 	Sort     string
-	Page     int
-	PageSize int
+	Page     db.Page
+
+	// BettorID restricts results to events the given user has bet on.
+	BettorID string
 }
 
 // CategoryCount holds a category name and its event count.
@@ -27,18 +33,29 @@ type CategoryCount struct {
 	Count    int64  `json:"count"`
 }
 
+// EventSuggestion is a minimal event projection used for typeahead search.
+type EventSuggestion struct {
+	ID       string  `json:"id"`
+	Question string  `json:"question"`
+	ImageURL *string `json:"image_url"`
+}
+
 // EventRepository provides database access for events.
 type EventRepository struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	retention PriceRetention
 }
 
-// NewEventRepository creates a new EventRepository.
-func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
-	return &EventRepository{pool: pool}
+// NewEventRepository creates a new EventRepository. retention must match the
+// scraper's price history retention policy.
+func NewEventRepository(pool *pgxpool.Pool, retention PriceRetention) *EventRepository {
+	return &EventRepository{pool: pool, retention: retention}
 }
 
-// List retrieves a paginated list of events with optional filters.
-func (r *EventRepository) List(ctx context.Context, filters EventFilters) ([]model.Event, int64, error) {
+// List retrieves a page of events with optional filters. In keyset mode the
+// total is not counted and the cursor values for the next page are returned
+// instead.
+func (r *EventRepository) List(ctx context.Context, filters EventFilters) ([]model.Event, int64, []string, error) {
 	var conditions []string
 	var args []interface{}
 	argIdx := 1
@@ -55,78 +72,123 @@ func (r *EventRepository) List(ctx context.Context, filters EventFilters) ([]mod
 		argIdx++
 	}
 
-	if filters.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("question ILIKE $%d", argIdx))
-		args = append(args, "%"+filters.Search+"%")
+	if filters.BettorID != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM bets b WHERE b.event_id = events.id AND b.user_id = $%d)", argIdx,
+		))
+		args = append(args, filters.BettorID)
 		argIdx++
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	// Full-text search with prefix matching on the last term. searchIdx
+	// records the placeholder of the tsquery so relevance sorting can reuse it.
+	searchIdx := 0
+	if tsQuery := buildPrefixTSQuery(filters.Search); tsQuery != "" {
+		conditions = append(conditions, fmt.Sprintf("search_vector @@ to_tsquery('english', $%d)", argIdx))
+		args = append(args, tsQuery)
+		searchIdx = argIdx
+		argIdx++
 	}
 
-	// Count query.
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM events %s", whereClause)
-	var total int64
-	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("count events: %w", err)
-	}
+	keys := eventKeyset(filters.Sort, searchIdx)
+	page := filters.Page
 
-	// Sort clause.
-	orderClause := "ORDER BY CASE WHEN end_date > NOW() THEN 0 ELSE 1 END, volume_24h DESC"
-	switch filters.Sort {
-	case "trending":
-		orderClause = "ORDER BY CASE WHEN end_date > NOW() THEN 0 ELSE 1 END, volume_24h DESC"
-	case "volume":
-		orderClause = "ORDER BY volume DESC"
-	case "volume_24h":
-		orderClause = "ORDER BY volume_24h DESC"
-	case "liquidity":
-		orderClause = "ORDER BY liquidity DESC"
-	case "newest":
-		orderClause = "ORDER BY created_at DESC"
-	case "ending_soon":
-		orderClause = "ORDER BY CASE WHEN end_date > NOW() THEN 0 ELSE 1 END, end_date ASC NULLS LAST"
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := keys.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			conditions = append(conditions, after)
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		// Count query.
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM events %s", whereSQL(conditions))
+		err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("count events: %w", err)
+		}
 	}
 
-	offset := (filters.Page - 1) * filters.PageSize
 	dataQuery := fmt.Sprintf(
 		`SELECT id, polymarket_event_id, slug, question, description, category, image_url,
 		        outcomes, outcome_prices, clob_token_ids, status, resolved_outcome, resolved_at,
-		        volume, volume_24h, liquidity, end_date, created_at, updated_at, synced_at
+		        volume, volume_24h, liquidity, end_date, created_at, updated_at, synced_at %s
 		 FROM events %s %s LIMIT $%d OFFSET $%d`,
-		whereClause, orderClause, argIdx, argIdx+1,
+		keys.Select(), whereSQL(conditions), keys.OrderBy(), argIdx, argIdx+1,
 	)
-	args = append(args, filters.PageSize, offset)
+	args = append(args, page.FetchLimit(), page.Offset)
 
 	rows, err := r.pool.Query(ctx, dataQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("list events: %w", err)
+		return nil, 0, nil, fmt.Errorf("list events: %w", page.CursorError(err))
 	}
 	defer rows.Close()
 
 	var events []model.Event
+	var cursors [][]string
 	for rows.Next() {
 		var e model.Event
-		err := rows.Scan(
+		cur := make([]string, len(keys))
+		dest := append([]interface{}{
 			&e.ID, &e.PolymarketEventID, &e.Slug, &e.Question, &e.Description, &e.Category,
 			&e.ImageURL, &e.Outcomes, &e.OutcomePrices, &e.ClobTokenIDs, &e.Status,
 			&e.ResolvedOutcome, &e.ResolvedAt, &e.Volume, &e.Volume24h, &e.Liquidity,
 			&e.EndDate, &e.CreatedAt, &e.UpdatedAt, &e.SyncedAt,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("scan event: %w", err)
+		}, keys.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan event: %w", err)
 		}
 		events = append(events, e)
+		cursors = append(cursors, cur)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("iterate events: %w", err)
+		return nil, 0, nil, fmt.Errorf("iterate events: %w", page.CursorError(err))
 	}
 
-	return events, total, nil
+	events, next := db.TrimPage(page, events, cursors)
+	return events, total, next, nil
+}
+
+// eventKeyset returns the ordering for a sort mode. Every ordering ends with
+// the primary key so it is total and usable for keyset pagination.
+// searchIdx is the placeholder of the search tsquery, or 0 if none.
+func eventKeyset(sort string, searchIdx int) db.Keyset {
+	const openFirst = "CASE WHEN end_date > NOW() THEN 0 ELSE 1 END"
+	id := db.SortKey{Expr: "id"}
+
+	switch sort {
+	case "volume":
+		return db.Keyset{{Expr: "volume", Desc: true}, id}
+	case "volume_24h":
+		return db.Keyset{{Expr: "volume_24h", Desc: true}, id}
+	case "liquidity":
+		return db.Keyset{{Expr: "liquidity", Desc: true}, id}
+	case "newest":
+		return db.Keyset{{Expr: "created_at", Desc: true}, id}
+	case "ending_soon":
+		return db.Keyset{{Expr: openFirst}, {Expr: "COALESCE(end_date, 'infinity')"}, id}
+	case "relevance":
+		if searchIdx > 0 {
+			rank := fmt.Sprintf("ts_rank(search_vector, to_tsquery('english', $%d))", searchIdx)
+			return db.Keyset{{Expr: rank, Desc: true}, {Expr: "volume_24h", Desc: true}, id}
+		}
+	}
+
+	// Default and "trending".
+	return db.Keyset{{Expr: openFirst}, {Expr: "volume_24h", Desc: true}, id}
+}
+
+// whereSQL joins conditions into a WHERE clause, or returns "" if there are none.
+func whereSQL(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
 }
 
 // GetByID retrieves a single event by its ID.
@@ -153,29 +215,146 @@ func (r *EventRepository) GetByID(ctx context.Context, id string) (*model.Event,
 	return &e, nil
 }
 
-// GetPriceHistory retrieves the price history for an event, filtered by period.
-// Period can be: 1h, 6h, 24h, 7d, 30d.
+// GetMarket retrieves the status, outcomes and outcome prices of an event
+// within tx, the fields needed to price a bet. It returns nil if the event
+// does not exist.
+func (r *EventRepository) GetMarket(ctx context.Context, tx pgx.Tx, id string) (*model.Event, error) {
+	e := model.Event{ID: id}
+	err := tx.QueryRow(ctx,
+		"SELECT status, outcomes, outcome_prices FROM events WHERE id = $1", id,
+	).Scan(&e.Status, &e.Outcomes, &e.OutcomePrices)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get event market: %w", err)
+	}
+
+	return &e, nil
+}
+
+// PricePeriods maps the supported price history look-back periods to their
+// durations.
+var PricePeriods = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+	"1y":  365 * 24 * time.Hour,
+}
+
+// CandleIntervals maps the supported candle widths to their durations.
+var CandleIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// ErrResolutionUnavailable is returned when a candle interval is finer than
+// the stored resolution of the look-back window it was requested for.
+var ErrResolutionUnavailable = errors.New("price resolution unavailable for period")
+
+// Candle is an open/high/low/close summary of one outcome's price over a
+// fixed-width time bucket.
+type Candle struct {
+	OutcomeLabel string    `json:"-"`
+	BucketStart  time.Time `json:"bucket_start"`
+	Open         float64   `json:"open"`
+	High         float64   `json:"high"`
+	Low          float64   `json:"low"`
+	Close        float64   `json:"close"`
+	Samples      int       `json:"samples"`
+}
+
+// priceTier is one resolution at which price history is stored. The scraper
+// rolls raw points into hourly buckets and hourly buckets into daily ones.
+type priceTier struct {
+	table string
+	width time.Duration // zero for raw points
+}
+
+var (
+	rawTier    = &priceTier{table: "price_history"}
+	hourlyTier = &priceTier{table: "price_history_hourly", width: time.Hour}
+	dailyTier  = &priceTier{table: "price_history_daily", width: 24 * time.Hour}
+)
+
+// columns renders the tier as (id, outcome_label, ts, open, high, low, close,
+// samples). A raw point is a one-sample candle; rollups have no id.
+func (t *priceTier) columns() string {
+	if t.width == 0 {
+		return "id, outcome_label, recorded_at AS ts, price AS open, price AS high, price AS low, price AS close, 1 AS samples"
+	}
+	return "0::bigint AS id, outcome_label, bucket_start AS ts, open, high, low, close, samples"
+}
+
+func (t *priceTier) timeColumn() string {
+	if t.width == 0 {
+		return "recorded_at"
+	}
+	return "bucket_start"
+}
+
+// PriceRetention describes how long each price history tier is kept, so
+// reads can be routed to the finest tier that still covers a period.
+type PriceRetention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+}
+
+// tiersFor returns the tier covering lookback and the next finer tier, whose
+// points newer than the coarse tier's latest bucket fill in the tail that
+// has not been rolled up yet. finer is nil for the raw tier.
+func (p PriceRetention) tiersFor(lookback time.Duration) (tier, finer *priceTier) {
+	switch {
+	case lookback <= p.Raw:
+		return rawTier, nil
+	case lookback <= p.Hourly:
+		return hourlyTier, rawTier
+	default:
+		return dailyTier, hourlyTier
+	}
+}
+
+// priceSource renders a subquery over the tiers for lookback, yielding the
+// columns described by priceTier.columns for event $1 over NOW() - $2.
+func (p PriceRetention) priceSource(lookback time.Duration) string {
+	tier, finer := p.tiersFor(lookback)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE event_id = $1 AND %s >= NOW() - $2::interval`,
+		tier.columns(), tier.table, tier.timeColumn())
+	if finer == nil {
+		return query
+	}
+
+	return query + fmt.Sprintf(`
+	          UNION ALL
+	          SELECT %s FROM %s
+	          WHERE event_id = $1 AND %s >= GREATEST(NOW() - $2::interval,
+	              (SELECT COALESCE(MAX(bucket_start) + INTERVAL '%d seconds', '-infinity') FROM %s WHERE event_id = $1))`,
+		finer.columns(), finer.table, finer.timeColumn(), int(tier.width.Seconds()), tier.table)
+}
+
+// GetPriceHistory retrieves the price history for an event, filtered by
+// period (a key of PricePeriods). Periods beyond the raw retention window
+// are served from the hourly or daily rollups, using each bucket's closing
+// price; such points have a zero ID.
 func (r *EventRepository) GetPriceHistory(ctx context.Context, eventID string, period string) ([]model.PriceHistory, error) {
-	interval := "24 hours" // default
-	switch period {
-	case "1h":
-		interval = "1 hour"
-	case "6h":
-		interval = "6 hours"
-	case "24h":
-		interval = "24 hours"
-	case "7d":
-		interval = "7 days"
-	case "30d":
-		interval = "30 days"
-	}
-
-	query := `SELECT id, event_id, outcome_label, price, recorded_at
-	          FROM price_history
-	          WHERE event_id = $1 AND recorded_at >= NOW() - $2::interval
-	          ORDER BY recorded_at ASC`
-
-	rows, err := r.pool.Query(ctx, query, eventID, interval)
+	lookback, ok := PricePeriods[period]
+	if !ok {
+		lookback = PricePeriods["24h"] // default
+	}
+
+	query := `SELECT id, outcome_label, close, ts
+	          FROM (` + r.retention.priceSource(lookback) + `) p
+	          ORDER BY ts ASC`
+
+	rows, err := r.pool.Query(ctx, query, eventID, lookback)
 	if err != nil {
 		return nil, fmt.Errorf("get price history: %w", err)
 	}
@@ -183,8 +362,8 @@ func (r *EventRepository) GetPriceHistory(ctx context.Context, eventID string, p
 
 	var history []model.PriceHistory
 	for rows.Next() {
-		var ph model.PriceHistory
-		err := rows.Scan(&ph.ID, &ph.EventID, &ph.OutcomeLabel, &ph.Price, &ph.RecordedAt)
+		ph := model.PriceHistory{EventID: eventID}
+		err := rows.Scan(&ph.ID, &ph.OutcomeLabel, &ph.Price, &ph.RecordedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan price history: %w", err)
 		}
@@ -198,6 +377,146 @@ func (r *EventRepository) GetPriceHistory(ctx context.Context, eventID string, p
 	return history, nil
 }
 
+// GetCandles aggregates an event's price history into fixed-width OHLC
+// buckets per outcome over the look-back window. Buckets are aligned to the
+// Unix epoch so they line up across requests. Empty buckets are omitted.
+// ErrResolutionUnavailable is returned when interval is finer than the tier
+// holding the window.
+func (r *EventRepository) GetCandles(ctx context.Context, eventID string, interval, lookback time.Duration) ([]Candle, error) {
+	if tier, _ := r.retention.tiersFor(lookback); interval < tier.width {
+		return nil, ErrResolutionUnavailable
+	}
+
+	query := `SELECT outcome_label, bucket,
+	                 (array_agg(open ORDER BY ts ASC))[1],
+	                 MAX(high), MIN(low),
+	                 (array_agg(close ORDER BY ts DESC))[1],
+	                 SUM(samples)
+	          FROM (
+	              SELECT outcome_label, ts, open, high, low, close, samples,
+	                     date_bin($3::interval, ts, TIMESTAMPTZ '1970-01-01 00:00:00+00') AS bucket
+	              FROM (` + r.retention.priceSource(lookback) + `) src
+	          ) p
+	          GROUP BY outcome_label, bucket
+	          ORDER BY outcome_label, bucket`
+
+	rows, err := r.pool.Query(ctx, query, eventID, lookback, interval)
+	if err != nil {
+		return nil, fmt.Errorf("get candles: %w", err)
+	}
+	defer rows.Close()
+
+	var candles []Candle
+	for rows.Next() {
+		var cd Candle
+		err := rows.Scan(&cd.OutcomeLabel, &cd.BucketStart, &cd.Open, &cd.High, &cd.Low, &cd.Close, &cd.Samples)
+		if err != nil {
+			return nil, fmt.Errorf("scan candle: %w", err)
+		}
+		candles = append(candles, cd)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate candles: %w", err)
+	}
+
+	return candles, nil
+}
+
+// ListByGroups retrieves the markets belonging to each of the given groups,
+// keyed by group ID and ordered by 24h volume within each group.
+func (r *EventRepository) ListByGroups(ctx context.Context, groupIDs []string) (map[string][]model.Event, error) {
+	markets := make(map[string][]model.Event)
+	if len(groupIDs) == 0 {
+		return markets, nil
+	}
+
+	query := `SELECT id, polymarket_event_id, slug, question, description, category, image_url,
+	                 outcomes, outcome_prices, clob_token_ids, status, resolved_outcome, resolved_at,
+	                 volume, volume_24h, liquidity, end_date, created_at, updated_at, synced_at
+	          FROM events
+	          WHERE polymarket_event_id = ANY($1)
+	          ORDER BY volume_24h DESC, id`
+
+	rows, err := r.pool.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list events by groups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.Event
+		err := rows.Scan(
+			&e.ID, &e.PolymarketEventID, &e.Slug, &e.Question, &e.Description, &e.Category,
+			&e.ImageURL, &e.Outcomes, &e.OutcomePrices, &e.ClobTokenIDs, &e.Status,
+			&e.ResolvedOutcome, &e.ResolvedAt, &e.Volume, &e.Volume24h, &e.Liquidity,
+			&e.EndDate, &e.CreatedAt, &e.UpdatedAt, &e.SyncedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		markets[e.PolymarketEventID] = append(markets[e.PolymarketEventID], e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate events: %w", err)
+	}
+
+	return markets, nil
+}
+
+// Suggest returns lightweight matches for search-as-you-type. Results are
+// ranked by relevance and limited to open events.
+func (r *EventRepository) Suggest(ctx context.Context, search string, limit int) ([]EventSuggestion, error) {
+	tsQuery := buildPrefixTSQuery(search)
+	if tsQuery == "" {
+		return nil, nil
+	}
+
+	query := `SELECT id, question, image_url
+	          FROM events
+	          WHERE status = 'open' AND search_vector @@ to_tsquery('english', $1)
+	          ORDER BY ts_rank(search_vector, to_tsquery('english', $1)) DESC, volume_24h DESC
+	          LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("suggest events: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []EventSuggestion
+	for rows.Next() {
+		var es EventSuggestion
+		if err := rows.Scan(&es.ID, &es.Question, &es.ImageURL); err != nil {
+			return nil, fmt.Errorf("scan suggestion: %w", err)
+		}
+		suggestions = append(suggestions, es)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// buildPrefixTSQuery turns free text into a to_tsquery expression that ANDs
+// every word together. The final word is prefix-matched so partially typed
+// input still finds results. Punctuation is stripped so user input can never
+// produce a tsquery syntax error. Returns "" if no searchable words remain.
+func buildPrefixTSQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " & ")
+}
+
 // GetCategories retrieves all distinct categories with their event counts.
 func (r *EventRepository) GetCategories(ctx context.Context) ([]CategoryCount, error) {
 	query := `SELECT category, COUNT(*) as count
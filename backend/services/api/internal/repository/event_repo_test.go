@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   string
+	}{
+		{"single word", "trump", "trump:*"},
+		{"multiple words", "fed rate cut", "fed & rate & cut:*"},
+		{"mixed case", "Bitcoin ETF", "bitcoin & etf:*"},
+		{"strips tsquery operators", "btc & !eth | (sol)", "btc & eth & sol:*"},
+		{"strips quotes and colons", `"election":* 2028`, "election & 2028:*"},
+		{"extra whitespace", "  super   bowl  ", "super & bowl:*"},
+		{"empty", "", ""},
+		{"punctuation only", "&|!():*", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPrefixTSQuery(tt.search)
+			if got != tt.want {
+				t.Errorf("buildPrefixTSQuery(%q) = %q, want %q", tt.search, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceRetentionTiersFor(t *testing.T) {
+	day := 24 * time.Hour
+	retention := PriceRetention{Raw: 14 * day, Hourly: 180 * day}
+
+	tests := []struct {
+		name      string
+		lookback  time.Duration
+		wantTier  *priceTier
+		wantFiner *priceTier
+	}{
+		{"within raw retention", 7 * day, rawTier, nil},
+		{"at raw retention", 14 * day, rawTier, nil},
+		{"beyond raw retention", 30 * day, hourlyTier, rawTier},
+		{"beyond hourly retention", 365 * day, dailyTier, hourlyTier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier, finer := retention.tiersFor(tt.lookback)
+			if tier != tt.wantTier || finer != tt.wantFiner {
+				t.Errorf("tiersFor(%v) = (%v, %v), want (%v, %v)", tt.lookback, tier, finer, tt.wantTier, tt.wantFiner)
+			}
+		})
+	}
+}
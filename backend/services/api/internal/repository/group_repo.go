@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// groupKeyset orders market groups by 24h volume, busiest first.
+var groupKeyset = db.Keyset{{Expr: "volume_24h", Desc: true}, {Expr: "id"}}
+
+// GroupRepository provides database access for market groups.
+type GroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewGroupRepository creates a new GroupRepository.
+func NewGroupRepository(pool *pgxpool.Pool) *GroupRepository {
+	return &GroupRepository{pool: pool}
+}
+
+// List retrieves a page of market groups, optionally filtered by tag label.
+// In keyset mode the total is not counted and the cursor values for the next
+// page are returned instead.
+func (r *GroupRepository) List(ctx context.Context, tag string, page db.Page) ([]model.MarketGroup, int64, []string, error) {
+	var conditions []string
+	var args []interface{}
+	argIdx := 1
+
+	if tag != "" {
+		conditions = append(conditions, fmt.Sprintf("tags ? $%d", argIdx))
+		args = append(args, tag)
+		argIdx++
+	}
+
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := groupKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			conditions = append(conditions, after)
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM market_groups %s", whereSQL(conditions))
+		if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, nil, fmt.Errorf("count groups: %w", err)
+		}
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT id, slug, title, description, image_url, tags, volume_24h, market_count,
+		        created_at, updated_at, synced_at %s
+		 FROM market_groups %s %s LIMIT $%d OFFSET $%d`,
+		groupKeyset.Select(), whereSQL(conditions), groupKeyset.OrderBy(), argIdx, argIdx+1,
+	)
+	args = append(args, page.FetchLimit(), page.Offset)
+
+	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("list groups: %w", page.CursorError(err))
+	}
+	defer rows.Close()
+
+	var groups []model.MarketGroup
+	var cursors [][]string
+	for rows.Next() {
+		var g model.MarketGroup
+		cur := make([]string, len(groupKeyset))
+		dest := append([]interface{}{
+			&g.ID, &g.Slug, &g.Title, &g.Description, &g.ImageURL, &g.Tags,
+			&g.Volume24h, &g.MarketCount, &g.CreatedAt, &g.UpdatedAt, &g.SyncedAt,
+		}, groupKeyset.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan group: %w", err)
+		}
+		groups = append(groups, g)
+		cursors = append(cursors, cur)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, fmt.Errorf("iterate groups: %w", page.CursorError(err))
+	}
+
+	groups, next := db.TrimPage(page, groups, cursors)
+	return groups, total, next, nil
+}
+
+// GetByID retrieves a single market group by its Polymarket event ID.
+func (r *GroupRepository) GetByID(ctx context.Context, id string) (*model.MarketGroup, error) {
+	query := `SELECT id, slug, title, description, image_url, tags, volume_24h, market_count,
+	                 created_at, updated_at, synced_at
+	          FROM market_groups WHERE id = $1`
+
+	var g model.MarketGroup
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&g.ID, &g.Slug, &g.Title, &g.Description, &g.ImageURL, &g.Tags,
+		&g.Volume24h, &g.MarketCount, &g.CreatedAt, &g.UpdatedAt, &g.SyncedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get group by id: %w", err)
+	}
+
+	return &g, nil
+}
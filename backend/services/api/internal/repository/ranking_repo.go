@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/db"
+)
+
+// RankingEntry is the enriched ranking returned by the API.
+type RankingEntry struct {
+	Rank         int       `json:"rank"`
+	UserID       string    `json:"user_id"`
+	Handle       string    `json:"handle"`
+	DisplayName  string    `json:"display_name"`
+	AvatarURL    *string   `json:"avatar_url"`
+	TotalProfit  int64     `json:"total_profit"`
+	WinRate      float64   `json:"win_rate"`
+	ROI          float64   `json:"roi"`
+	TotalBets    int       `json:"total_bets"`
+	WinCount     int       `json:"win_count"`
+	LossCount    int       `json:"loss_count"`
+	CalculatedAt time.Time `json:"calculated_at"`
+}
+
+// RankingFilters holds the filter and pagination parameters for listing
+// rankings.
+type RankingFilters struct {
+	Period   string
+	Category string
+	SortBy   string
+	Page     db.Page
+}
+
+// RankingRepository provides database access for rankings.
+type RankingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRankingRepository creates a new RankingRepository.
+func NewRankingRepository(pool *pgxpool.Pool) *RankingRepository {
+	return &RankingRepository{pool: pool}
+}
+
+// rankingKeyset returns the ordering for a sort_by value, ending with the
+// ranking row ID so it is total and usable for keyset pagination.
+func rankingKeyset(sortBy string) db.Keyset {
+	id := db.SortKey{Expr: "r.id"}
+
+	switch sortBy {
+	case "total_assets":
+		return db.Keyset{{Expr: "r.total_assets", Desc: true}, id}
+	case "total_profit":
+		return db.Keyset{{Expr: "r.total_profit", Desc: true}, id}
+	case "win_rate":
+		return db.Keyset{{Expr: "r.win_rate", Desc: true}, id}
+	case "roi":
+		return db.Keyset{{Expr: "r.roi", Desc: true}, id}
+	}
+
+	return db.Keyset{{Expr: "COALESCE(r.rank_position, 2147483647)"}, id}
+}
+
+// List retrieves a page of rankings with optional filters. In keyset mode the
+// total is not counted and the cursor values for the next page are returned
+// instead.
+func (r *RankingRepository) List(ctx context.Context, filters RankingFilters) ([]RankingEntry, int64, []string, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIdx := 1
+
+	if filters.Period != "" {
+		whereClause += fmt.Sprintf(" AND r.period = $%d", argIdx)
+		args = append(args, filters.Period)
+		argIdx++
+	}
+
+	if filters.Category != "" {
+		whereClause += fmt.Sprintf(" AND r.category = $%d", argIdx)
+		args = append(args, filters.Category)
+		argIdx++
+	}
+
+	keys := rankingKeyset(filters.SortBy)
+	page := filters.Page
+
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := keys.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			whereClause += " AND " + after
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		// Count.
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM rankings r %s", whereClause)
+		err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("count rankings: %w", err)
+		}
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT r.rank_position, r.user_id, COALESCE(u.handle, ''), COALESCE(u.display_name, 'Unknown'),
+		        u.avatar_url, r.total_profit, r.win_rate, r.roi,
+		        r.win_count, r.loss_count, r.win_count + r.loss_count, r.calculated_at %s
+		 FROM rankings r
+		 LEFT JOIN users u ON u.id = r.user_id
+		 %s %s LIMIT $%d OFFSET $%d`,
+		keys.Select(), whereClause, keys.OrderBy(), argIdx, argIdx+1,
+	)
+	args = append(args, page.FetchLimit(), page.Offset)
+
+	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("list rankings: %w", page.CursorError(err))
+	}
+	defer rows.Close()
+
+	var rankings []RankingEntry
+	var cursors [][]string
+	for rows.Next() {
+		var e RankingEntry
+		var totalBets int
+		cur := make([]string, len(keys))
+		dest := append([]interface{}{
+			&e.Rank, &e.UserID, &e.Handle, &e.DisplayName,
+			&e.AvatarURL, &e.TotalProfit, &e.WinRate, &e.ROI,
+			&e.WinCount, &e.LossCount, &totalBets, &e.CalculatedAt,
+		}, keys.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan ranking: %w", err)
+		}
+		e.TotalBets = totalBets
+		rankings = append(rankings, e)
+		cursors = append(cursors, cur)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, fmt.Errorf("iterate rankings: %w", page.CursorError(err))
+	}
+
+	rankings, next := db.TrimPage(page, rankings, cursors)
+	return rankings, total, next, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// ReferralProgress is a referral as seen by the referrer: who signed up and
+// how close they are to unlocking the bonus.
+type ReferralProgress struct {
+	ID           string               `json:"id"`
+	RefereeName  string               `json:"referee_name"`
+	Status       model.ReferralStatus `json:"status"`
+	SettledBets  int                  `json:"settled_bets"`
+	RequiredBets int                  `json:"required_bets"`
+	Bonus        int64                `json:"bonus"`
+	RewardedAt   *time.Time           `json:"rewarded_at"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+// ReferralRepository provides database access for referrals.
+type ReferralRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewReferralRepository creates a new ReferralRepository.
+func NewReferralRepository(pool *pgxpool.Pool) *ReferralRepository {
+	return &ReferralRepository{pool: pool}
+}
+
+// Referee is what decides whether a user may still claim a referral code.
+type Referee struct {
+	CreatedAt time.Time
+	// Claimed is set once the user has claimed a code.
+	Claimed bool
+	// Settled is set once one of the user's bets has been won or lost.
+	Settled bool
+}
+
+// LockReferee locks the user's row within tx for the rest of the transaction
+// and returns their claim eligibility. It returns nil for unknown or deleted
+// users.
+func (r *ReferralRepository) LockReferee(ctx context.Context, tx pgx.Tx, id string) (*Referee, error) {
+	var ref Referee
+	err := tx.QueryRow(ctx,
+		`SELECT created_at,
+		        EXISTS(SELECT 1 FROM referrals WHERE referee_id = $1),
+		        EXISTS(SELECT 1 FROM bets WHERE user_id = $1 AND status IN ('won', 'lost'))
+		 FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		id,
+	).Scan(&ref.CreatedAt, &ref.Claimed, &ref.Settled)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lock referee: %w", err)
+	}
+
+	return &ref, nil
+}
+
+// FindReferrer returns the user whose referral code is code, in any letter
+// case, within tx. loop is set if that user was referred by refereeID; found
+// is false if no active user has the code.
+func (r *ReferralRepository) FindReferrer(ctx context.Context, tx pgx.Tx, code, refereeID string) (referrerID string, loop, found bool, err error) {
+	err = tx.QueryRow(ctx,
+		`SELECT u.id, EXISTS(SELECT 1 FROM referrals r WHERE r.referrer_id = $2 AND r.referee_id = u.id)
+		 FROM users u WHERE u.referral_code = upper($1) AND u.deleted_at IS NULL`,
+		code, refereeID,
+	).Scan(&referrerID, &loop)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", false, false, nil
+		}
+		return "", false, false, fmt.Errorf("get referrer: %w", err)
+	}
+
+	return referrerID, loop, true, nil
+}
+
+// Create records within tx that refereeID claimed referrerID's code. ip may
+// be empty.
+func (r *ReferralRepository) Create(ctx context.Context, tx pgx.Tx, referrerID, refereeID, code, ip string) (*model.Referral, error) {
+	var ref model.Referral
+	err := tx.QueryRow(ctx,
+		`INSERT INTO referrals (referrer_id, referee_id, code, claim_ip)
+		 VALUES ($1, $2, upper($3), NULLIF($4, '')::inet)
+		 RETURNING id, referrer_id, referee_id, code, status, rewarded_at, created_at`,
+		referrerID, refereeID, code, ip,
+	).Scan(&ref.ID, &ref.ReferrerID, &ref.RefereeID, &ref.Code, &ref.Status, &ref.RewardedAt, &ref.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert referral: %w", err)
+	}
+
+	return &ref, nil
+}
+
+// referralKeyset orders a referrer's referrals newest first.
+var referralKeyset = db.Keyset{{Expr: "r.created_at", Desc: true}, {Expr: "r.id", Desc: true}}
+
+// ListByReferrer retrieves a page of the referrals made with a user's code.
+// In keyset mode the total is not counted and the cursor values for the next
+// page are returned instead.
+func (r *ReferralRepository) ListByReferrer(ctx context.Context, referrerID string, page db.Page) ([]ReferralProgress, int64, []string, error) {
+	whereClause := "WHERE r.referrer_id = $1"
+	args := []interface{}{referrerID}
+	argIdx := 2
+
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := referralKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			whereClause += " AND " + after
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM referrals r "+whereClause, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("count referrals: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(
+		`SELECT r.id, u.display_name, r.status, r.rewarded_at, r.created_at,
+		        (SELECT COUNT(*) FROM bets b WHERE b.user_id = r.referee_id AND b.status IN ('won', 'lost')) %s
+		 FROM referrals r
+		 JOIN users u ON u.id = r.referee_id
+		 %s %s LIMIT $%d OFFSET $%d`,
+		referralKeyset.Select(), whereClause, referralKeyset.OrderBy(), argIdx, argIdx+1,
+	)
+	args = append(args, page.FetchLimit(), page.Offset)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("list referrals: %w", page.CursorError(err))
+	}
+	defer rows.Close()
+
+	var referrals []ReferralProgress
+	var cursors [][]string
+	for rows.Next() {
+		p := ReferralProgress{
+			RequiredBets: model.ReferralQualifyingBets,
+			Bonus:        model.ReferralBonus,
+		}
+		cur := make([]string, len(referralKeyset))
+		dest := append([]interface{}{
+			&p.ID, &p.RefereeName, &p.Status, &p.RewardedAt, &p.CreatedAt, &p.SettledBets,
+		}, referralKeyset.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan referral: %w", err)
+		}
+		referrals = append(referrals, p)
+		cursors = append(cursors, cur)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, fmt.Errorf("iterate referrals: %w", page.CursorError(err))
+	}
+
+	referrals, next := db.TrimPage(page, referrals, cursors)
+	return referrals, total, next, nil
+}
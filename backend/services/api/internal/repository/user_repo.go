@@ -2,35 +2,42 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
 )
 
 // UserRepository provides database access for users.
 type UserRepository struct {
-	pool *pgxpool.Pool
+	pool            *pgxpool.Pool
+	startingBalance int64
 }
 
-// NewUserRepository creates a new UserRepository.
-func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
-	return &UserRepository{pool: pool}
+// NewUserRepository creates a new UserRepository. New users are credited
+// startingBalance.
+func NewUserRepository(pool *pgxpool.Pool, startingBalance int64) *UserRepository {
+	return &UserRepository{pool: pool, startingBalance: startingBalance}
 }
 
 // GetByID retrieves a user by their ID.
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
 	query := `SELECT id, display_name, avatar_url, balance, frozen_balance, level, xp,
-	                 current_streak, max_streak, total_bets, total_wins, created_at, updated_at
+	                 current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at, created_at, updated_at
 	          FROM users WHERE id = $1`
 
 	var u model.User
 	err := r.pool.QueryRow(ctx, query, id).Scan(
 		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
 		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets,
-		&u.TotalWins, &u.CreatedAt, &u.UpdatedAt,
+		&u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -55,84 +62,223 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-// UpdateDisplayName updates the user's display name and returns the updated user.
-func (r *UserRepository) UpdateDisplayName(ctx context.Context, id, name string) (*model.User, error) {
-	query := `UPDATE users SET display_name = $2, updated_at = NOW() WHERE id = $1
+// UpdateAvatarURL sets or, given nil, clears the user's avatar URL and
+// returns the updated user.
+func (r *UserRepository) UpdateAvatarURL(ctx context.Context, id string, url *string) (*model.User, error) {
+	query := `UPDATE users SET avatar_url = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL
 	          RETURNING id, display_name, avatar_url, balance, frozen_balance, level, xp,
-	                    current_streak, max_streak, total_bets, total_wins, created_at, updated_at`
+	                    current_streak, max_streak, total_bets, total_wins, handle, handle_changed_at, referral_code, deleted_at, created_at, updated_at`
 
 	var u model.User
-	err := r.pool.QueryRow(ctx, query, id, name).Scan(
+	err := r.pool.QueryRow(ctx, query, id, url).Scan(
 		&u.ID, &u.DisplayName, &u.AvatarURL, &u.Balance, &u.FrozenBalance,
 		&u.Level, &u.XP, &u.CurrentStreak, &u.MaxStreak, &u.TotalBets,
-		&u.TotalWins, &u.CreatedAt, &u.UpdatedAt,
+		&u.TotalWins, &u.Handle, &u.HandleChangedAt, &u.ReferralCode, &u.DeletedAt, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("update display name: %w", err)
+		return nil, fmt.Errorf("update avatar url: %w", err)
 	}
 
 	return &u, nil
 }
 
+// createAttempts bounds how often GetOrCreate generates a new handle and
+// referral code after one collides with an existing user's.
+const createAttempts = 3
+
 // GetOrCreate retrieves a user by ID, or creates one with default values if not found.
+// New users get a generated handle, which also serves as their display name
+// until they choose one, and a generated referral code. Both are unique, so
+// a collision is retried with new ones.
 // Uses INSERT ... ON CONFLICT DO NOTHING followed by a SELECT to handle the upsert.
-func (r *UserRepository) GetOrCreate(ctx context.Context, id, displayName string) (*model.User, error) {
-	const defaultBalance int64 = 10000 // 10,000 credits
-
-	upsertQuery := `INSERT INTO users (id, display_name, balance, created_at, updated_at)
-	                VALUES ($1, $2, $3, NOW(), NOW())
+// created reports whether this call inserted the user.
+func (r *UserRepository) GetOrCreate(ctx context.Context, id string) (user *model.User, created bool, err error) {
+	upsertQuery := `INSERT INTO users (id, handle, display_name, referral_code, balance, created_at, updated_at)
+	                VALUES ($1, $2, $2, $3, $4, NOW(), NOW())
 	                ON CONFLICT (id) DO NOTHING`
 
-	_, err := r.pool.Exec(ctx, upsertQuery, id, displayName, defaultBalance)
+	var inserted int64
+	for attempt := 1; ; attempt++ {
+		handle, code, err := generateIdentifiers()
+		if err != nil {
+			return nil, false, err
+		}
+
+		tag, err := r.pool.Exec(ctx, upsertQuery, id, handle, code, r.startingBalance)
+		if err == nil {
+			inserted = tag.RowsAffected()
+			break
+		}
+		collided := db.IsUniqueViolation(err, "idx_users_handle") || db.IsUniqueViolation(err, "idx_users_referral_code")
+		if !collided || attempt == createAttempts {
+			return nil, false, fmt.Errorf("upsert user: %w", err)
+		}
+	}
+
+	user, err = r.GetByID(ctx, id)
+	return user, inserted == 1, err
+}
+
+// generateIdentifiers returns a random handle and referral code for a new
+// user, each with 48 random bits.
+func generateIdentifiers() (handle, code string, err error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generate user identifiers: %w", err)
+	}
+	return "player_" + hex.EncodeToString(b[:6]), strings.ToUpper(hex.EncodeToString(b[6:])), nil
+}
+
+// LockBalance locks the user's row within tx for the rest of the transaction
+// and returns their available balance. found is false for unknown or deleted
+// users.
+func (r *UserRepository) LockBalance(ctx context.Context, tx pgx.Tx, id string) (balance int64, found bool, err error) {
+	err = tx.QueryRow(ctx, "SELECT balance FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&balance)
 	if err != nil {
-		return nil, fmt.Errorf("upsert user: %w", err)
+		if err == pgx.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("lock user balance: %w", err)
 	}
 
-	return r.GetByID(ctx, id)
+	return balance, true, nil
 }
 
-// GetTransactions retrieves a paginated list of credit transactions for a user.
-func (r *UserRepository) GetTransactions(ctx context.Context, userID string, page, pageSize int) ([]model.CreditTransaction, int64, error) {
-	// Count.
-	var total int64
-	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM credit_transactions WHERE user_id = $1", userID).Scan(&total)
+// FreezeStake moves amount from the user's available to their frozen balance
+// within tx and counts the bet.
+func (r *UserRepository) FreezeStake(ctx context.Context, tx pgx.Tx, id string, amount int64) error {
+	_, err := tx.Exec(ctx,
+		"UPDATE users SET balance = balance - $1, frozen_balance = frozen_balance + $1, total_bets = total_bets + 1, updated_at = NOW() WHERE id = $2",
+		amount, id,
+	)
 	if err != nil {
-		return nil, 0, fmt.Errorf("count transactions: %w", err)
+		return fmt.Errorf("freeze stake: %w", err)
+	}
+
+	return nil
+}
+
+// LockHandle locks the user's row within tx for the rest of the transaction
+// and returns their handle and when it was last changed, nil if never. found
+// is false for unknown or deleted users.
+func (r *UserRepository) LockHandle(ctx context.Context, tx pgx.Tx, id string) (handle string, changedAt *time.Time, found bool, err error) {
+	err = tx.QueryRow(ctx,
+		"SELECT handle, handle_changed_at FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+	).Scan(&handle, &changedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil, false, nil
+		}
+		return "", nil, false, fmt.Errorf("lock user handle: %w", err)
+	}
+
+	return handle, changedAt, true, nil
+}
+
+// UpdateProfile sets the user's display name and handle within tx; nil
+// leaves a field unchanged and a new handle restarts the rename cooldown.
+// updated is false if another user has the handle in any letter case.
+func (r *UserRepository) UpdateProfile(ctx context.Context, tx pgx.Tx, id string, displayName, handle *string) (updated bool, err error) {
+	_, err = tx.Exec(ctx,
+		`UPDATE users
+		 SET display_name = COALESCE($2, display_name),
+		     handle = COALESCE($3, handle),
+		     handle_changed_at = CASE WHEN $3::text IS NULL THEN handle_changed_at ELSE NOW() END,
+		     updated_at = NOW()
+		 WHERE id = $1`,
+		id, displayName, handle,
+	)
+	if err != nil {
+		if db.IsUniqueViolation(err, "idx_users_handle") {
+			return false, nil
+		}
+		return false, fmt.Errorf("update profile: %w", err)
+	}
+
+	return true, nil
+}
+
+// AddTransaction records a credit transaction within tx.
+func (r *UserRepository) AddTransaction(ctx context.Context, tx pgx.Tx, t *model.CreditTransaction) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		t.UserID, t.Type, t.Amount, t.BalanceAfter, t.ReferenceID, t.Description, t.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert credit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// transactionKeyset orders a user's ledger newest first.
+var transactionKeyset = db.Keyset{{Expr: "created_at", Desc: true}, {Expr: "id", Desc: true}}
+
+// GetTransactions retrieves a page of credit transactions for a user. In
+// keyset mode the total is not counted and the cursor values for the next
+// page are returned instead.
+func (r *UserRepository) GetTransactions(ctx context.Context, userID string, page db.Page) ([]model.CreditTransaction, int64, []string, error) {
+	whereClause := "WHERE user_id = $1"
+	args := []interface{}{userID}
+	argIdx := 2
+
+	var total int64
+	if page.Keyset {
+		if page.After != nil {
+			after, afterArgs, err := transactionKeyset.After(page.After, argIdx)
+			if err != nil {
+				return nil, 0, nil, fmt.Errorf("apply cursor: %w", err)
+			}
+			whereClause += " AND " + after
+			args = append(args, afterArgs...)
+			argIdx += len(afterArgs)
+		}
+	} else {
+		// Count.
+		err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM credit_transactions "+whereClause, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("count transactions: %w", err)
+		}
 	}
 
 	// Data.
-	offset := (page - 1) * pageSize
-	query := `SELECT id, user_id, type, amount, balance_after, reference_id, description, created_at
-	          FROM credit_transactions
-	          WHERE user_id = $1
-	          ORDER BY created_at DESC
-	          LIMIT $2 OFFSET $3`
-
-	rows, err := r.pool.Query(ctx, query, userID, pageSize, offset)
+	query := fmt.Sprintf(
+		`SELECT id, user_id, type, amount, balance_after, reference_id, description, created_at %s
+		 FROM credit_transactions %s %s LIMIT $%d OFFSET $%d`,
+		transactionKeyset.Select(), whereClause, transactionKeyset.OrderBy(), argIdx, argIdx+1,
+	)
+	args = append(args, page.FetchLimit(), page.Offset)
+
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("list transactions: %w", err)
+		return nil, 0, nil, fmt.Errorf("list transactions: %w", page.CursorError(err))
 	}
 	defer rows.Close()
 
 	var transactions []model.CreditTransaction
+	var cursors [][]string
 	for rows.Next() {
 		var t model.CreditTransaction
-		err := rows.Scan(
+		cur := make([]string, len(transactionKeyset))
+		dest := append([]interface{}{
 			&t.ID, &t.UserID, &t.Type, &t.Amount, &t.BalanceAfter,
 			&t.ReferenceID, &t.Description, &t.CreatedAt,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("scan transaction: %w", err)
+		}, transactionKeyset.ScanDest(cur)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, nil, fmt.Errorf("scan transaction: %w", err)
 		}
 		transactions = append(transactions, t)
+		cursors = append(cursors, cur)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("iterate transactions: %w", err)
+		return nil, 0, nil, fmt.Errorf("iterate transactions: %w", page.CursorError(err))
 	}
 
-	return transactions, total, nil
+	transactions, next := db.TrimPage(page, transactions, cursors)
+	return transactions, total, next, nil
 }
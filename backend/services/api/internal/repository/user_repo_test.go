@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/poly-predict/backend/pkg/moderation"
+)
+
+func TestGenerateIdentifiers(t *testing.T) {
+	handle, code, err := generateIdentifiers()
+	if err != nil {
+		t.Fatalf("generateIdentifiers: %v", err)
+	}
+
+	if err := moderation.ValidateHandle(handle); err != nil {
+		t.Errorf("generated handle %q is invalid: %v", handle, err)
+	}
+	// referral_code is VARCHAR(16).
+	if len(code) != 12 {
+		t.Errorf("generated code %q has %d characters, want 12", code, len(code))
+	}
+
+	other, otherCode, err := generateIdentifiers()
+	if err != nil {
+		t.Fatalf("generateIdentifiers: %v", err)
+	}
+	if other == handle || otherCode == code {
+		t.Errorf("two calls generated the same identifiers: %q %q", handle, code)
+	}
+}
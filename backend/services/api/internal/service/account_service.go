@@ -0,0 +1,163 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/storage"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+// AccountService handles personal data export and account deletion.
+type AccountService struct {
+	repo  AccountRepository
+	store storage.Storage
+}
+
+// NewAccountService creates a new AccountService.
+func NewAccountService(repo AccountRepository, store storage.Storage) *AccountService {
+	return &AccountService{repo: repo, store: store}
+}
+
+// Export writes a zip archive of everything stored about the user to w: the
+// full export as account.json plus one CSV per table. It returns
+// ErrUserNotFound for unknown or deleted users.
+func (s *AccountService) Export(ctx context.Context, userID string, w io.Writer) error {
+	export, err := s.repo.Export(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if export == nil || export.Profile.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+
+	return writeArchive(w, export)
+}
+
+// Delete removes the user's avatar images and anonymizes the user, leaving a
+// tombstone in place of their row. It returns ErrUserNotFound for unknown or
+// already deleted users. The images go first because deleting them is
+// idempotent: if that fails the account is still live and the user can
+// retry, whereas a failure after anonymizing would leave orphaned files that
+// a retry could no longer reach.
+func (s *AccountService) Delete(ctx context.Context, userID string) error {
+	for _, size := range storage.AvatarSizes {
+		if err := s.store.Delete(ctx, storage.AvatarKey(userID, size)); err != nil {
+			return fmt.Errorf("delete avatar: %w", err)
+		}
+	}
+
+	ok, err := s.repo.Anonymize(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// writeArchive encodes an export as a zip of account.json and per-table CSVs.
+func writeArchive(w io.Writer, export *repository.AccountExport) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("account.json")
+	if err != nil {
+		return fmt.Errorf("create account.json: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("encode account.json: %w", err)
+	}
+
+	bets := [][]string{{"id", "event_id", "outcome", "amount", "locked_odds", "potential_payout", "status", "payout", "settled_at", "created_at"}}
+	for _, b := range export.Bets {
+		bets = append(bets, []string{
+			b.ID, b.EventID, b.Outcome, itoa(b.Amount), strconv.FormatFloat(b.LockedOdds, 'f', -1, 64),
+			itoa(b.PotentialPayout), string(b.Status), optInt(b.Payout), optTime(b.SettledAt), b.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	transactions := [][]string{{"id", "type", "amount", "balance_after", "reference_id", "description", "created_at"}}
+	for _, t := range export.Transactions {
+		transactions = append(transactions, []string{
+			itoa(t.ID), t.Type, itoa(t.Amount), itoa(t.BalanceAfter),
+			optString(t.ReferenceID), optString(t.Description), t.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	rankings := [][]string{{"period", "category", "rank_position", "total_assets", "total_profit", "win_count", "loss_count", "win_rate", "roi", "calculated_at"}}
+	for _, k := range export.Rankings {
+		rankings = append(rankings, []string{
+			k.Period, k.Category, strconv.Itoa(k.RankPosition), itoa(k.TotalAssets), itoa(k.TotalProfit),
+			strconv.Itoa(k.WinCount), strconv.Itoa(k.LossCount),
+			strconv.FormatFloat(k.WinRate, 'f', -1, 64), strconv.FormatFloat(k.ROI, 'f', -1, 64),
+			k.CalculatedAt.Format(time.RFC3339),
+		})
+	}
+
+	referrals := [][]string{{"id", "role", "code", "status", "rewarded_at", "created_at"}}
+	for _, f := range export.Referrals {
+		role := "referee"
+		if f.ReferrerID == export.Profile.ID {
+			role = "referrer"
+		}
+		referrals = append(referrals, []string{
+			f.ID, role, f.Code, string(f.Status), optTime(f.RewardedAt), f.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	tables := []struct {
+		name    string
+		records [][]string
+	}{
+		{"bets.csv", bets},
+		{"transactions.csv", transactions},
+		{"rankings.csv", rankings},
+		{"referrals.csv", referrals},
+	}
+	for _, table := range tables {
+		f, err := zw.Create(table.name)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", table.name, err)
+		}
+		if err := csv.NewWriter(f).WriteAll(table.records); err != nil {
+			return fmt.Errorf("write %s: %w", table.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return nil
+}
+
+func itoa(n int64) string { return strconv.FormatInt(n, 10) }
+
+func optInt(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return itoa(*n)
+}
+
+func optString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/storage"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+func TestWriteArchive(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	export := &repository.AccountExport{
+		ExportedAt: created,
+		Profile:    model.User{ID: "u1", DisplayName: "alice"},
+		Bets: []model.Bet{
+			{ID: "b1", EventID: "e1", Outcome: "Yes", Amount: 100, LockedOdds: 0.5, PotentialPayout: 200, Status: model.BetStatusPending, CreatedAt: created},
+		},
+		Referrals: []model.Referral{
+			{ID: "r1", ReferrerID: "u1", RefereeID: "u2", Code: "ABC", Status: model.ReferralStatusPending, CreatedAt: created},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, export); err != nil {
+		t.Fatalf("writeArchive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{"account.json", "bets.csv", "transactions.csv", "rankings.csv", "referrals.csv"} {
+		if files[name] == nil {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	rc, err := files["bets.csv"].Open()
+	if err != nil {
+		t.Fatalf("open bets.csv: %v", err)
+	}
+	defer rc.Close()
+
+	records, err := csv.NewReader(rc).ReadAll()
+	if err != nil {
+		t.Fatalf("read bets.csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("bets.csv has %d records, want header + 1", len(records))
+	}
+	if got := records[1][0]; got != "b1" {
+		t.Errorf("bets.csv first id = %q, want b1", got)
+	}
+	if got := records[1][7]; got != "" {
+		t.Errorf("unsettled payout = %q, want empty", got)
+	}
+}
+
+// fakeAccounts tombstones users in memory.
+type fakeAccounts struct {
+	AccountRepository
+	deleted map[string]bool
+}
+
+func (f *fakeAccounts) Anonymize(ctx context.Context, userID string) (bool, error) {
+	if f.deleted[userID] {
+		return false, nil
+	}
+	f.deleted[userID] = true
+	return true, nil
+}
+
+// flakyStore fails the first `failures` deletes and records the keys of the
+// ones that succeed.
+type flakyStore struct {
+	storage.Storage
+	failures int
+	deleted  []string
+}
+
+func (s *flakyStore) Delete(ctx context.Context, key string) error {
+	if s.failures > 0 {
+		s.failures--
+		return errBoom
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestDeleteRetriesAfterStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	accounts := &fakeAccounts{deleted: map[string]bool{}}
+	store := &flakyStore{failures: 1}
+	svc := NewAccountService(accounts, store)
+
+	if err := svc.Delete(ctx, "u1"); !errors.Is(err, errBoom) {
+		t.Fatalf("Delete with failing storage: err = %v, want errBoom", err)
+	}
+	if accounts.deleted["u1"] {
+		t.Fatal("account anonymized although its avatars were not deleted")
+	}
+
+	if err := svc.Delete(ctx, "u1"); err != nil {
+		t.Fatalf("retried Delete: %v", err)
+	}
+	if !accounts.deleted["u1"] {
+		t.Error("retried Delete did not anonymize the account")
+	}
+	if len(store.deleted) != len(storage.AvatarSizes) {
+		t.Errorf("deleted %d avatar objects, want %d", len(store.deleted), len(storage.AvatarSizes))
+	}
+
+	if err := svc.Delete(ctx, "u1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Delete of a deleted account: err = %v, want ErrUserNotFound", err)
+	}
+}
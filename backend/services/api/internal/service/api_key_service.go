@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/auth"
+)
+
+// apiKeyPrefix starts every personal API key, so leaked keys are easy to
+// recognise in logs and secret scanners.
+const apiKeyPrefix = "pp_"
+
+// lastUsedResolution bounds how often a key's last_used_at is written.
+const lastUsedResolution = time.Minute
+
+var (
+	// ErrInvalidAPIKeyName is returned for an empty or overlong key name.
+	ErrInvalidAPIKeyName = apperr.ErrValidation.WithMessage("name must be 1-100 characters")
+	// ErrInvalidAPIKeyScopes is returned when no scope, or an unknown one, is
+	// requested.
+	ErrInvalidAPIKeyScopes = apperr.ErrValidation.WithMessage("scopes must be read or trade")
+	// ErrTooManyAPIKeys is returned when the user already holds the maximum
+	// number of active keys.
+	ErrTooManyAPIKeys = apperr.New(http.StatusConflict, apperr.CodeLimitReached,
+		fmt.Sprintf("at most %d api keys may be active at once", model.MaxAPIKeysPerUser))
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not own
+	// or has already revoked.
+	ErrAPIKeyNotFound = apperr.ErrNotFound.WithMessage("api key not found")
+)
+
+// CreatedAPIKey is a newly created key together with its secret, which is
+// shown only once.
+type CreatedAPIKey struct {
+	model.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyService manages personal API keys and verifies them for the auth
+// middleware.
+type APIKeyService struct {
+	repo APIKeyRepository
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// Create issues a new key. The trade scope implies read.
+func (s *APIKeyService) Create(ctx context.Context, userID, name string, scopes []model.APIKeyScope) (*CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, ErrInvalidAPIKeyName
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate api key: %w", err)
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(secret[:4])
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret[4:])
+
+	created, err := s.repo.Create(ctx, &model.APIKey{
+		UserID: userID,
+		Name:   name,
+		Prefix: prefix,
+		Scopes: scopes,
+	}, hashAPIKey(key), model.MaxAPIKeysPerUser)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	return &CreatedAPIKey{APIKey: *created, Key: key}, nil
+}
+
+// List returns the user's active keys without their secrets.
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Revoke revokes one of the user's keys. An id that is not a UUID names no
+// key, so it is not found rather than sent to the database.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrAPIKeyNotFound
+	}
+	revoked, err := s.repo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// VerifyAPIKey implements auth.APIKeyVerifier and records when the key was
+// last used.
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, key string) (*auth.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil
+	}
+
+	k, err := s.repo.GetActiveByHash(ctx, hashAPIKey(key))
+	if err != nil || k == nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, k.ID, lastUsedResolution); err != nil {
+		logging.Ctx(ctx).Warn().Err(err).Str("api_key_id", k.ID).Msg("failed to record api key use")
+	}
+
+	return &auth.APIKey{ID: k.ID, UserID: k.UserID, Scopes: k.Scopes}, nil
+}
+
+// hashAPIKey returns the SHA-256 hex digest a key is stored under. Keys carry
+// enough entropy that a slow password hash is unnecessary.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes validates scopes, adds read when trade is requested and
+// removes duplicates.
+func normalizeScopes(scopes []model.APIKeyScope) ([]model.APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScopes
+	}
+
+	var read, trade bool
+	for _, s := range scopes {
+		switch s {
+		case model.APIKeyScopeRead:
+			read = true
+		case model.APIKeyScopeTrade:
+			read, trade = true, true
+		default:
+			return nil, ErrInvalidAPIKeyScopes
+		}
+	}
+
+	out := []model.APIKeyScope{}
+	if read {
+		out = append(out, model.APIKeyScopeRead)
+	}
+	if trade {
+		out = append(out, model.APIKeyScopeTrade)
+	}
+	return out, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeAPIKeys struct {
+	APIKeyRepository
+	revoked []string
+}
+
+func (f *fakeAPIKeys) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	f.revoked = append(f.revoked, id)
+	return id == "6f1c2d9e-3b4a-4c5d-8e6f-7a8b9c0d1e2f", nil
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	repo := &fakeAPIKeys{}
+	s := NewAPIKeyService(repo)
+	ctx := context.Background()
+
+	if err := s.Revoke(ctx, "user-1", "6f1c2d9e-3b4a-4c5d-8e6f-7a8b9c0d1e2f"); err != nil {
+		t.Errorf("revoke own key: %v", err)
+	}
+	if err := s.Revoke(ctx, "user-1", "0b5e1f7a-9c2d-4e3f-a1b2-c3d4e5f6a7b8"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoke unknown key: %v, want not found", err)
+	}
+	if err := s.Revoke(ctx, "user-1", "not-a-uuid"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoke malformed id: %v, want not found", err)
+	}
+	if len(repo.revoked) != 2 {
+		t.Errorf("%d ids reached the repository, want the 2 UUIDs", len(repo.revoked))
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	// Register the decoders accepted for avatar uploads.
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+)
+
+const (
+	// MaxAvatarBytes caps the size of an uploaded avatar file.
+	MaxAvatarBytes = 5 << 20
+
+	minAvatarEdge = 64
+	maxAvatarEdge = 4096
+	avatarQuality = 85
+)
+
+var (
+	// ErrInvalidImage is returned for uploads that are not a JPEG, PNG or GIF
+	// image within the accepted dimensions.
+	ErrInvalidImage = apperr.New(http.StatusUnprocessableEntity, apperr.CodeInvalidImage,
+		"avatar must be a JPEG, PNG or GIF image between 64x64 and 4096x4096 pixels")
+	// ErrImageTooLarge is returned for uploads over MaxAvatarBytes.
+	ErrImageTooLarge = apperr.New(http.StatusRequestEntityTooLarge, apperr.CodePayloadTooLarge, "avatar must be at most 5 MB")
+)
+
+// avatarTypes are the accepted upload types, by sniffed content type.
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// processAvatar validates an uploaded image and renders it as square JPEGs
+// at each of sizes, center-cropping non-square images. Transparent areas are
+// flattened onto white.
+func processAvatar(data []byte, sizes []int) (map[int][]byte, error) {
+	if len(data) > MaxAvatarBytes {
+		return nil, ErrImageTooLarge
+	}
+	if !avatarTypes[http.DetectContentType(data)] {
+		return nil, ErrInvalidImage
+	}
+
+	// Check dimensions before decoding so a small file cannot expand into a
+	// huge bitmap.
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width < minAvatarEdge || cfg.Height < minAvatarEdge ||
+		cfg.Width > maxAvatarEdge || cfg.Height > maxAvatarEdge {
+		return nil, ErrInvalidImage
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	out := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeSquare(src, size), &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return nil, err
+		}
+		out[size] = buf.Bytes()
+	}
+
+	return out, nil
+}
+
+// resizeSquare center-crops src to a square and scales it to size x size.
+// Each destination pixel is the average of the source pixels it covers (a
+// box filter), which avoids the aliasing of nearest-neighbour sampling when
+// shrinking.
+func resizeSquare(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := span(y, size, side)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := span(x, size, side)
+
+			var r, g, bl, a, n uint64
+			for sy := y0 + sy0; sy < y0+sy1; sy++ {
+				for sx := x0 + sx0; sx < x0+sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			// Colours are alpha-premultiplied, so compositing over white is
+			// adding the uncovered fraction of white to each channel.
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + white),
+				G: uint16(g/n + white),
+				B: uint16(bl/n + white),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return dst
+}
+
+// span returns the half-open range of source offsets covered by destination
+// index i when mapping side source pixels onto size destination pixels. The
+// range always holds at least one pixel.
+func span(i, size, side int) (int, int) {
+	lo := i * side / size
+	hi := (i + 1) * side / size
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/storage"
+)
+
+// AvatarService handles avatar uploads.
+type AvatarService struct {
+	users UserRepository
+	store storage.Storage
+}
+
+// NewAvatarService creates a new AvatarService.
+func NewAvatarService(users UserRepository, store storage.Storage) *AvatarService {
+	return &AvatarService{users: users, store: store}
+}
+
+// Upload validates and resizes an uploaded image, stores it at every size in
+// storage.AvatarSizes and points the user's avatar_url at the largest. The
+// URL carries a version parameter so clients and CDNs pick up the new image
+// even though object keys are reused.
+func (s *AvatarService) Upload(ctx context.Context, userID string, data []byte) (*model.User, error) {
+	images, err := processAvatar(data, storage.AvatarSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	var avatarURL string
+	for i, size := range storage.AvatarSizes {
+		url, err := s.store.Put(ctx, storage.AvatarKey(userID, size), images[size], "image/jpeg")
+		if err != nil {
+			return nil, fmt.Errorf("store avatar: %w", err)
+		}
+		if i == 0 {
+			avatarURL = url
+		}
+	}
+
+	avatarURL += "?v=" + strconv.FormatInt(time.Now().Unix(), 10)
+
+	user, err := s.users.UpdateAvatarURL(ctx, userID, &avatarURL)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessAvatar(t *testing.T) {
+	// A 200x100 image: opaque red on the left, transparent on the right.
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	out, err := processAvatar(encodePNG(t, src), []int{64, 32})
+	if err != nil {
+		t.Fatalf("processAvatar: %v", err)
+	}
+
+	for _, size := range []int{64, 32} {
+		img, err := jpeg.Decode(bytes.NewReader(out[size]))
+		if err != nil {
+			t.Fatalf("decode %d: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("size %d rendered as %dx%d", size, b.Dx(), b.Dy())
+		}
+	}
+
+	// The center crop spans x 50-150: red on the left, white on the right.
+	img, _ := jpeg.Decode(bytes.NewReader(out[64]))
+	if r, g, _, _ := img.At(8, 32).RGBA(); r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("left pixel = (%d, %d), want red", r>>8, g>>8)
+	}
+	if r, g, b, _ := img.At(56, 32).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("right pixel = (%d, %d, %d), want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestProcessAvatar_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not an image", []byte("hello, world"), ErrInvalidImage},
+		{"too small", encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 16, 16))), ErrInvalidImage},
+		{"too many bytes", make([]byte, MaxAvatarBytes+1), ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := processAvatar(tt.data, []int{64}); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -8,147 +8,155 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
 	"github.com/poly-predict/backend/pkg/model"
-	"github.com/poly-predict/backend/services/api/internal/repository"
 )
 
 // BetService handles bet business logic.
 type BetService struct {
-	pool     *pgxpool.Pool
-	betRepo  *repository.BetRepository
-	userRepo *repository.UserRepository
+	tx        db.Transactor
+	betRepo   BetRepository
+	userRepo  UserRepository
+	eventRepo EventRepository
 }
 
 // NewBetService creates a new BetService.
-func NewBetService(pool *pgxpool.Pool, betRepo *repository.BetRepository, userRepo *repository.UserRepository) *BetService {
+func NewBetService(tx db.Transactor, betRepo BetRepository, userRepo UserRepository, eventRepo EventRepository) *BetService {
 	return &BetService{
-		pool:     pool,
-		betRepo:  betRepo,
-		userRepo: userRepo,
+		tx:        tx,
+		betRepo:   betRepo,
+		userRepo:  userRepo,
+		eventRepo: eventRepo,
 	}
 }
 
 // PlaceBet creates a new bet atomically within a database transaction.
 // It verifies the user has sufficient balance, the event is open, and locks odds at the time of placement.
-func (s *BetService) PlaceBet(ctx context.Context, userID, eventID, outcome string, amount int64) (*model.Bet, error) {
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
+// If maxOdds is set the bet is refused with apperr.ErrOddsMoved when the
+// current odds are higher, i.e. the payout would be worse than the caller saw.
+func (s *BetService) PlaceBet(ctx context.Context, userID, eventID, outcome string, amount int64, maxOdds *float64) (*model.Bet, error) {
+	var bet *model.Bet
+	err := s.tx.InTx(ctx, func(tx pgx.Tx) error {
+		// 1. Lock user row and check balance.
+		balance, found, err := s.userRepo.LockBalance(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrUserNotFound
+		}
 
-	// 1. Lock user row and check balance.
-	var balance int64
-	err = tx.QueryRow(ctx, "SELECT balance FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&balance)
-	if err != nil {
-		return nil, fmt.Errorf("get user balance: %w", err)
-	}
+		if balance < amount {
+			return apperr.ErrInsufficientBalance.WithDetails(map[string]int64{
+				"balance":  balance,
+				"required": amount,
+			})
+		}
 
-	if balance < amount {
-		return nil, fmt.Errorf("insufficient balance: have %d, need %d", balance, amount)
-	}
+		// 2. Get event and verify it is open.
+		event, err := s.eventRepo.GetMarket(ctx, tx, eventID)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return apperr.ErrNotFound.WithMessage("event not found")
+		}
 
-	// 2. Get event and verify it is open.
-	var status model.EventStatus
-	var outcomes json.RawMessage
-	var outcomePrices json.RawMessage
-	err = tx.QueryRow(ctx,
-		"SELECT status, outcomes, outcome_prices FROM events WHERE id = $1",
-		eventID,
-	).Scan(&status, &outcomes, &outcomePrices)
-	if err != nil {
-		return nil, fmt.Errorf("get event: %w", err)
-	}
+		if event.Status != model.EventStatusOpen {
+			return apperr.ErrEventNotOpen.WithDetails(map[string]model.EventStatus{"status": event.Status})
+		}
 
-	if status != model.EventStatusOpen {
-		return nil, fmt.Errorf("event is not open for betting")
-	}
+		// 3. Find the odds for the chosen outcome.
+		lockedOdds, err := outcomeOdds(event, outcome)
+		if err != nil {
+			return err
+		}
 
-	// 3. Parse outcomes and prices to find the odds for the chosen outcome.
-	var outcomeLabels []string
-	if err := json.Unmarshal(outcomes, &outcomeLabels); err != nil {
-		return nil, fmt.Errorf("parse outcomes: %w", err)
-	}
+		if maxOdds != nil && lockedOdds > *maxOdds {
+			return apperr.ErrOddsMoved.WithDetails(map[string]float64{
+				"max_odds":     *maxOdds,
+				"current_odds": lockedOdds,
+			})
+		}
 
-	var prices []string
-	if err := json.Unmarshal(outcomePrices, &prices); err != nil {
-		return nil, fmt.Errorf("parse outcome prices: %w", err)
-	}
+		// 4. Move the stake to the frozen balance and increment total_bets.
+		if err := s.userRepo.FreezeStake(ctx, tx, userID, amount); err != nil {
+			return err
+		}
 
-	outcomeIdx := findOutcomeIndex(outcomeLabels, outcome)
-	if outcomeIdx == -1 || outcomeIdx >= len(prices) {
-		return nil, fmt.Errorf("invalid outcome: %s", outcome)
-	}
+		// 5. Insert bet with potential payout amount / lockedOdds (as int64).
+		now := time.Now()
+		bet = &model.Bet{
+			ID:              uuid.New().String(),
+			UserID:          userID,
+			EventID:         eventID,
+			Outcome:         outcome,
+			Amount:          amount,
+			LockedOdds:      lockedOdds,
+			PotentialPayout: int64(float64(amount) / lockedOdds),
+			Status:          model.BetStatusPending,
+			CreatedAt:       now,
+		}
+		if err := s.betRepo.Create(ctx, tx, bet); err != nil {
+			return err
+		}
 
-	// Parse the price string to float64.
-	var lockedOdds float64
-	_, err = fmt.Sscanf(prices[outcomeIdx], "%f", &lockedOdds)
-	if err != nil || lockedOdds <= 0 {
-		return nil, fmt.Errorf("invalid odds for outcome: %s", outcome)
+		// 6. Insert credit transaction.
+		desc := fmt.Sprintf("Bet placed on event %s", eventID)
+		return s.userRepo.AddTransaction(ctx, tx, &model.CreditTransaction{
+			UserID:       userID,
+			Type:         "bet_placed",
+			Amount:       -amount,
+			BalanceAfter: balance - amount,
+			ReferenceID:  &bet.ID,
+			Description:  &desc,
+			CreatedAt:    now,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Calculate potential payout: amount / lockedOdds (as int64).
-	potentialPayout := int64(float64(amount) / lockedOdds)
+	betsPlaced.Inc()
+	betAmount.Observe(float64(amount))
 
-	// 5. Update user balances and increment total_bets.
-	_, err = tx.Exec(ctx,
-		"UPDATE users SET balance = balance - $1, frozen_balance = frozen_balance + $1, total_bets = total_bets + 1, updated_at = NOW() WHERE id = $2",
-		amount, userID,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("update user balance: %w", err)
-	}
+	return bet, nil
+}
 
-	// 6. Insert bet.
-	betID := uuid.New().String()
-	now := time.Now()
-	bet := &model.Bet{
-		ID:              betID,
-		UserID:          userID,
-		EventID:         eventID,
-		Outcome:         outcome,
-		Amount:          amount,
-		LockedOdds:      lockedOdds,
-		PotentialPayout: potentialPayout,
-		Status:          model.BetStatusPending,
-		CreatedAt:       now,
+// outcomeOdds returns the current price of outcome on event, which is the
+// odds a bet on it is locked at.
+func outcomeOdds(event *model.Event, outcome string) (float64, error) {
+	var outcomeLabels []string
+	if err := json.Unmarshal(event.Outcomes, &outcomeLabels); err != nil {
+		return 0, fmt.Errorf("parse outcomes: %w", err)
 	}
 
-	_, err = tx.Exec(ctx,
-		`INSERT INTO bets (id, user_id, event_id, outcome, amount, locked_odds, potential_payout, status, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-		bet.ID, bet.UserID, bet.EventID, bet.Outcome, bet.Amount,
-		bet.LockedOdds, bet.PotentialPayout, bet.Status, bet.CreatedAt,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("insert bet: %w", err)
+	var prices []string
+	if err := json.Unmarshal(event.OutcomePrices, &prices); err != nil {
+		return 0, fmt.Errorf("parse outcome prices: %w", err)
 	}
 
-	// 7. Insert credit transaction.
-	newBalance := balance - amount
-	desc := fmt.Sprintf("Bet placed on event %s", eventID)
-	_, err = tx.Exec(ctx,
-		`INSERT INTO credit_transactions (user_id, type, amount, balance_after, reference_id, description, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		userID, "bet_placed", -amount, newBalance, &betID, &desc, now,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("insert credit transaction: %w", err)
+	outcomeIdx := findOutcomeIndex(outcomeLabels, outcome)
+	if outcomeIdx == -1 || outcomeIdx >= len(prices) {
+		return 0, apperr.ErrInvalidOutcome.WithDetails(map[string][]string{"outcomes": outcomeLabels})
 	}
 
-	// 8. Commit.
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("commit transaction: %w", err)
+	// Parse the price string to float64.
+	var odds float64
+	_, err := fmt.Sscanf(prices[outcomeIdx], "%f", &odds)
+	if err != nil || odds <= 0 {
+		return 0, apperr.ErrInvalidOutcome.WithMessage("outcome has no tradable odds")
 	}
 
-	return bet, nil
+	return odds, nil
 }
 
-// ListByUser retrieves a paginated list of bets for the given user.
-func (s *BetService) ListByUser(ctx context.Context, userID string, status string, page, pageSize int) ([]model.Bet, int64, error) {
-	return s.betRepo.ListByUser(ctx, userID, status, page, pageSize)
+// ListByUser retrieves a page of bets for the given user.
+func (s *BetService) ListByUser(ctx context.Context, userID string, status string, page db.Page) ([]model.Bet, int64, []string, error) {
+	return s.betRepo.ListByUser(ctx, userID, status, page)
 }
 
 // GetByID retrieves a single bet by ID.
@@ -0,0 +1,95 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/testdb"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func newBetService(pool *pgxpool.Pool) *BetService {
+	return NewBetService(
+		db.NewTransactor(pool),
+		repository.NewBetRepository(pool),
+		repository.NewUserRepository(pool, 1000),
+		repository.NewEventRepository(pool, repository.PriceRetention{}),
+	)
+}
+
+func TestPlaceBetConcurrentNoOverdraw(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	user := testdb.CreateUser(t, pool, 1000)
+	event := testdb.CreateEvent(t, pool, "0.4", "0.6")
+	svc := newBetService(pool)
+
+	// 40 bets of 100 race for a balance that covers exactly 10 of them.
+	const attempts, amount = 40, 100
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.PlaceBet(ctx, user, event, "yes", amount, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	placed := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			placed++
+		case !errors.Is(err, apperr.ErrInsufficientBalance):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if placed != 10 {
+		t.Errorf("placed %d bets, want 10", placed)
+	}
+
+	var balance, frozen int64
+	var bets, totalBets int
+	if err := pool.QueryRow(ctx,
+		`SELECT balance, frozen_balance, total_bets, (SELECT COUNT(*) FROM bets) FROM users WHERE id = $1`, user,
+	).Scan(&balance, &frozen, &totalBets, &bets); err != nil {
+		t.Fatal(err)
+	}
+	if balance != 0 || frozen != 1000 || bets != placed || totalBets != placed {
+		t.Errorf("balance %d, frozen %d, %d bets, total_bets %d; want 0, 1000, %d, %d",
+			balance, frozen, bets, totalBets, placed, placed)
+	}
+	testdb.CheckBalances(t, pool)
+}
+
+func TestPlaceBetRejectsClosedEvent(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	user := testdb.CreateUser(t, pool, 1000)
+	event := testdb.CreateEvent(t, pool, "0.4", "0.6")
+	svc := newBetService(pool)
+
+	if _, err := pool.Exec(ctx, `UPDATE events SET status = 'closed' WHERE id = $1`, event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.PlaceBet(ctx, user, event, "yes", 100, nil); !errors.Is(err, apperr.ErrEventNotOpen) {
+		t.Errorf("expected ErrEventNotOpen, got %v", err)
+	}
+	testdb.CheckBalances(t, pool)
+}
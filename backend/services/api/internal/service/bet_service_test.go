@@ -1,6 +1,16 @@
 package service
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/model"
+)
 
 func TestFindOutcomeIndex(t *testing.T) {
 	labels := []string{"Yes", "No"}
@@ -36,3 +46,162 @@ func TestFindOutcomeIndex_EmptyLabels(t *testing.T) {
 		t.Errorf("findOutcomeIndex(nil, \"yes\") = %d, want -1", got)
 	}
 }
+
+// fakeTransactor runs fn without a database and records whether the
+// transaction would have been committed.
+type fakeTransactor struct {
+	committed bool
+}
+
+func (f *fakeTransactor) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if err := fn(nil); err != nil {
+		return err
+	}
+	f.committed = true
+	return nil
+}
+
+// fakeUsers holds balances in memory. Methods the bet tests do not use panic
+// through the nil embedded interface.
+type fakeUsers struct {
+	UserRepository
+	balances     map[string]int64
+	frozen       map[string]int64
+	transactions []model.CreditTransaction
+	freezeErr    error
+}
+
+func (f *fakeUsers) LockBalance(ctx context.Context, tx pgx.Tx, id string) (int64, bool, error) {
+	balance, ok := f.balances[id]
+	return balance, ok, nil
+}
+
+func (f *fakeUsers) FreezeStake(ctx context.Context, tx pgx.Tx, id string, amount int64) error {
+	if f.freezeErr != nil {
+		return f.freezeErr
+	}
+	f.balances[id] -= amount
+	f.frozen[id] += amount
+	return nil
+}
+
+func (f *fakeUsers) AddTransaction(ctx context.Context, tx pgx.Tx, t *model.CreditTransaction) error {
+	f.transactions = append(f.transactions, *t)
+	return nil
+}
+
+type fakeEvents struct {
+	EventRepository
+	events map[string]*model.Event
+}
+
+func (f *fakeEvents) GetMarket(ctx context.Context, tx pgx.Tx, id string) (*model.Event, error) {
+	return f.events[id], nil
+}
+
+type fakeBets struct {
+	BetRepository
+	created []model.Bet
+}
+
+func (f *fakeBets) Create(ctx context.Context, tx pgx.Tx, bet *model.Bet) error {
+	f.created = append(f.created, *bet)
+	return nil
+}
+
+func market(status model.EventStatus, outcomes, prices string) *model.Event {
+	return &model.Event{Status: status, Outcomes: json.RawMessage(outcomes), OutcomePrices: json.RawMessage(prices)}
+}
+
+func TestPlaceBet(t *testing.T) {
+	odds := func(v float64) *float64 { return &v }
+	events := map[string]*model.Event{
+		"open":     market(model.EventStatusOpen, `["Yes", "No"]`, `["0.4", "0.6"]`),
+		"closed":   market(model.EventStatusClosed, `["Yes", "No"]`, `["0.4", "0.6"]`),
+		"resolved": market(model.EventStatusResolved, `["Yes", "No"]`, `["1", "0"]`),
+		"unpriced": market(model.EventStatusOpen, `["Yes", "No"]`, `["0", "1"]`),
+		"short":    market(model.EventStatusOpen, `["Yes", "No"]`, `["0.4"]`),
+	}
+
+	tests := []struct {
+		name      string
+		userID    string
+		eventID   string
+		outcome   string
+		amount    int64
+		maxOdds   *float64
+		freezeErr error
+		wantErr   error
+		wantOdds  float64
+		wantPay   int64
+	}{
+		{name: "places bet", userID: "alice", eventID: "open", outcome: "Yes", amount: 100, wantOdds: 0.4, wantPay: 250},
+		{name: "outcome is case-insensitive", userID: "alice", eventID: "open", outcome: "no", amount: 300, wantOdds: 0.6, wantPay: 500},
+		{name: "whole balance", userID: "alice", eventID: "open", outcome: "yes", amount: 1000, wantOdds: 0.4, wantPay: 2500},
+		{name: "odds at max", userID: "alice", eventID: "open", outcome: "yes", amount: 100, maxOdds: odds(0.4), wantOdds: 0.4, wantPay: 250},
+		{name: "odds moved", userID: "alice", eventID: "open", outcome: "yes", amount: 100, maxOdds: odds(0.35), wantErr: apperr.ErrOddsMoved},
+		{name: "insufficient balance", userID: "alice", eventID: "open", outcome: "yes", amount: 1001, wantErr: apperr.ErrInsufficientBalance},
+		{name: "unknown user", userID: "bob", eventID: "open", outcome: "yes", amount: 100, wantErr: ErrUserNotFound},
+		{name: "unknown event", userID: "alice", eventID: "missing", outcome: "yes", amount: 100, wantErr: apperr.ErrNotFound},
+		{name: "closed event", userID: "alice", eventID: "closed", outcome: "yes", amount: 100, wantErr: apperr.ErrEventNotOpen},
+		{name: "resolved event", userID: "alice", eventID: "resolved", outcome: "yes", amount: 100, wantErr: apperr.ErrEventNotOpen},
+		{name: "invalid outcome", userID: "alice", eventID: "open", outcome: "maybe", amount: 100, wantErr: apperr.ErrInvalidOutcome},
+		{name: "outcome without price", userID: "alice", eventID: "short", outcome: "no", amount: 100, wantErr: apperr.ErrInvalidOutcome},
+		{name: "zero price", userID: "alice", eventID: "unpriced", outcome: "yes", amount: 100, wantErr: apperr.ErrInvalidOutcome},
+		{name: "repository error rolls back", userID: "alice", eventID: "open", outcome: "yes", amount: 100, freezeErr: errBoom, wantErr: errBoom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTransactor{}
+			users := &fakeUsers{
+				balances:  map[string]int64{"alice": 1000},
+				frozen:    map[string]int64{},
+				freezeErr: tt.freezeErr,
+			}
+			bets := &fakeBets{}
+			svc := NewBetService(tx, bets, users, &fakeEvents{events: events})
+
+			bet, err := svc.PlaceBet(context.Background(), tt.userID, tt.eventID, tt.outcome, tt.amount, tt.maxOdds)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PlaceBet() error = %v, want %v", err, tt.wantErr)
+				}
+				if tx.committed || len(bets.created) != 0 || len(users.transactions) != 0 {
+					t.Errorf("failed bet was committed: %d bets, %d transactions", len(bets.created), len(users.transactions))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlaceBet() error = %v", err)
+			}
+
+			if !tx.committed {
+				t.Error("transaction not committed")
+			}
+			if bet.LockedOdds != tt.wantOdds || bet.PotentialPayout != tt.wantPay || bet.Status != model.BetStatusPending {
+				t.Errorf("bet at %v paying %d (%s), want %v paying %d (pending)", bet.LockedOdds, bet.PotentialPayout, bet.Status, tt.wantOdds, tt.wantPay)
+			}
+			if len(bets.created) != 1 || bets.created[0].ID != bet.ID {
+				t.Errorf("created bets %+v, want the returned bet", bets.created)
+			}
+			if got, want := users.balances["alice"], 1000-tt.amount; got != want {
+				t.Errorf("balance %d, want %d", got, want)
+			}
+			if got := users.frozen["alice"]; got != tt.amount {
+				t.Errorf("frozen balance %d, want %d", got, tt.amount)
+			}
+
+			if len(users.transactions) != 1 {
+				t.Fatalf("%d credit transactions, want 1", len(users.transactions))
+			}
+			ct := users.transactions[0]
+			if ct.Type != "bet_placed" || ct.Amount != -tt.amount || ct.BalanceAfter != 1000-tt.amount ||
+				ct.ReferenceID == nil || *ct.ReferenceID != bet.ID {
+				t.Errorf("credit transaction %+v does not record the bet", ct)
+			}
+		})
+	}
+}
+
+var errBoom = errors.New("boom")
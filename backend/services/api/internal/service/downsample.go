@@ -0,0 +1,91 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// downsampleByOutcome reduces each outcome's series to at most maxPoints using
+// LTTB and returns the merged result ordered by time. A maxPoints below 3
+// disables downsampling.
+func downsampleByOutcome(history []model.PriceHistory, maxPoints int) []model.PriceHistory {
+	if maxPoints < 3 {
+		return history
+	}
+
+	var labels []string
+	series := make(map[string][]model.PriceHistory)
+	for _, ph := range history {
+		if _, ok := series[ph.OutcomeLabel]; !ok {
+			labels = append(labels, ph.OutcomeLabel)
+		}
+		series[ph.OutcomeLabel] = append(series[ph.OutcomeLabel], ph)
+	}
+
+	result := make([]model.PriceHistory, 0, len(history))
+	for _, label := range labels {
+		result = append(result, lttb(series[label], maxPoints)...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RecordedAt.Before(result[j].RecordedAt)
+	})
+
+	return result
+}
+
+// lttb applies the Largest-Triangle-Three-Buckets algorithm to a time-ordered
+// series, keeping the first and last points and, from each bucket in between,
+// the point forming the largest triangle with its neighbours. This preserves
+// the visual shape of a price chart far better than fixed-stride sampling.
+func lttb(points []model.PriceHistory, threshold int) []model.PriceHistory {
+	if threshold >= len(points) || threshold < 3 {
+		return points
+	}
+
+	x := func(i int) float64 { return float64(points[i].RecordedAt.UnixMilli()) }
+	y := func(i int) float64 { return points[i].Price }
+
+	sampled := make([]model.PriceHistory, 0, threshold)
+	sampled = append(sampled, points[0])
+
+	// Buckets cover every point except the fixed first and last.
+	every := float64(len(points)-2) / float64(threshold-2)
+	prev := 0
+
+	for b := 0; b < threshold-2; b++ {
+		// Average of the next bucket, used as the third triangle vertex.
+		nextStart := int(math.Floor(float64(b+1)*every)) + 1
+		nextEnd := int(math.Floor(float64(b+2)*every)) + 1
+		if nextEnd > len(points) {
+			nextEnd = len(points)
+		}
+		var avgX, avgY float64
+		for i := nextStart; i < nextEnd; i++ {
+			avgX += x(i)
+			avgY += y(i)
+		}
+		if n := float64(nextEnd - nextStart); n > 0 {
+			avgX /= n
+			avgY /= n
+		}
+
+		// Pick the point in the current bucket with the largest triangle.
+		start := int(math.Floor(float64(b)*every)) + 1
+		end := int(math.Floor(float64(b+1)*every)) + 1
+		best, bestArea := start, -1.0
+		for i := start; i < end; i++ {
+			area := math.Abs((x(prev)-avgX)*(y(i)-y(prev)) - (x(prev)-x(i))*(avgY-y(prev)))
+			if area > bestArea {
+				best, bestArea = i, area
+			}
+		}
+
+		sampled = append(sampled, points[best])
+		prev = best
+	}
+
+	return append(sampled, points[len(points)-1])
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+func series(label string, prices ...float64) []model.PriceHistory {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]model.PriceHistory, len(prices))
+	for i, p := range prices {
+		out[i] = model.PriceHistory{OutcomeLabel: label, Price: p, RecordedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return out
+}
+
+func TestLTTB_KeepsEndpointsAndSpike(t *testing.T) {
+	points := series("Yes", 0.5, 0.5, 0.5, 0.9, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5)
+
+	got := lttb(points, 4)
+	if len(got) != 4 {
+		t.Fatalf("len = %d, want 4", len(got))
+	}
+	if !got[0].RecordedAt.Equal(points[0].RecordedAt) || !got[3].RecordedAt.Equal(points[9].RecordedAt) {
+		t.Error("first and last points must be preserved")
+	}
+
+	var sawSpike bool
+	for _, p := range got {
+		if p.Price == 0.9 {
+			sawSpike = true
+		}
+	}
+	if !sawSpike {
+		t.Error("downsampled series dropped the price spike")
+	}
+}
+
+func TestLTTB_ShortSeriesUntouched(t *testing.T) {
+	points := series("Yes", 0.1, 0.2, 0.3)
+	if got := lttb(points, 10); len(got) != 3 {
+		t.Errorf("len = %d, want 3", len(got))
+	}
+}
+
+func TestDownsampleByOutcome(t *testing.T) {
+	history := append(series("Yes", 0.1, 0.2, 0.3, 0.4, 0.5, 0.6), series("No", 0.9, 0.8, 0.7, 0.6, 0.5, 0.4)...)
+
+	got := downsampleByOutcome(history, 3)
+	if len(got) != 6 {
+		t.Fatalf("len = %d, want 6", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].RecordedAt.Before(got[i-1].RecordedAt) {
+			t.Fatal("result is not ordered by time")
+		}
+	}
+}
@@ -2,23 +2,49 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/poly-predict/backend/pkg/apperr"
 	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/services/api/internal/repository"
 )
 
+// EventWithPosition is an event annotated with the caller's aggregated
+// position. MyPosition is omitted for anonymous callers and for events the
+// caller has not bet on.
+type EventWithPosition struct {
+	model.Event
+	MyPosition *repository.Position `json:"my_position,omitempty"`
+}
+
+// maxCandles caps the number of buckets a single candles request may span.
+const maxCandles = 1000
+
+// ErrInvalidCandleRange is returned for an unsupported candle interval or
+// period, a combination producing more than maxCandles buckets, or an
+// interval finer than the stored resolution of the period.
+var ErrInvalidCandleRange = apperr.ErrValidation.WithMessage("invalid candle interval or period")
+
+// OutcomeCandles is the candle series for one outcome of an event.
+type OutcomeCandles struct {
+	Outcome string              `json:"outcome"`
+	Candles []repository.Candle `json:"candles"`
+}
+
 // EventService wraps EventRepository methods.
 type EventService struct {
-	repo *repository.EventRepository
+	repo    EventRepository
+	betRepo BetRepository
 }
 
 // NewEventService creates a new EventService.
-func NewEventService(repo *repository.EventRepository) *EventService {
-	return &EventService{repo: repo}
+func NewEventService(repo EventRepository, betRepo BetRepository) *EventService {
+	return &EventService{repo: repo, betRepo: betRepo}
 }
 
-// List retrieves a paginated list of events with filters.
-func (s *EventService) List(ctx context.Context, filters repository.EventFilters) ([]model.Event, int64, error) {
+// List retrieves a page of events with filters.
+func (s *EventService) List(ctx context.Context, filters repository.EventFilters) ([]model.Event, int64, []string, error) {
 	return s.repo.List(ctx, filters)
 }
 
@@ -27,9 +53,86 @@ func (s *EventService) GetByID(ctx context.Context, id string) (*model.Event, er
 	return s.repo.GetByID(ctx, id)
 }
 
+// WithPositions annotates events with the given user's positions using a
+// single aggregate query. An empty userID leaves every position unset.
+func (s *EventService) WithPositions(ctx context.Context, userID string, events []model.Event) ([]EventWithPosition, error) {
+	result := make([]EventWithPosition, len(events))
+	for i, e := range events {
+		result[i].Event = e
+	}
+
+	if userID == "" || len(events) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+
+	positions, err := s.betRepo.PositionsByEvents(ctx, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("load positions: %w", err)
+	}
+
+	for i := range result {
+		result[i].MyPosition = positions[result[i].ID]
+	}
+
+	return result, nil
+}
+
+// Suggest returns lightweight typeahead matches for the given search text.
+func (s *EventService) Suggest(ctx context.Context, search string, limit int) ([]repository.EventSuggestion, error) {
+	return s.repo.Suggest(ctx, search, limit)
+}
+
 // GetPriceHistory retrieves price history for an event filtered by period.
-func (s *EventService) GetPriceHistory(ctx context.Context, eventID string, period string) ([]model.PriceHistory, error) {
-	return s.repo.GetPriceHistory(ctx, eventID, period)
+// When maxPoints is at least 3, each outcome's series is downsampled to at
+// most that many points.
+func (s *EventService) GetPriceHistory(ctx context.Context, eventID string, period string, maxPoints int) ([]model.PriceHistory, error) {
+	history, err := s.repo.GetPriceHistory(ctx, eventID, period)
+	if err != nil {
+		return nil, err
+	}
+	return downsampleByOutcome(history, maxPoints), nil
+}
+
+// GetCandles returns per-outcome OHLC candles for an event. interval and
+// period must be keys of repository.CandleIntervals and
+// repository.PricePeriods, and may not produce more than maxCandles buckets.
+func (s *EventService) GetCandles(ctx context.Context, eventID, interval, period string) ([]OutcomeCandles, error) {
+	width, ok := repository.CandleIntervals[interval]
+	if !ok {
+		return nil, ErrInvalidCandleRange
+	}
+	lookback, ok := repository.PricePeriods[period]
+	if !ok {
+		return nil, ErrInvalidCandleRange
+	}
+	if lookback/width > maxCandles {
+		return nil, ErrInvalidCandleRange
+	}
+
+	candles, err := s.repo.GetCandles(ctx, eventID, width, lookback)
+	if errors.Is(err, repository.ErrResolutionUnavailable) {
+		return nil, ErrInvalidCandleRange
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows arrive ordered by outcome, so consecutive runs form each series.
+	var result []OutcomeCandles
+	for _, cd := range candles {
+		if len(result) == 0 || result[len(result)-1].Outcome != cd.OutcomeLabel {
+			result = append(result, OutcomeCandles{Outcome: cd.OutcomeLabel})
+		}
+		last := &result[len(result)-1]
+		last.Candles = append(last.Candles, cd)
+	}
+
+	return result, nil
 }
 
 // GetCategories retrieves all categories with their event counts.
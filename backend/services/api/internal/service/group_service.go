@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// GroupWithMarkets is a market group together with its child markets.
+type GroupWithMarkets struct {
+	model.MarketGroup
+	Markets []model.Event `json:"markets"`
+}
+
+// GroupService handles market group queries.
+type GroupService struct {
+	groupRepo GroupRepository
+	eventRepo EventRepository
+}
+
+// NewGroupService creates a new GroupService.
+func NewGroupService(groupRepo GroupRepository, eventRepo EventRepository) *GroupService {
+	return &GroupService{groupRepo: groupRepo, eventRepo: eventRepo}
+}
+
+// List retrieves a page of market groups, each with its child markets.
+func (s *GroupService) List(ctx context.Context, tag string, page db.Page) ([]GroupWithMarkets, int64, []string, error) {
+	groups, total, next, err := s.groupRepo.List(ctx, tag, page)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	result, err := s.withMarkets(ctx, groups)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	return result, total, next, nil
+}
+
+// GetByID retrieves a single market group with its child markets, or nil if
+// the group does not exist.
+func (s *GroupService) GetByID(ctx context.Context, id string) (*GroupWithMarkets, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil || group == nil {
+		return nil, err
+	}
+
+	result, err := s.withMarkets(ctx, []model.MarketGroup{*group})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result[0], nil
+}
+
+// withMarkets loads the child markets for all groups in one query.
+func (s *GroupService) withMarkets(ctx context.Context, groups []model.MarketGroup) ([]GroupWithMarkets, error) {
+	ids := make([]string, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+	}
+
+	markets, err := s.eventRepo.ListByGroups(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("load group markets: %w", err)
+	}
+
+	result := make([]GroupWithMarkets, len(groups))
+	for i, g := range groups {
+		result[i].MarketGroup = g
+		result[i].Markets = markets[g.ID]
+		if result[i].Markets == nil {
+			result[i].Markets = []model.Event{}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/poly-predict/backend/pkg/metrics"
+)
+
+var (
+	betsPlaced = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "bets_placed_total",
+		Help:      "Bets placed successfully.",
+	})
+
+	betAmount = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "bet_amount_credits",
+		Help:      "Amount staked per placed bet, in credits.",
+		Buckets:   prometheus.ExponentialBuckets(10, 4, 8),
+	})
+)
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/moderation"
+)
+
+var (
+	// ErrHandleTaken is returned when another user has the handle, in any
+	// letter case.
+	ErrHandleTaken = apperr.New(http.StatusConflict, apperr.CodeHandleTaken, "handle is already taken")
+	// ErrNameBlocked is returned when a handle or display name contains a
+	// blocklisted term.
+	ErrNameBlocked = apperr.New(http.StatusUnprocessableEntity, apperr.CodeNameBlocked, "name is not allowed")
+	// ErrHandleCooldown is returned when the handle was changed too recently.
+	ErrHandleCooldown = apperr.New(http.StatusTooManyRequests, apperr.CodeRenameCooldown, "handle was changed too recently")
+)
+
+// ProfileService handles reading a user's profile and credit ledger and
+// changing their handle and display name.
+type ProfileService struct {
+	tx        db.Transactor
+	users     UserRepository
+	blocklist BlocklistRepository
+}
+
+// NewProfileService creates a new ProfileService.
+func NewProfileService(tx db.Transactor, users UserRepository, blocklist BlocklistRepository) *ProfileService {
+	return &ProfileService{tx: tx, users: users, blocklist: blocklist}
+}
+
+// GetOrCreate returns the user's profile, creating the user with the starting
+// balance and a generated handle on their first request. created reports
+// whether this call created them.
+func (s *ProfileService) GetOrCreate(ctx context.Context, userID string) (user *model.User, created bool, err error) {
+	return s.users.GetOrCreate(ctx, userID)
+}
+
+// Transactions returns a page of the user's credit ledger.
+func (s *ProfileService) Transactions(ctx context.Context, userID string, page db.Page) ([]model.CreditTransaction, int64, []string, error) {
+	return s.users.GetTransactions(ctx, userID, page)
+}
+
+// Update sets the user's display name and/or handle; nil leaves a field
+// unchanged. Both are checked against the blocklist. A handle change is
+// limited to one per model.HandleChangeCooldown, except for the first change
+// away from the generated handle.
+func (s *ProfileService) Update(ctx context.Context, userID string, displayName, handle *string) (*model.User, error) {
+	if displayName != nil {
+		cleaned, err := moderation.CleanDisplayName(*displayName)
+		if err != nil {
+			return nil, err
+		}
+		displayName = &cleaned
+	}
+	if handle != nil {
+		if err := moderation.ValidateHandle(*handle); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkBlocklist(ctx, displayName, handle); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.InTx(ctx, func(tx pgx.Tx) error {
+		// 1. Lock the user so concurrent renames cannot both pass the cooldown.
+		current, changedAt, found, err := s.users.LockHandle(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrUserNotFound
+		}
+
+		// 2. Only an actual change of handle counts against the cooldown.
+		if handle != nil && *handle == current {
+			handle = nil
+		}
+		if handle != nil && changedAt != nil {
+			if until := changedAt.Add(model.HandleChangeCooldown); time.Now().Before(until) {
+				return ErrHandleCooldown.WithDetails(map[string]time.Time{"retry_at": until.UTC()})
+			}
+		}
+
+		// 3. Apply the change; the unique index settles races for the handle.
+		updated, err := s.users.UpdateProfile(ctx, tx, userID, displayName, handle)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrHandleTaken
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.users.GetByID(ctx, userID)
+}
+
+// checkBlocklist rejects names containing a blocked term.
+func (s *ProfileService) checkBlocklist(ctx context.Context, names ...*string) error {
+	terms, err := s.blocklist.Terms(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if name == nil {
+			continue
+		}
+		if _, blocked := moderation.Match(*name, terms); blocked {
+			return ErrNameBlocked
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// fakeProfiles keeps alice's handle in memory; other users do not exist.
+type fakeProfiles struct {
+	UserRepository
+	handle    string
+	changedAt *time.Time
+	taken     string
+	updates   int
+}
+
+func (f *fakeProfiles) LockHandle(ctx context.Context, tx pgx.Tx, id string) (string, *time.Time, bool, error) {
+	if id != "alice" {
+		return "", nil, false, nil
+	}
+	return f.handle, f.changedAt, true, nil
+}
+
+func (f *fakeProfiles) UpdateProfile(ctx context.Context, tx pgx.Tx, id string, displayName, handle *string) (bool, error) {
+	if handle != nil && *handle == f.taken {
+		return false, nil
+	}
+	f.updates++
+	if handle != nil {
+		now := time.Now()
+		f.handle, f.changedAt = *handle, &now
+	}
+	return true, nil
+}
+
+func (f *fakeProfiles) GetByID(ctx context.Context, id string) (*model.User, error) {
+	return &model.User{ID: id, Handle: f.handle, HandleChangedAt: f.changedAt}, nil
+}
+
+type fakeBlocklist struct{ terms []string }
+
+func (f fakeBlocklist) Terms(ctx context.Context) ([]string, error) {
+	return f.terms, nil
+}
+
+func TestUpdateProfile(t *testing.T) {
+	str := func(s string) *string { return &s }
+	recently := time.Now().Add(-time.Hour)
+	longAgo := time.Now().Add(-model.HandleChangeCooldown - time.Hour)
+
+	tests := []struct {
+		name        string
+		userID      string
+		changedAt   *time.Time
+		displayName *string
+		handle      *string
+		wantErr     error
+		wantHandle  string
+	}{
+		{name: "first rename", userID: "alice", handle: str("alice_b"), wantHandle: "alice_b"},
+		{name: "rename after cooldown", userID: "alice", changedAt: &longAgo, handle: str("alice_b"), wantHandle: "alice_b"},
+		{name: "rename during cooldown", userID: "alice", changedAt: &recently, handle: str("alice_b"), wantErr: ErrHandleCooldown},
+		{name: "same handle during cooldown", userID: "alice", changedAt: &recently, displayName: str("Alice"), handle: str("player_1"), wantHandle: "player_1"},
+		{name: "handle taken", userID: "alice", handle: str("bob"), wantErr: ErrHandleTaken},
+		{name: "blocked handle", userID: "alice", handle: str("badword_1"), wantErr: ErrNameBlocked},
+		{name: "blocked display name", userID: "alice", displayName: str("Bad Word"), wantErr: ErrNameBlocked},
+		{name: "invalid handle", userID: "alice", handle: str("a"), wantErr: apperr.ErrValidation},
+		{name: "unknown user", userID: "carol", displayName: str("Carol"), wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTransactor{}
+			users := &fakeProfiles{handle: "player_1", changedAt: tt.changedAt, taken: "bob"}
+			svc := NewProfileService(tx, users, fakeBlocklist{terms: []string{"badword"}})
+
+			user, err := svc.Update(context.Background(), tt.userID, tt.displayName, tt.handle)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+				}
+				if tx.committed || users.updates != 0 {
+					t.Errorf("refused update was applied")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			if !tx.committed || users.updates != 1 {
+				t.Errorf("committed %v after %d updates, want one committed update", tx.committed, users.updates)
+			}
+			if user.Handle != tt.wantHandle {
+				t.Errorf("handle %q, want %q", user.Handle, tt.wantHandle)
+			}
+			if tt.handle != nil && *tt.handle == "player_1" && user.HandleChangedAt != tt.changedAt {
+				t.Error("keeping the handle restarted the cooldown")
+			}
+		})
+	}
+}
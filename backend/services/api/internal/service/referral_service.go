@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/apperr"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+var (
+	// ErrReferralCodeNotFound is returned when no user has the given code.
+	ErrReferralCodeNotFound = apperr.New(http.StatusNotFound, apperr.CodeReferralCodeNotFound, "referral code not found")
+	// ErrAlreadyReferred is returned when the user has already claimed a code.
+	ErrAlreadyReferred = apperr.New(http.StatusConflict, apperr.CodeAlreadyReferred, "a referral code has already been claimed")
+	// ErrSelfReferral is returned for a user's own code, or the code of a
+	// user they referred.
+	ErrSelfReferral = apperr.New(http.StatusUnprocessableEntity, apperr.CodeSelfReferral, "cannot claim this referral code")
+	// ErrReferralClaimClosed is returned once the claim window has passed or
+	// the user has settled a bet.
+	ErrReferralClaimClosed = apperr.New(http.StatusUnprocessableEntity, apperr.CodeReferralClaimClosed, "referral codes can only be claimed by new users")
+	// ErrUserNotFound is returned when the user does not exist yet or has
+	// been deleted.
+	ErrUserNotFound = apperr.ErrNotFound.WithMessage("user not found")
+)
+
+// ReferralService handles referral business logic. Bonuses are paid by the
+// settlement flow once a referee has settled enough bets.
+type ReferralService struct {
+	tx   db.Transactor
+	repo ReferralRepository
+}
+
+// NewReferralService creates a new ReferralService.
+func NewReferralService(tx db.Transactor, repo ReferralRepository) *ReferralService {
+	return &ReferralService{
+		tx:   tx,
+		repo: repo,
+	}
+}
+
+// Claim records that refereeID signed up with code. Only users inside the
+// claim window who have not settled any bets may claim, and only once. ip is
+// kept for the admin farming report and may be empty.
+func (s *ReferralService) Claim(ctx context.Context, refereeID, code, ip string) (*model.Referral, error) {
+	var ref *model.Referral
+	err := s.tx.InTx(ctx, func(tx pgx.Tx) error {
+		// 1. Lock the referee and check they are still eligible to claim.
+		referee, err := s.repo.LockReferee(ctx, tx, refereeID)
+		if err != nil {
+			return err
+		}
+		if referee == nil {
+			return ErrUserNotFound
+		}
+
+		if time.Since(referee.CreatedAt) > model.ReferralClaimWindow {
+			return ErrReferralClaimClosed
+		}
+		if referee.Claimed {
+			return ErrAlreadyReferred
+		}
+		if referee.Settled {
+			return ErrReferralClaimClosed
+		}
+
+		// 2. Resolve the code, rejecting self-referrals and referral loops.
+		referrerID, loop, found, err := s.repo.FindReferrer(ctx, tx, code, refereeID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrReferralCodeNotFound
+		}
+
+		if referrerID == refereeID || loop {
+			return ErrSelfReferral
+		}
+
+		// 3. Record the referral.
+		ref, err = s.repo.Create(ctx, tx, referrerID, refereeID, code, ip)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ref, nil
+}
+
+// ListByReferrer returns a page of the referrals made with a user's code.
+func (s *ReferralService) ListByReferrer(ctx context.Context, userID string, page db.Page) ([]repository.ReferralProgress, int64, []string, error) {
+	return s.repo.ListByReferrer(ctx, userID, page)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+// fakeReferrals knows the referees and referral codes it is given; "ALICE1"
+// belongs to alice and "BOB1" to bob, whom alice referred.
+type fakeReferrals struct {
+	ReferralRepository
+	referees map[string]*repository.Referee
+	created  []model.Referral
+}
+
+func (f *fakeReferrals) LockReferee(ctx context.Context, tx pgx.Tx, id string) (*repository.Referee, error) {
+	return f.referees[id], nil
+}
+
+func (f *fakeReferrals) FindReferrer(ctx context.Context, tx pgx.Tx, code, refereeID string) (string, bool, bool, error) {
+	switch strings.ToUpper(code) {
+	case "ALICE1":
+		return "alice", false, true, nil
+	case "BOB1":
+		return "bob", refereeID == "alice", true, nil
+	}
+	return "", false, false, nil
+}
+
+func (f *fakeReferrals) Create(ctx context.Context, tx pgx.Tx, referrerID, refereeID, code, ip string) (*model.Referral, error) {
+	ref := model.Referral{ReferrerID: referrerID, RefereeID: refereeID, Code: strings.ToUpper(code), Status: model.ReferralStatusPending}
+	f.created = append(f.created, ref)
+	return &ref, nil
+}
+
+func TestClaimReferral(t *testing.T) {
+	now := time.Now()
+	referees := map[string]*repository.Referee{
+		"alice":   {CreatedAt: now},
+		"carol":   {CreatedAt: now},
+		"late":    {CreatedAt: now.Add(-model.ReferralClaimWindow - time.Hour)},
+		"claimed": {CreatedAt: now, Claimed: true},
+		"settled": {CreatedAt: now, Settled: true},
+	}
+
+	tests := []struct {
+		name         string
+		refereeID    string
+		code         string
+		wantErr      error
+		wantReferrer string
+	}{
+		{name: "claims code", refereeID: "carol", code: "alice1", wantReferrer: "alice"},
+		{name: "unknown code", refereeID: "carol", code: "nobody", wantErr: ErrReferralCodeNotFound},
+		{name: "own code", refereeID: "alice", code: "ALICE1", wantErr: ErrSelfReferral},
+		{name: "code of own referee", refereeID: "alice", code: "BOB1", wantErr: ErrSelfReferral},
+		{name: "already claimed", refereeID: "claimed", code: "ALICE1", wantErr: ErrAlreadyReferred},
+		{name: "after claim window", refereeID: "late", code: "ALICE1", wantErr: ErrReferralClaimClosed},
+		{name: "after settled bet", refereeID: "settled", code: "ALICE1", wantErr: ErrReferralClaimClosed},
+		{name: "unknown user", refereeID: "dave", code: "ALICE1", wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTransactor{}
+			repo := &fakeReferrals{referees: referees}
+			svc := NewReferralService(tx, repo)
+
+			ref, err := svc.Claim(context.Background(), tt.refereeID, tt.code, "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Claim() error = %v, want %v", err, tt.wantErr)
+				}
+				if tx.committed || len(repo.created) != 0 {
+					t.Errorf("refused claim was recorded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Claim() error = %v", err)
+			}
+
+			if !tx.committed || len(repo.created) != 1 {
+				t.Errorf("committed %v with %d referrals, want one committed referral", tx.committed, len(repo.created))
+			}
+			if ref.ReferrerID != tt.wantReferrer || ref.RefereeID != tt.refereeID {
+				t.Errorf("referral %+v, want %s referring %s", ref, tt.wantReferrer, tt.refereeID)
+			}
+		})
+	}
+}
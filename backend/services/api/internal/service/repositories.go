@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/services/api/internal/repository"
+)
+
+// The services depend on these interfaces rather than on the repository
+// structs so their logic can be tested against fakes. Each lists the methods
+// the services use; the repository package provides the implementations.
+// Methods taking a pgx.Tx run within a transaction begun by a db.Transactor.
+
+// BetRepository is the data access the services need for bets.
+type BetRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, bet *model.Bet) error
+	ListByUser(ctx context.Context, userID string, status string, page db.Page) ([]model.Bet, int64, []string, error)
+	GetByID(ctx context.Context, id string) (*model.Bet, error)
+	PositionsByEvents(ctx context.Context, userID string, eventIDs []string) (map[string]*repository.Position, error)
+}
+
+// UserRepository is the data access the services need for users and their
+// credit ledger.
+type UserRepository interface {
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	GetOrCreate(ctx context.Context, id string) (user *model.User, created bool, err error)
+	GetTransactions(ctx context.Context, userID string, page db.Page) ([]model.CreditTransaction, int64, []string, error)
+	UpdateAvatarURL(ctx context.Context, id string, url *string) (*model.User, error)
+	LockBalance(ctx context.Context, tx pgx.Tx, id string) (balance int64, found bool, err error)
+	FreezeStake(ctx context.Context, tx pgx.Tx, id string, amount int64) error
+	AddTransaction(ctx context.Context, tx pgx.Tx, t *model.CreditTransaction) error
+	LockHandle(ctx context.Context, tx pgx.Tx, id string) (handle string, changedAt *time.Time, found bool, err error)
+	UpdateProfile(ctx context.Context, tx pgx.Tx, id string, displayName, handle *string) (updated bool, err error)
+}
+
+// EventRepository is the data access the services need for events.
+type EventRepository interface {
+	List(ctx context.Context, filters repository.EventFilters) ([]model.Event, int64, []string, error)
+	GetByID(ctx context.Context, id string) (*model.Event, error)
+	GetMarket(ctx context.Context, tx pgx.Tx, id string) (*model.Event, error)
+	GetPriceHistory(ctx context.Context, eventID string, period string) ([]model.PriceHistory, error)
+	GetCandles(ctx context.Context, eventID string, interval, lookback time.Duration) ([]repository.Candle, error)
+	ListByGroups(ctx context.Context, groupIDs []string) (map[string][]model.Event, error)
+	Suggest(ctx context.Context, search string, limit int) ([]repository.EventSuggestion, error)
+	GetCategories(ctx context.Context) ([]repository.CategoryCount, error)
+}
+
+// GroupRepository is the data access the services need for market groups.
+type GroupRepository interface {
+	List(ctx context.Context, tag string, page db.Page) ([]model.MarketGroup, int64, []string, error)
+	GetByID(ctx context.Context, id string) (*model.MarketGroup, error)
+}
+
+// RankingRepository is the data access the services need for rankings.
+type RankingRepository interface {
+	List(ctx context.Context, filters repository.RankingFilters) ([]repository.RankingEntry, int64, []string, error)
+}
+
+// ReferralRepository is the data access the services need for referrals.
+type ReferralRepository interface {
+	ListByReferrer(ctx context.Context, referrerID string, page db.Page) ([]repository.ReferralProgress, int64, []string, error)
+	LockReferee(ctx context.Context, tx pgx.Tx, id string) (*repository.Referee, error)
+	FindReferrer(ctx context.Context, tx pgx.Tx, code, refereeID string) (referrerID string, loop, found bool, err error)
+	Create(ctx context.Context, tx pgx.Tx, referrerID, refereeID, code, ip string) (*model.Referral, error)
+}
+
+// AccountRepository is the data access the services need for account export
+// and deletion.
+type AccountRepository interface {
+	Export(ctx context.Context, userID string) (*repository.AccountExport, error)
+	Anonymize(ctx context.Context, userID string) (bool, error)
+}
+
+// APIKeyRepository is the data access the services need for API keys.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey, hash string, limit int) (*model.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, userID, id string) (bool, error)
+	GetActiveByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	TouchLastUsed(ctx context.Context, id string, resolution time.Duration) error
+}
+
+// BlocklistRepository is the data access the services need for the name
+// blocklist.
+type BlocklistRepository interface {
+	Terms(ctx context.Context) ([]string, error)
+}
+
+// Compile-time checks that the repositories implement the interfaces.
+var (
+	_ BetRepository       = (*repository.BetRepository)(nil)
+	_ UserRepository      = (*repository.UserRepository)(nil)
+	_ EventRepository     = (*repository.EventRepository)(nil)
+	_ GroupRepository     = (*repository.GroupRepository)(nil)
+	_ RankingRepository   = (*repository.RankingRepository)(nil)
+	_ ReferralRepository  = (*repository.ReferralRepository)(nil)
+	_ AccountRepository   = (*repository.AccountRepository)(nil)
+	_ APIKeyRepository    = (*repository.APIKeyRepository)(nil)
+	_ BlocklistRepository = (*repository.BlocklistRepository)(nil)
+)
@@ -2,38 +2,36 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/poly-predict/backend/pkg/config"
 	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/health"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/metrics"
+	"github.com/poly-predict/backend/pkg/migrate"
 	"github.com/poly-predict/backend/services/scraper/internal/polymarket"
+	"github.com/poly-predict/backend/services/scraper/internal/retention"
 	"github.com/poly-predict/backend/services/scraper/internal/scheduler"
 	"github.com/poly-predict/backend/services/scraper/internal/syncer"
 )
 
 func main() {
-	// Configure zerolog.
-	zerolog.TimeFieldFormat = time.RFC3339
-	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
-		With().
-		Timestamp().
-		Str("service", "scraper").
-		Logger()
-
-	log.Info().Msg("starting scraper service")
-
 	// Load configuration.
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.ServiceScraper)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
 
+	logging.Setup("scraper", cfg.Environment, cfg.LogLevel)
+	log.Info().Msg("starting scraper service")
+
 	log.Info().
 		Str("environment", cfg.Environment).
 		Msg("config loaded")
@@ -48,10 +46,55 @@ func main() {
 
 	log.Info().Msg("database connection established")
 
+	if cfg.MigrateOnStart {
+		if err := migrate.ApplyEmbedded(ctx, pool); err != nil {
+			log.Fatal().Err(err).Msg("failed to apply database migrations")
+		}
+	}
+
 	// Create API clients and syncer.
-	gammaClient := polymarket.NewGammaClient()
-	clobClient := polymarket.NewCLOBClient()
+	// Both clients share the breakers, so one per upstream host.
+	resilience := polymarket.Resilience{
+		Retry: polymarket.RetryPolicy{
+			MaxAttempts: cfg.Scraper.HTTPRetries,
+			BaseDelay:   cfg.Scraper.RetryBaseDelay,
+			MaxDelay:    cfg.Scraper.RetryMaxDelay,
+			PageResumes: cfg.Scraper.PageResumes,
+		},
+		Breakers: polymarket.NewBreakers(cfg.Scraper.CircuitThreshold, cfg.Scraper.CircuitCooldown),
+	}
+	gammaClient := polymarket.NewGammaClient(cfg.Scraper.GammaURL, cfg.Scraper.HTTPTimeout, resilience)
+	clobClient := polymarket.NewCLOBClient(cfg.Scraper.CLOBURL, cfg.Scraper.HTTPTimeout, resilience, polymarket.CLOBLimits{
+		Concurrency:   cfg.Scraper.CLOBConcurrency,
+		RatePerSecond: float64(cfg.Scraper.CLOBRateLimit),
+		BatchSize:     cfg.Scraper.CLOBBatchSize,
+	})
 	syncService := syncer.New(pool, gammaClient, clobClient)
+	retainer := retention.New(pool, retention.Policy{
+		Raw:    cfg.Prices.RawRetention,
+		Hourly: cfg.Prices.HourlyRetention,
+	})
+
+	// Metrics and probes. Readiness fails when the last sync failed or is
+	// older than SyncStaleAfter, so a stalled scraper is caught.
+	readiness := health.NewChecker(
+		health.Database(pool),
+		health.PoolSaturation(pool, 0.9),
+		health.LastRun(syncService.Runs(), cfg.Health.SyncStaleAfter),
+		health.SyncFreshness(pool, cfg.Health.SyncStaleAfter, true),
+		health.SettlementBacklog(pool, cfg.Health.SettlementStaleAfter, false),
+	)
+	metrics.RegisterPool(pool)
+	metricsSrv := metrics.Serve(":"+cfg.MetricsPort(), map[string]http.Handler{
+		"/livez":  health.Live(),
+		"/readyz": readiness,
+	})
+
+	// Make sure the current month's price history partition exists before
+	// the first sync writes to it.
+	if err := retainer.EnsurePartitions(ctx, time.Now()); err != nil {
+		log.Fatal().Err(err).Msg("failed to create price history partitions")
+	}
 
 	// Run an initial sync immediately.
 	log.Info().Msg("running initial sync")
@@ -60,7 +103,10 @@ func main() {
 	}
 
 	// Set up cron scheduler.
-	sched := scheduler.New(syncService)
+	sched := scheduler.New(syncService, retainer, scheduler.Intervals{
+		Sync:      cfg.Scraper.SyncInterval,
+		Retention: cfg.Scraper.RetentionInterval,
+	})
 	sched.Start()
 
 	// Block until shutdown signal.
@@ -72,6 +118,7 @@ func main() {
 
 	// Graceful shutdown.
 	sched.Stop()
+	metricsSrv.Close()
 	db.Close(pool)
 
 	log.Info().Msg("scraper service stopped")
@@ -0,0 +1,85 @@
+// Command polymarket-stub serves fixture markets on the Gamma /markets and
+// CLOB /midpoint endpoints so that the scraper can run offline. Point
+// GAMMA_API_URL and CLOB_API_URL at it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket/stub"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	fixturePath := flag.String("fixture", "", "markets fixture file (default: the built-in markets)")
+	scenarioPath := flag.String("scenario", "", "scenario file to replay (see internal/polymarket/stub/fixtures/scenario.example.json)")
+	seed := flag.Uint64("seed", 1, "random seed for drift steps")
+	flag.Parse()
+
+	logging.Setup("polymarket-stub", "development", "info")
+
+	fixture := stub.DefaultFixture()
+	if *fixturePath != "" {
+		var err error
+		if fixture, err = stub.LoadFixture(*fixturePath); err != nil {
+			log.Fatal().Err(err).Msg("failed to load fixture")
+		}
+	}
+	var scenario *stub.Scenario
+	if *scenarioPath != "" {
+		var err error
+		if scenario, err = stub.LoadScenario(*scenarioPath); err != nil {
+			log.Fatal().Err(err).Msg("failed to load scenario")
+		}
+	}
+
+	server, err := stub.New(fixture, scenario, *seed)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid scenario")
+	}
+
+	srv := &http.Server{
+		Addr: *addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			server.ServeHTTP(w, r)
+			log.Info().
+				Str("path", r.URL.Path).
+				Str("query", r.URL.RawQuery).
+				Int("round", server.Round()).
+				Dur("duration", time.Since(start)).
+				Msg("request")
+		}),
+	}
+
+	go func() {
+		log.Info().
+			Str("addr", *addr).
+			Int("markets", len(fixture.Markets)).
+			Bool("scenario", scenario != nil).
+			Msg("serving Polymarket stub")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("server failed")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("shutdown failed")
+	}
+}
@@ -0,0 +1,121 @@
+package polymarket
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit
+// breaker for the upstream host is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Breakers holds one circuit breaker per upstream host. Clients of the same
+// host share its breaker, so the Gamma and CLOB clients pointed at a single
+// stub trip together.
+type Breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewBreakers creates a set of breakers that open after threshold
+// consecutive failed requests to a host and let a probe through after
+// cooldown. A threshold below 1 disables circuit breaking.
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{threshold: threshold, cooldown: cooldown, breakers: make(map[string]*breaker)}
+}
+
+// forHost returns the breaker for host, or nil, which always allows
+// requests, if circuit breaking is disabled.
+func (b *Breakers) forHost(host string) *breaker {
+	if b == nil || b.threshold < 1 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.breakers[host]
+	if !ok {
+		br = &breaker{host: host, threshold: b.threshold, cooldown: b.cooldown, now: time.Now}
+		b.breakers[host] = br
+	}
+	return br
+}
+
+// breaker is a consecutive-failure circuit breaker. It opens after threshold
+// failures in a row, then after cooldown lets one probe request through
+// (half-open); the probe's outcome closes or reopens it.
+type breaker struct {
+	host      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if
+// not. A nil breaker allows everything.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// abandon notes that a request allow let through ended without an outcome,
+// such as when its context was cancelled.
+func (b *breaker) abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record notes the outcome of a request that allow let through.
+func (b *breaker) record(ok bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		if b.open {
+			circuitOpen.WithLabelValues(b.host).Set(0)
+		}
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if b.open || b.failures >= b.threshold {
+		if !b.open {
+			circuitOpen.WithLabelValues(b.host).Set(1)
+		}
+		b.open = true
+		b.openedAt = b.now()
+	}
+}
@@ -1,45 +1,79 @@
 package polymarket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-const (
-	clobBaseURL    = "https://clob.polymarket.com"
-	clobReqTimeout = 30 * time.Second
-)
-
 // midpointResponse represents the JSON response from the CLOB midpoint endpoint.
 type midpointResponse struct {
 	Mid string `json:"mid"`
 }
 
+// midpointsRequest is one entry of the body of the CLOB batch midpoints
+// endpoint, which responds with a map from token ID to midpoint.
+type midpointsRequest struct {
+	TokenID string `json:"token_id"`
+}
+
+// errBatchUnsupported is returned when the CLOB API has no batch midpoints
+// endpoint, as with older deployments and some proxies.
+var errBatchUnsupported = errors.New("batch midpoints endpoint not available")
+
+// CLOBLimits bounds how hard GetMidpoints drives the CLOB API.
+type CLOBLimits struct {
+	// Concurrency is how many requests may be in flight at once. Values
+	// below 1 mean 1.
+	Concurrency int
+	// RatePerSecond caps the requests a second across all workers, with
+	// bursts of up to Concurrency. Zero means no limit.
+	RatePerSecond float64
+	// BatchSize is the number of tokens asked for per batch request. Zero
+	// fetches every token on its own.
+	BatchSize int
+}
+
 // CLOBClient is an HTTP client for the Polymarket CLOB API.
 type CLOBClient struct {
-	client *http.Client
+	http    *transport
+	baseURL string
+	limits  CLOBLimits
+	limiter *tokenBucket
+
+	// noBatch is set once the batch endpoint turns out to be unavailable, so
+	// later calls go straight to per-token requests.
+	noBatch atomic.Bool
 }
 
-// NewCLOBClient creates a new CLOBClient.
-func NewCLOBClient() *CLOBClient {
+// NewCLOBClient creates a new CLOBClient for the API at baseURL, giving up
+// on each attempt after timeout, retrying as res says and staying within
+// limits.
+func NewCLOBClient(baseURL string, timeout time.Duration, res Resilience, limits CLOBLimits) *CLOBClient {
+	limits.Concurrency = max(limits.Concurrency, 1)
 	return &CLOBClient{
-		client: &http.Client{
-			Timeout: clobReqTimeout,
-		},
+		http:    newTransport(timeout, res),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		limits:  limits,
+		limiter: newTokenBucket(limits.RatePerSecond, limits.Concurrency),
 	}
 }
 
 // GetMidpoint fetches the midpoint price for a single CLOB token.
 func (c *CLOBClient) GetMidpoint(ctx context.Context, tokenID string) (float64, error) {
-	endpoint := fmt.Sprintf("%s/midpoint?token_id=%s", clobBaseURL, url.QueryEscape(tokenID))
+	endpoint := fmt.Sprintf("%s/midpoint?token_id=%s", c.baseURL, url.QueryEscape(tokenID))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -48,9 +82,9 @@ func (c *CLOBClient) GetMidpoint(ctx context.Context, tokenID string) (float64,
 
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req, "midpoint")
 	if err != nil {
-		return 0, fmt.Errorf("executing request: %w", err)
+		return 0, err
 	}
 	defer resp.Body.Close()
 
@@ -72,28 +106,182 @@ func (c *CLOBClient) GetMidpoint(ctx context.Context, tokenID string) (float64,
 	return mid, nil
 }
 
+// getBatchMidpoints fetches the midpoints of tokenIDs in one request. Tokens
+// without an order book are left out of the result.
+func (c *CLOBClient) getBatchMidpoints(ctx context.Context, tokenIDs []string) (map[string]float64, error) {
+	entries := make([]midpointsRequest, len(tokenIDs))
+	for i, id := range tokenIDs {
+		entries[i] = midpointsRequest{TokenID: id}
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/midpoints", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req, "midpoints")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, errBatchUnsupported
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	midpoints := make(map[string]float64, len(result))
+	for id, raw := range result {
+		mid, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			log.Warn().
+				Str("token_id", id).
+				Str("mid", raw).
+				Msg("unparsable midpoint in batch response")
+			continue
+		}
+		midpoints[id] = mid
+	}
+
+	return midpoints, nil
+}
+
+// do sends req, waiting for the rate limiter before every attempt.
+func (c *CLOBClient) do(req *http.Request, endpoint string) (*http.Response, error) {
+	return c.http.do(req, func(ctx context.Context) error {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("waiting for rate limit: %w", err)
+		}
+		clobRequests.WithLabelValues(endpoint).Inc()
+		return nil
+	})
+}
+
 // GetMidpoints fetches midpoint prices for multiple CLOB tokens. It returns
-// a map from token ID to its midpoint price. If fetching an individual token
-// fails, that token is skipped and the error is logged.
+// a map from token ID to its midpoint price. Tokens are fetched in batches
+// where the API supports it, and one at a time otherwise or when a batch
+// fails or leaves a token out, using up to the configured number of
+// concurrent requests. If fetching an individual token fails, that token is
+// skipped and the error is logged. If ctx is done the midpoints fetched so far
+// are returned with its error.
 func (c *CLOBClient) GetMidpoints(ctx context.Context, tokenIDs []string) (map[string]float64, error) {
-	midpoints := make(map[string]float64, len(tokenIDs))
-
+	seen := make(map[string]struct{}, len(tokenIDs))
+	pending := make([]string, 0, len(tokenIDs))
 	for _, tokenID := range tokenIDs {
-		if tokenID == "" {
+		if _, dup := seen[tokenID]; tokenID == "" || dup {
 			continue
 		}
+		seen[tokenID] = struct{}{}
+		pending = append(pending, tokenID)
+	}
+
+	var mu sync.Mutex
+	midpoints := make(map[string]float64, len(pending))
+
+	if c.limits.BatchSize > 0 && !c.noBatch.Load() {
+		var batches [][]string
+		for start := 0; start < len(pending); start += c.limits.BatchSize {
+			batches = append(batches, pending[start:min(start+c.limits.BatchSize, len(pending))])
+		}
+
+		forEach(ctx, c.limits.Concurrency, batches, func(batch []string) {
+			if c.noBatch.Load() {
+				return
+			}
+			mids, err := c.getBatchMidpoints(ctx, batch)
+			if errors.Is(err, errBatchUnsupported) {
+				c.noBatch.Store(true)
+				log.Warn().Msg("CLOB batch midpoints endpoint not available, fetching tokens one at a time")
+				return
+			}
+			if err != nil {
+				clobErrors.Inc()
+				log.Warn().
+					Err(err).
+					Int("tokens", len(batch)).
+					Msg("failed to fetch midpoint batch, fetching its tokens one at a time")
+				return
+			}
+
+			mu.Lock()
+			for id, mid := range mids {
+				midpoints[id] = mid
+			}
+			mu.Unlock()
+		})
+
+		// Whatever the batches did not cover is fetched token by token.
+		missing := pending[:0:0]
+		for _, tokenID := range pending {
+			if _, ok := midpoints[tokenID]; !ok {
+				missing = append(missing, tokenID)
+			}
+		}
+		pending = missing
+	}
 
+	forEach(ctx, c.limits.Concurrency, pending, func(tokenID string) {
 		mid, err := c.GetMidpoint(ctx, tokenID)
 		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			clobErrors.Inc()
 			log.Warn().
 				Err(err).
 				Str("token_id", tokenID).
 				Msg("failed to fetch midpoint, skipping token")
-			continue
+			return
 		}
 
+		mu.Lock()
 		midpoints[tokenID] = mid
+		mu.Unlock()
+	})
+
+	return midpoints, ctx.Err()
+}
+
+// forEach calls fn for each item on up to workers goroutines and returns
+// once they are done. Items not yet started when ctx is done are
+// skipped.
+func forEach[T any](ctx context.Context, workers int, items []T, fn func(T)) {
+	work := make(chan T)
+	var wg sync.WaitGroup
+	for range min(workers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fn(item)
+			}
+		}()
 	}
 
-	return midpoints, nil
+feed:
+	for _, item := range items {
+		select {
+		case <-ctx.Done():
+			break feed
+		case work <- item:
+		}
+	}
+	close(work)
+	wg.Wait()
 }
@@ -0,0 +1,226 @@
+package polymarket_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket"
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket/stub"
+)
+
+// countingServer serves the stub, counting requests by path. Requests to
+// paths in unavailable get a 404, as from an API without that endpoint.
+type countingServer struct {
+	stub        *stub.Server
+	unavailable map[string]bool
+	delay       time.Duration
+
+	mu          sync.Mutex
+	requests    map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *countingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if s.unavailable[r.URL.Path] {
+		http.NotFound(w, r)
+		return
+	}
+	s.stub.ServeHTTP(w, r)
+}
+
+func (s *countingServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// startCounting serves the stub's default fixture and returns the server and
+// the CLOB token IDs of its open markets.
+func startCounting(t *testing.T, sc *stub.Scenario) (*countingServer, string, []string) {
+	t.Helper()
+	s, err := stub.New(stub.DefaultFixture(), sc, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := &countingServer{stub: s, unavailable: map[string]bool{}, requests: map[string]int{}}
+	ts := httptest.NewServer(cs)
+	t.Cleanup(ts.Close)
+
+	markets, err := polymarket.NewGammaClient(ts.URL, 5*time.Second, polymarket.Resilience{}).FetchMarkets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	for _, m := range markets {
+		tokens = append(tokens, m.ClobTokenIDs...)
+	}
+	if len(tokens) < 10 {
+		t.Fatalf("fixture has %d tokens, want at least 10", len(tokens))
+	}
+	return cs, ts.URL, tokens
+}
+
+func TestGetMidpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      polymarket.CLOBLimits
+		unavailable string
+		fault       *stub.Fault
+		wantBatch   func(tokens int) int
+		wantSingle  func(tokens int) int
+	}{
+		{
+			name:       "batches",
+			limits:     polymarket.CLOBLimits{Concurrency: 2, BatchSize: 4},
+			wantBatch:  func(n int) int { return (n + 1 + 3) / 4 },
+			wantSingle: func(int) int { return 1 }, // the unknown token
+		},
+		{
+			name:       "one token at a time",
+			limits:     polymarket.CLOBLimits{Concurrency: 4},
+			wantBatch:  func(int) int { return 0 },
+			wantSingle: func(n int) int { return n + 1 },
+		},
+		{
+			name:        "batch endpoint unavailable",
+			limits:      polymarket.CLOBLimits{Concurrency: 1, BatchSize: 4},
+			unavailable: "/midpoints",
+			wantBatch:   func(int) int { return 1 },
+			wantSingle:  func(n int) int { return n + 1 },
+		},
+		{
+			name:       "failed batch",
+			limits:     polymarket.CLOBLimits{Concurrency: 1, BatchSize: 100},
+			fault:      &stub.Fault{Path: "/midpoints", Status: http.StatusTooManyRequests},
+			wantBatch:  func(int) int { return 1 },
+			wantSingle: func(n int) int { return n + 1 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sc *stub.Scenario
+			if tt.fault != nil {
+				sc = &stub.Scenario{Steps: []stub.Step{{Round: 1, Action: stub.ActionFault, Fault: tt.fault}}}
+			}
+			srv, url, tokens := startCounting(t, sc)
+			if tt.unavailable != "" {
+				srv.unavailable[tt.unavailable] = true
+			}
+			clob := polymarket.NewCLOBClient(url, 5*time.Second, polymarket.Resilience{}, tt.limits)
+
+			// Duplicates and empty IDs are dropped; unknown tokens are skipped.
+			ids := append(append([]string{"", "unknown"}, tokens...), tokens[0])
+			mids, err := clob.GetMidpoints(context.Background(), ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(mids) != len(tokens) {
+				t.Errorf("%d midpoints, want %d", len(mids), len(tokens))
+			}
+			for _, id := range tokens {
+				if _, ok := mids[id]; !ok {
+					t.Errorf("no midpoint for %s", id)
+				}
+			}
+			if got, want := srv.count("/midpoints"), tt.wantBatch(len(tokens)); got != want {
+				t.Errorf("%d batch requests, want %d", got, want)
+			}
+			if got, want := srv.count("/midpoint"), tt.wantSingle(len(tokens)); got != want {
+				t.Errorf("%d single requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestGetMidpointsRemembersMissingBatchEndpoint(t *testing.T) {
+	srv, url, tokens := startCounting(t, nil)
+	srv.unavailable["/midpoints"] = true
+	clob := polymarket.NewCLOBClient(url, 5*time.Second, polymarket.Resilience{}, polymarket.CLOBLimits{Concurrency: 4, BatchSize: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := clob.GetMidpoints(context.Background(), tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.count("/midpoints"); n > 4 {
+		t.Errorf("%d batch requests, want the endpoint given up on in the first call", n)
+	}
+	if n := srv.count("/midpoint"); n != 2*len(tokens) {
+		t.Errorf("%d single requests, want %d", n, 2*len(tokens))
+	}
+}
+
+func TestGetMidpointsBoundsConcurrency(t *testing.T) {
+	srv, url, tokens := startCounting(t, nil)
+	srv.delay = 20 * time.Millisecond
+	clob := polymarket.NewCLOBClient(url, 5*time.Second, polymarket.Resilience{}, polymarket.CLOBLimits{Concurrency: 3})
+
+	if _, err := clob.GetMidpoints(context.Background(), tokens); err != nil {
+		t.Fatal(err)
+	}
+	if srv.maxInFlight != 3 {
+		t.Errorf("%d requests in flight at most, want 3", srv.maxInFlight)
+	}
+}
+
+func TestGetMidpointsRateLimit(t *testing.T) {
+	_, url, tokens := startCounting(t, nil)
+	clob := polymarket.NewCLOBClient(url, 5*time.Second, polymarket.Resilience{}, polymarket.CLOBLimits{Concurrency: 2, RatePerSecond: 50})
+
+	// A burst of 2, then 50 a second for the rest.
+	start := time.Now()
+	if _, err := clob.GetMidpoints(context.Background(), tokens); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed, want := time.Since(start), time.Duration(len(tokens)-2)*20*time.Millisecond; elapsed < want*9/10 {
+		t.Errorf("%d requests took %v, want at least %v", len(tokens), elapsed, want)
+	}
+}
+
+func TestGetMidpointsCancel(t *testing.T) {
+	srv, url, tokens := startCounting(t, nil)
+	srv.delay = time.Minute
+	clob := polymarket.NewCLOBClient(url, 5*time.Minute, polymarket.Resilience{}, polymarket.CLOBLimits{Concurrency: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	mids, err := clob.GetMidpoints(ctx, tokens)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v, want the context's", err)
+	}
+	if len(mids) != 0 {
+		t.Errorf("%d midpoints from a cancelled fetch", len(mids))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled fetch took %v", elapsed)
+	}
+	if n := srv.count("/midpoint"); n != 2 {
+		t.Errorf("%d requests started, want only the 2 in flight when cancelled", n)
+	}
+}
@@ -6,16 +6,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	gammaBaseURL     = "https://gamma-api.polymarket.com"
 	gammaPageLimit   = 100
 	gammaRateDelay   = 200 * time.Millisecond
-	gammaReqTimeout  = 30 * time.Second
+	gammaLookupBatch = 50
 )
 
 // stringSlice is a []string that can unmarshal from a JSON-encoded string
@@ -76,39 +78,119 @@ type GammaMarket struct {
 	ConditionID    string      `json:"conditionId"`
 	EventID        string      `json:"eventId"`
 	Active         bool        `json:"active"`
+	Events         []GammaEvent `json:"events"`
+	// UMAResolutionStatus is where the market is in resolution; UMAResolved
+	// once its outcome prices are final.
+	UMAResolutionStatus string `json:"umaResolutionStatus"`
+}
+
+// UMAResolved is the UMAResolutionStatus of a market whose resolution is
+// final.
+const UMAResolved = "resolved"
+
+// GammaEvent is the parent event embedded in a Gamma market response. One
+// event groups many related markets.
+type GammaEvent struct {
+	ID          string     `json:"id"`
+	Slug        string     `json:"slug"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Image       string     `json:"image"`
+	Tags        []GammaTag `json:"tags"`
+}
+
+// GammaTag is a topic label attached to a Gamma event.
+type GammaTag struct {
+	ID    flexString `json:"id"`
+	Label string     `json:"label"`
+	Slug  string     `json:"slug"`
+}
+
+// ParentEvent returns the market's parent event, or nil if the response did
+// not embed one. A bare eventId is promoted to an event with only an ID.
+func (m GammaMarket) ParentEvent() *GammaEvent {
+	if len(m.Events) > 0 && m.Events[0].ID != "" {
+		return &m.Events[0]
+	}
+	if m.EventID != "" {
+		return &GammaEvent{ID: m.EventID}
+	}
+	return nil
 }
 
 // GammaClient is an HTTP client for the Polymarket Gamma API.
 type GammaClient struct {
-	client *http.Client
+	http        *transport
+	baseURL     string
+	resumes     int
+	resumeAfter time.Duration
+	pageLimit   int
 }
 
-// NewGammaClient creates a new GammaClient.
-func NewGammaClient() *GammaClient {
+// NewGammaClient creates a new GammaClient for the API at baseURL, giving up
+// on each attempt after timeout and retrying as res says.
+func NewGammaClient(baseURL string, timeout time.Duration, res Resilience) *GammaClient {
 	return &GammaClient{
-		client: &http.Client{
-			Timeout: gammaReqTimeout,
-		},
+		http:        newTransport(timeout, res),
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		resumes:     res.Retry.PageResumes,
+		resumeAfter: res.Retry.MaxDelay,
+		pageLimit:   gammaPageLimit,
 	}
 }
 
+// PageError is returned by FetchMarkets when a page could not be fetched.
+// The markets returned with it are those of the pages before Offset.
+type PageError struct {
+	Offset int
+	Err    error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("fetching page at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *PageError) Unwrap() error { return e.Err }
+
 // FetchMarkets fetches all active markets from the Gamma API, paginating until
-// the response is empty.
+// the response is empty. A page that still fails after the client's retries
+// is resumed from, keeping the pages before it, up to the policy's
+// PageResumes times. If it keeps failing the markets fetched so far are
+// returned with a *PageError.
 func (g *GammaClient) FetchMarkets(ctx context.Context) ([]GammaMarket, error) {
 	var allMarkets []GammaMarket
 	offset := 0
+	resumes := 0
 
 	for {
-		url := fmt.Sprintf("%s/markets?closed=false&limit=%d&offset=%d", gammaBaseURL, gammaPageLimit, offset)
+		pageURL := fmt.Sprintf("%s/markets?closed=false&limit=%d&offset=%d", g.baseURL, g.pageLimit, offset)
 
 		log.Debug().
 			Int("offset", offset).
-			Int("limit", gammaPageLimit).
+			Int("limit", g.pageLimit).
 			Msg("fetching markets page from Gamma API")
 
-		markets, err := g.fetchPage(ctx, url)
+		markets, err := g.fetchPage(ctx, pageURL)
 		if err != nil {
-			return allMarkets, fmt.Errorf("fetching page at offset %d: %w", offset, err)
+			// Resuming only pays off once there are pages to keep; a
+			// failed first page is left to the next sync.
+			if offset == 0 || resumes >= g.resumes || ctx.Err() != nil {
+				return allMarkets, &PageError{Offset: offset, Err: err}
+			}
+			resumes++
+			log.Warn().
+				Err(err).
+				Int("offset", offset).
+				Int("resume", resumes).
+				Dur("after", g.resumeAfter).
+				Msg("markets page failed, resuming from it")
+
+			select {
+			case <-ctx.Done():
+				return allMarkets, &PageError{Offset: offset, Err: ctx.Err()}
+			case <-time.After(g.resumeAfter):
+			}
+			continue
 		}
 
 		if len(markets) == 0 {
@@ -116,7 +198,7 @@ func (g *GammaClient) FetchMarkets(ctx context.Context) ([]GammaMarket, error) {
 		}
 
 		allMarkets = append(allMarkets, markets...)
-		offset += gammaPageLimit
+		offset += g.pageLimit
 
 		log.Debug().
 			Int("page_count", len(markets)).
@@ -126,7 +208,7 @@ func (g *GammaClient) FetchMarkets(ctx context.Context) ([]GammaMarket, error) {
 		// Simple rate limiting between pages.
 		select {
 		case <-ctx.Done():
-			return allMarkets, ctx.Err()
+			return allMarkets, &PageError{Offset: offset, Err: ctx.Err()}
 		case <-time.After(gammaRateDelay):
 		}
 	}
@@ -138,6 +220,31 @@ func (g *GammaClient) FetchMarkets(ctx context.Context) ([]GammaMarket, error) {
 	return allMarkets, nil
 }
 
+// FetchMarketsByConditionIDs looks up markets by condition ID, whether open
+// or closed, asking for up to gammaLookupBatch of them a request. Markets
+// Gamma does not know are left out of the result. If a request fails, the
+// markets of the batches fetched before it are returned with the error.
+func (g *GammaClient) FetchMarketsByConditionIDs(ctx context.Context, conditionIDs []string) ([]GammaMarket, error) {
+	var markets []GammaMarket
+	for start := 0; start < len(conditionIDs); start += gammaLookupBatch {
+		batch := conditionIDs[start:min(start+gammaLookupBatch, len(conditionIDs))]
+
+		q := url.Values{}
+		q.Set("limit", strconv.Itoa(len(batch)))
+		for _, id := range batch {
+			q.Add("condition_ids", id)
+		}
+
+		page, err := g.fetchPage(ctx, g.baseURL+"/markets?"+q.Encode())
+		if err != nil {
+			return markets, fmt.Errorf("looking up markets: %w", err)
+		}
+		markets = append(markets, page...)
+	}
+
+	return markets, nil
+}
+
 // fetchPage fetches a single page of markets from the given URL.
 func (g *GammaClient) fetchPage(ctx context.Context, url string) ([]GammaMarket, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -147,9 +254,9 @@ func (g *GammaClient) fetchPage(ctx context.Context, url string) ([]GammaMarket,
 
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := g.client.Do(req)
+	resp, err := g.http.do(req, nil)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -0,0 +1,32 @@
+package polymarket
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/poly-predict/backend/pkg/metrics"
+)
+
+var clobErrors = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "scraper_clob_errors_total",
+	Help:      "Failed CLOB midpoint requests, batch or single.",
+})
+
+var clobRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "scraper_clob_requests_total",
+	Help:      "CLOB API requests sent, by endpoint.",
+}, []string{"endpoint"})
+
+var polymarketRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "scraper_polymarket_retries_total",
+	Help:      "Polymarket API requests retried after a 5xx, 429 or network error, by host.",
+}, []string{"host"})
+
+var circuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metrics.Namespace,
+	Name:      "scraper_polymarket_circuit_open",
+	Help:      "Whether the circuit breaker for a Polymarket API host is open (1) or closed (0).",
+}, []string{"host"})
@@ -0,0 +1,600 @@
+// Package stub is an offline stand-in for the Polymarket Gamma and CLOB APIs.
+// It serves /markets, /midpoint and /midpoints from fixture files in the same
+// loosely typed shapes the real APIs use, and replays a scenario that moves
+// prices, resolves markets and injects faults as sync rounds go by. A round
+// starts with every request for the first page of /markets, other than
+// lookups by condition_ids.
+package stub
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures/markets.json
+var defaultMarkets []byte
+
+const defaultPageLimit = 100
+
+// Fixture is the initial market list. Markets are kept as raw JSON objects so
+// that fixtures can carry whatever mix of string-encoded arrays, string and
+// numeric amounts, and missing fields the real Gamma API returns.
+type Fixture struct {
+	Markets []map[string]any `json:"markets"`
+}
+
+// Scenario scripts what changes from one sync round to the next.
+type Scenario struct {
+	Steps []Step `json:"steps"`
+}
+
+// Step is one scripted change. It applies when round Round starts, or when
+// the server is created if Round is 0, and again on every later round if
+// Repeat is set.
+type Step struct {
+	Round  int    `json:"round"`
+	Repeat bool   `json:"repeat,omitempty"`
+	Action string `json:"action"`
+
+	// Market is the conditionId of the market the step applies to. Drift
+	// applies to every open market when it is empty.
+	Market string `json:"market,omitempty"`
+
+	// Prices are the new outcome prices for a price step, or the final
+	// prices of a split step.
+	Prices []string `json:"prices,omitempty"`
+	// Outcome is the winning outcome label for a resolve step.
+	Outcome string `json:"outcome,omitempty"`
+	// Drift is the largest move of a drift step's random walk.
+	Drift float64 `json:"drift,omitempty"`
+	// Data is the market an add step adds.
+	Data map[string]any `json:"data,omitempty"`
+	// Fault is the fault a fault step injects.
+	Fault *Fault `json:"fault,omitempty"`
+}
+
+// Step actions.
+const (
+	ActionPrice   = "price"   // set a market's outcome prices
+	ActionDrift   = "drift"   // random walk binary market prices
+	ActionResolve = "resolve" // settle prices at 1 for Outcome and 0 for the rest
+	ActionVoid    = "void"    // close a market with every price at 0, cancelling it
+	ActionSplit   = "split"   // close a market settled at Prices, as in a 50/50
+	ActionClose   = "close"   // mark a market closed, dropping it from closed=false listings
+	ActionRemove  = "remove"  // drop a market from every listing
+	ActionAdd     = "add"     // list a new market
+	ActionFault   = "fault"   // fail upcoming requests
+)
+
+// Fault makes the next Count requests to Path (1 if Count is 0) fail.
+// Status sends that status code, with a Retry-After header for 429.
+// Malformed sends a 200 with a truncated body. Delay holds the response
+// back first, and combines with either of the others.
+type Fault struct {
+	Path      string   `json:"path"`
+	Count     int      `json:"count,omitempty"`
+	Status    int      `json:"status,omitempty"`
+	Malformed bool     `json:"malformed,omitempty"`
+	Delay     Duration `json:"delay,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms" in JSON.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Server serves the stub APIs. It is safe for concurrent use.
+type Server struct {
+	mu      sync.Mutex
+	markets []map[string]any
+	steps   []Step
+	round   int
+	faults  []*pendingFault
+	rng     *rand.Rand
+	mux     *http.ServeMux
+}
+
+type pendingFault struct {
+	Fault
+	left int
+}
+
+// New creates a Server serving the markets in f and replaying sc, which may
+// be nil. seed makes drift steps reproducible.
+func New(f *Fixture, sc *Scenario, seed uint64) (*Server, error) {
+	s := &Server{
+		markets: make([]map[string]any, 0, len(f.Markets)),
+		rng:     rand.New(rand.NewPCG(seed, seed)),
+	}
+	for _, m := range f.Markets {
+		s.markets = append(s.markets, clone(m))
+	}
+	if sc != nil {
+		for i, step := range sc.Steps {
+			if err := validate(step); err != nil {
+				return nil, fmt.Errorf("scenario step %d: %w", i+1, err)
+			}
+		}
+		s.steps = sc.Steps
+	}
+	if err := s.applySteps(0); err != nil {
+		return nil, err
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /markets", s.handleMarkets)
+	s.mux.HandleFunc("GET /midpoint", s.handleMidpoint)
+	s.mux.HandleFunc("POST /midpoints", s.handleMidpoints)
+	return s, nil
+}
+
+// Round returns how many sync rounds have started.
+func (s *Server) Round() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.round
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, err := intParam(q.Get("offset"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+	limit, err := intParam(q.Get("limit"), defaultPageLimit)
+	if err != nil || limit <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	// Lookups by condition ID happen within a sync, so they do not start a
+	// round.
+	lookup := make(map[string]bool, len(q["condition_ids"]))
+	for _, id := range q["condition_ids"] {
+		lookup[id] = true
+	}
+
+	s.mu.Lock()
+	if offset == 0 && len(lookup) == 0 {
+		s.round++
+		if err := s.applySteps(s.round); err != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	fault := s.takeFault(r.URL.Path)
+
+	var listed []map[string]any
+	for _, m := range s.markets {
+		if closed := q.Get("closed"); closed != "" && strconv.FormatBool(boolField(m, "closed")) != closed {
+			continue
+		}
+		if id, _ := m["conditionId"].(string); len(lookup) > 0 && !lookup[id] {
+			continue
+		}
+		listed = append(listed, m)
+	}
+	page := []map[string]any{}
+	if offset < len(listed) {
+		page = listed[offset:min(offset+limit, len(listed))]
+	}
+	body, err := json.Marshal(page)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if s.fail(w, r, fault, body) {
+		return
+	}
+	writeJSON(w, body)
+}
+
+func (s *Server) handleMidpoint(w http.ResponseWriter, r *http.Request) {
+	tokenID := r.URL.Query().Get("token_id")
+	if tokenID == "" {
+		writeError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	s.mu.Lock()
+	fault := s.takeFault(r.URL.Path)
+	mid, ok := s.midpoint(tokenID)
+	s.mu.Unlock()
+
+	body, _ := json.Marshal(map[string]string{"mid": mid})
+	if s.fail(w, r, fault, body) {
+		return
+	}
+	if !ok {
+		// The CLOB has no order book for unknown or closed markets.
+		writeError(w, http.StatusNotFound, "No orderbook exists for the requested token id")
+		return
+	}
+	writeJSON(w, body)
+}
+
+// handleMidpoints serves the batch endpoint, which takes a list of token IDs
+// and leaves tokens without an order book out of the response.
+func (s *Server) handleMidpoints(w http.ResponseWriter, r *http.Request) {
+	var req []struct {
+		TokenID string `json:"token_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req) == 0 {
+		writeError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	s.mu.Lock()
+	fault := s.takeFault(r.URL.Path)
+	mids := make(map[string]string, len(req))
+	for _, entry := range req {
+		if mid, ok := s.midpoint(entry.TokenID); ok {
+			mids[entry.TokenID] = mid
+		}
+	}
+	s.mu.Unlock()
+
+	body, _ := json.Marshal(mids)
+	if s.fail(w, r, fault, body) {
+		return
+	}
+	writeJSON(w, body)
+}
+
+// midpoint returns the price of the outcome tokenID trades, which the stub
+// takes to be the market's listed outcome price.
+func (s *Server) midpoint(tokenID string) (string, bool) {
+	for _, m := range s.markets {
+		if boolField(m, "closed") {
+			continue
+		}
+		prices := stringList(m, "outcomePrices")
+		for i, id := range stringList(m, "clobTokenIds") {
+			if id == tokenID && i < len(prices) {
+				return prices[i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// takeFault consumes one use of the first pending fault for path.
+func (s *Server) takeFault(path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != path {
+			continue
+		}
+		f.left--
+		if f.left == 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return &f.Fault
+	}
+	return nil
+}
+
+// fail delays and fails the response as f says, and reports whether the
+// response has been written.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, f *Fault, body []byte) bool {
+	if f == nil {
+		return false
+	}
+	if f.Delay > 0 {
+		select {
+		case <-time.After(time.Duration(f.Delay)):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	switch {
+	case f.Status != 0:
+		if f.Status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, f.Status, http.StatusText(f.Status))
+		return true
+	case f.Malformed:
+		writeJSON(w, body[:len(body)/2])
+		return true
+	}
+	return false
+}
+
+// applySteps applies the steps due when round starts.
+func (s *Server) applySteps(round int) error {
+	for i, step := range s.steps {
+		if step.Round != round && !(step.Repeat && step.Round < round) {
+			continue
+		}
+		if err := s.apply(step); err != nil {
+			return fmt.Errorf("round %d: scenario step %d: %w", round, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) apply(step Step) error {
+	switch step.Action {
+	case ActionAdd:
+		s.markets = append(s.markets, clone(step.Data))
+		return nil
+	case ActionFault:
+		f := &pendingFault{Fault: *step.Fault, left: max(step.Fault.Count, 1)}
+		s.faults = append(s.faults, f)
+		return nil
+	case ActionDrift:
+		if step.Market == "" {
+			for _, m := range s.markets {
+				s.drift(m, step.Drift)
+			}
+			return nil
+		}
+	}
+
+	i := s.find(step.Market)
+	if i < 0 {
+		return fmt.Errorf("unknown market %q", step.Market)
+	}
+	m := s.markets[i]
+	switch step.Action {
+	case ActionPrice:
+		setStringList(m, "outcomePrices", step.Prices)
+	case ActionDrift:
+		s.drift(m, step.Drift)
+	case ActionResolve:
+		outcomes := stringList(m, "outcomes")
+		prices := make([]string, len(outcomes))
+		won := false
+		for j, o := range outcomes {
+			prices[j] = "0"
+			if strings.EqualFold(o, step.Outcome) {
+				prices[j], won = "1", true
+			}
+		}
+		if !won {
+			return fmt.Errorf("market %q has no outcome %q", step.Market, step.Outcome)
+		}
+		setStringList(m, "outcomePrices", prices)
+	case ActionVoid:
+		prices := make([]string, len(stringList(m, "outcomes")))
+		for j := range prices {
+			prices[j] = "0"
+		}
+		setStringList(m, "outcomePrices", prices)
+		m["closed"] = true
+		m["umaResolutionStatus"] = "resolved"
+	case ActionSplit:
+		setStringList(m, "outcomePrices", step.Prices)
+		m["closed"] = true
+		m["umaResolutionStatus"] = "resolved"
+	case ActionClose:
+		m["closed"] = true
+	case ActionRemove:
+		s.markets = append(s.markets[:i], s.markets[i+1:]...)
+	}
+	return nil
+}
+
+// drift moves the first price of an open binary market by up to size in
+// either direction, keeping it within [0.01, 0.99] and the prices summing
+// to one.
+func (s *Server) drift(m map[string]any, size float64) {
+	prices := stringList(m, "outcomePrices")
+	if boolField(m, "closed") || len(prices) != 2 {
+		return
+	}
+	p, err := strconv.ParseFloat(prices[0], 64)
+	if err != nil || p <= 0 || p >= 1 {
+		return
+	}
+	p += (s.rng.Float64()*2 - 1) * size
+	p = min(max(p, 0.01), 0.99)
+	setStringList(m, "outcomePrices", []string{formatPrice(p), formatPrice(1 - p)})
+}
+
+func (s *Server) find(conditionID string) int {
+	for i, m := range s.markets {
+		if id, _ := m["conditionId"].(string); id == conditionID {
+			return i
+		}
+	}
+	return -1
+}
+
+func validate(step Step) error {
+	switch step.Action {
+	case ActionPrice, ActionSplit:
+		if len(step.Prices) == 0 {
+			return fmt.Errorf("%s needs prices", step.Action)
+		}
+	case ActionResolve:
+		if step.Outcome == "" {
+			return fmt.Errorf("resolve needs an outcome")
+		}
+	case ActionDrift:
+		if step.Drift <= 0 || step.Drift >= 1 {
+			return fmt.Errorf("drift must be between 0 and 1")
+		}
+		return nil
+	case ActionVoid, ActionClose, ActionRemove:
+	case ActionAdd:
+		if id, _ := step.Data["conditionId"].(string); id == "" {
+			return fmt.Errorf("add needs data with a conditionId")
+		}
+		return nil
+	case ActionFault:
+		if step.Fault == nil || step.Fault.Path == "" {
+			return fmt.Errorf("fault needs a path")
+		}
+		if step.Fault.Status == 0 && !step.Fault.Malformed && step.Fault.Delay == 0 {
+			return fmt.Errorf("fault needs a status, malformed or delay")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", step.Action)
+	}
+	if step.Market == "" {
+		return fmt.Errorf("%s needs a market", step.Action)
+	}
+	return nil
+}
+
+// stringList reads a field that Gamma encodes either as a JSON array or as a
+// string holding one.
+func stringList(m map[string]any, key string) []string {
+	switch v := m[key].(type) {
+	case string:
+		var list []string
+		if err := json.Unmarshal([]byte(v), &list); err != nil {
+			return nil
+		}
+		return list
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			s, _ := e.(string)
+			list = append(list, s)
+		}
+		return list
+	}
+	return nil
+}
+
+// setStringList writes list in the string-encoded form Gamma uses, unless
+// the fixture already used a plain array for the field.
+func setStringList(m map[string]any, key string, list []string) {
+	if _, ok := m[key].([]any); ok {
+		arr := make([]any, len(list))
+		for i, s := range list {
+			arr[i] = s
+		}
+		m[key] = arr
+		return
+	}
+	encoded, _ := json.Marshal(list)
+	m[key] = string(encoded)
+}
+
+func boolField(m map[string]any, key string) bool {
+	b, _ := m[key].(bool)
+	return b
+}
+
+func formatPrice(p float64) string {
+	return strconv.FormatFloat(p, 'f', 3, 64)
+}
+
+// clone deep-copies a market so that scenarios never modify the fixture.
+func clone(m map[string]any) map[string]any {
+	data, _ := json.Marshal(m)
+	var c map[string]any
+	_ = decode(bytes.NewReader(data), &c)
+	return c
+}
+
+// decode keeps numbers as json.Number so that they are served exactly as the
+// fixture wrote them.
+func decode(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func intParam(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// DefaultFixture returns the built-in markets, which cover the field shapes
+// the scraper has to cope with.
+func DefaultFixture() *Fixture {
+	f, err := parseFixture(defaultMarkets, "fixtures/markets.json")
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	return parseFixture(data, path)
+}
+
+// LoadScenario reads a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	var sc Scenario
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+func parseFixture(data []byte, name string) (*Fixture, error) {
+	var f Fixture
+	if err := decode(bytes.NewReader(data), &f); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", name, err)
+	}
+	return &f, nil
+}
@@ -0,0 +1,233 @@
+package stub_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket"
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket/stub"
+)
+
+const (
+	btc     = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+	btcYes  = "71321045679252212594626385532706912750332728571942532289631379312455583992563"
+	btcDip  = "0x8a1c9f0e6b5d7c3a2e4f6b8d0c1e3a5f7b9d2c4e6a8f0b1d3e5c7a9f2b4d6e8a"
+	fed     = "0x2c9e9b4f1b4a3e3d8f2a6c0e5d7b9a1c3e5f7a9b2d4c6e8f0a1b3c5d7e9f2a4c"
+	fedYes  = "28432187643387641239517287210462356126580384418417012765218815468318725590617"
+	timeout = 5 * time.Second
+)
+
+func start(t *testing.T, sc *stub.Scenario) (*stub.Server, string) {
+	t.Helper()
+	s, err := stub.New(stub.DefaultFixture(), sc, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts.URL
+}
+
+// nextRound starts a round the way the scraper does and returns the decoded
+// first page, or only the response status if it was not a 200.
+func nextRound(t *testing.T, url string) ([]polymarket.GammaMarket, int, error) {
+	t.Helper()
+	resp, err := http.Get(url + "/markets?closed=false&limit=100&offset=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+	var markets []polymarket.GammaMarket
+	err = json.NewDecoder(resp.Body).Decode(&markets)
+	return markets, resp.StatusCode, err
+}
+
+func find(markets []polymarket.GammaMarket, conditionID string) *polymarket.GammaMarket {
+	for i := range markets {
+		if markets[i].ConditionID == conditionID {
+			return &markets[i]
+		}
+	}
+	return nil
+}
+
+func TestClientsReadFixture(t *testing.T) {
+	_, url := start(t, nil)
+	ctx := context.Background()
+
+	markets, err := polymarket.NewGammaClient(url, timeout, polymarket.Resilience{}).FetchMarkets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 6 {
+		t.Fatalf("expected the 6 open fixture markets, got %d", len(markets))
+	}
+
+	m := find(markets, fed)
+	if m == nil {
+		t.Fatal("plain-array market missing")
+	}
+	if m.Volume.String() != "1.2e6" || len(m.ClobTokenIDs) != 2 || m.ParentEvent().ID != "15702" {
+		t.Errorf("plain-array market decoded as %+v", m)
+	}
+	if m := find(markets, btc); m == nil || m.OutcomePrices[0] != "0.215" || m.Events[0].Tags[1].ID.String() != "235" {
+		t.Errorf("string-encoded market decoded as %+v", m)
+	}
+
+	clob := polymarket.NewCLOBClient(url, timeout, polymarket.Resilience{}, polymarket.CLOBLimits{})
+	mid, err := clob.GetMidpoint(ctx, btcYes)
+	if err != nil || mid != 0.215 {
+		t.Errorf("midpoint = %v, %v; want 0.215", mid, err)
+	}
+	if _, err := clob.GetMidpoint(ctx, "unknown"); err == nil {
+		t.Error("expected an error for an unknown token")
+	}
+}
+
+func TestScenario(t *testing.T) {
+	s, url := start(t, &stub.Scenario{Steps: []stub.Step{
+		{Round: 2, Action: stub.ActionPrice, Market: fed, Prices: []string{"0.7", "0.3"}},
+		{Round: 2, Action: stub.ActionFault, Fault: &stub.Fault{Path: "/midpoint", Status: http.StatusTooManyRequests, Count: 2}},
+		{Round: 3, Action: stub.ActionFault, Fault: &stub.Fault{Path: "/markets", Malformed: true}},
+		{Round: 4, Action: stub.ActionResolve, Market: fed, Outcome: "Yes"},
+		{Round: 5, Action: stub.ActionClose, Market: fed},
+		{Round: 5, Action: stub.ActionRemove, Market: btc},
+		{Round: 6, Action: stub.ActionVoid, Market: fed},
+		{Round: 6, Action: stub.ActionSplit, Market: btcDip, Prices: []string{"0.5", "0.5"}},
+	}})
+
+	if _, status, err := nextRound(t, url); status != http.StatusOK || err != nil {
+		t.Fatalf("round 1: status %d, %v", status, err)
+	}
+
+	markets, _, _ := nextRound(t, url)
+	if m := find(markets, fed); m == nil || m.OutcomePrices[0] != "0.7" {
+		t.Errorf("round 2: price step not applied: %+v", m)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(url + "/midpoint?token_id=" + fedYes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+			t.Errorf("round 2: request %d: status %d, want 429 with Retry-After", i+1, resp.StatusCode)
+		}
+	}
+	mid, err := polymarket.NewCLOBClient(url, timeout, polymarket.Resilience{}, polymarket.CLOBLimits{}).GetMidpoint(context.Background(), fedYes)
+	if err != nil || mid != 0.7 {
+		t.Errorf("round 2: midpoint after the fault = %v, %v; want 0.7", mid, err)
+	}
+
+	if _, status, err := nextRound(t, url); status != http.StatusOK || err == nil {
+		t.Errorf("round 3: expected a malformed 200, got status %d, %v", status, err)
+	}
+
+	markets, _, _ = nextRound(t, url)
+	if m := find(markets, fed); m == nil || m.OutcomePrices[0] != "1" || m.OutcomePrices[1] != "0" {
+		t.Errorf("round 4: resolve step not applied: %+v", m)
+	}
+
+	markets, _, _ = nextRound(t, url)
+	if find(markets, fed) != nil || find(markets, btc) != nil || len(markets) != 4 {
+		t.Errorf("round 5: closed and removed markets still listed: %d markets", len(markets))
+	}
+
+	// Looking markets up by condition ID finds closed ones but not removed
+	// ones, and does not start a round.
+	gamma := polymarket.NewGammaClient(url, timeout, polymarket.Resilience{})
+	markets, err = gamma.FetchMarketsByConditionIDs(context.Background(), []string{fed, btc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 1 || markets[0].ConditionID != fed || !markets[0].Closed || markets[0].OutcomePrices[0] != "1" {
+		t.Errorf("round 5: lookup returned %+v, want only the closed fed market", markets)
+	}
+	if s.Round() != 5 {
+		t.Errorf("Round() = %d, want 5", s.Round())
+	}
+
+	nextRound(t, url)
+	markets, err = gamma.FetchMarketsByConditionIDs(context.Background(), []string{fed, btcDip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := find(markets, fed); m == nil || m.OutcomePrices[0] != "0" || m.OutcomePrices[1] != "0" || m.UMAResolutionStatus != polymarket.UMAResolved {
+		t.Errorf("round 6: void step not applied: %+v", m)
+	}
+	if m := find(markets, btcDip); m == nil || !m.Closed || m.OutcomePrices[0] != "0.5" || m.UMAResolutionStatus != polymarket.UMAResolved {
+		t.Errorf("round 6: split step not applied: %+v", m)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	s, url := start(t, nil)
+	var total int
+	for offset := 0; ; offset += 4 {
+		resp, err := http.Get(url + "/markets?limit=4&offset=" + strconv.Itoa(offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page []json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		total += len(page)
+	}
+	if total != 7 {
+		t.Errorf("paged through %d markets without a closed filter, want 7", total)
+	}
+	if s.Round() != 1 {
+		t.Errorf("only the first page starts a round, got %d rounds", s.Round())
+	}
+}
+
+func TestExampleScenario(t *testing.T) {
+	sc, err := stub.LoadScenario("fixtures/scenario.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, url := start(t, sc)
+	for round := 1; round <= 10; round++ {
+		if _, status, _ := nextRound(t, url); status != http.StatusOK {
+			t.Fatalf("round %d: status %d", round, status)
+		}
+	}
+}
+
+func TestNewRejectsInvalidSteps(t *testing.T) {
+	for _, step := range []stub.Step{
+		{Action: "explode"},
+		{Action: stub.ActionResolve, Market: fed},
+		{Action: stub.ActionPrice, Prices: []string{"0.5", "0.5"}},
+		{Action: stub.ActionSplit, Market: fed},
+		{Action: stub.ActionFault, Fault: &stub.Fault{Path: "/markets"}},
+		{Round: 1, Action: stub.ActionClose, Market: "0xunknown"},
+	} {
+		s, err := stub.New(stub.DefaultFixture(), &stub.Scenario{Steps: []stub.Step{step}}, 1)
+		if err == nil && step.Round == 0 {
+			t.Errorf("step %+v accepted", step)
+		}
+		if err == nil && step.Round > 0 {
+			// Unknown markets are only found when the step applies.
+			ts := httptest.NewServer(s)
+			if _, status, _ := nextRound(t, ts.URL); status != http.StatusInternalServerError {
+				t.Errorf("step %+v: status %d, want 500", step, status)
+			}
+			ts.Close()
+		}
+	}
+}
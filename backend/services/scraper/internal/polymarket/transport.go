@@ -0,0 +1,163 @@
+package polymarket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests to the Polymarket APIs are retried. A
+// request is retried after a network error, a 5xx or a 429, waiting a random
+// delay of up to BaseDelay doubled for every attempt so far (full jitter), or
+// as long as a Retry-After header asks. Both waits are capped at MaxDelay.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries per request, including the first.
+	// Values below 1 mean 1.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// PageResumes is how many times FetchMarkets resumes from a page that
+	// still failed after MaxAttempts, waiting MaxDelay first, before giving
+	// up and returning the pages fetched so far.
+	PageResumes int
+}
+
+// Resilience bundles the retry policy and the circuit breakers the clients
+// share. The zero value sends every request once with no circuit breaking.
+type Resilience struct {
+	Retry    RetryPolicy
+	Breakers *Breakers
+}
+
+// transport sends requests with retries, behind the circuit breaker of the
+// request's host.
+type transport struct {
+	client   *http.Client
+	retry    RetryPolicy
+	breakers *Breakers
+}
+
+func newTransport(timeout time.Duration, res Resilience) *transport {
+	res.Retry.MaxAttempts = max(res.Retry.MaxAttempts, 1)
+	return &transport{
+		client:   &http.Client{Timeout: timeout},
+		retry:    res.Retry,
+		breakers: res.Breakers,
+	}
+}
+
+// do sends req, retrying it as the policy allows, and returns the final
+// response, which the caller must close. beforeAttempt, if set, runs before
+// every attempt, e.g. to wait for a rate limiter. Retried requests must have
+// a GetBody if they have a body, as those made by http.NewRequest do.
+func (t *transport) do(req *http.Request, beforeAttempt func(context.Context) error) (*http.Response, error) {
+	ctx := req.Context()
+	br := t.breakers.forHost(req.URL.Host)
+
+	for attempt := 1; ; attempt++ {
+		if err := br.allow(); err != nil {
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+		}
+		if beforeAttempt != nil {
+			if err := beforeAttempt(ctx); err != nil {
+				br.abandon()
+				return nil, err
+			}
+		}
+
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			br.abandon()
+			return nil, err
+		}
+
+		resp, err := t.client.Do(attemptReq)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			br.abandon()
+			return nil, fmt.Errorf("executing request: %w", ctx.Err())
+		case err != nil:
+			br.record(false)
+		case resp.StatusCode >= 500:
+			br.record(false)
+		default:
+			// A 429 shows the host is up, so it does not trip the breaker.
+			br.record(true)
+		}
+
+		retryable := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		if !retryable || attempt >= t.retry.MaxAttempts {
+			if err != nil {
+				return nil, fmt.Errorf("executing request: %w", err)
+			}
+			return resp, nil
+		}
+
+		wait := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = min(after, t.retry.MaxDelay)
+			}
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			resp.Body.Close()
+		}
+		polymarketRetries.WithLabelValues(req.URL.Host).Inc()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("waiting to retry: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the full-jitter delay before retrying after the given
+// attempt.
+func (t *transport) backoff(attempt int) time.Duration {
+	ceiling := t.retry.BaseDelay << min(attempt-1, 30)
+	if ceiling <= 0 || ceiling > t.retry.MaxDelay {
+		ceiling = t.retry.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// rewind returns the request to send for the given attempt: req itself the
+// first time, then a copy with a fresh body.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry request without GetBody")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("rewinding request body: %w", err)
+	}
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,383 @@
+package polymarket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers each request with the next status in statuses, then
+// with 200s, and counts the requests.
+type scriptedServer struct {
+	statuses   []int
+	retryAfter string
+
+	mu       sync.Mutex
+	requests int
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	n := s.requests
+	s.requests++
+	s.mu.Unlock()
+
+	if n < len(s.statuses) && s.statuses[n] != http.StatusOK {
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(s.statuses[n])
+		return
+	}
+	fmt.Fprint(w, `{"mid":"0.5"}`)
+}
+
+func (s *scriptedServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func fastRetries(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		attempts     int
+		wantStatus   int
+		wantRequests int
+	}{
+		{"server errors", []int{503, 502}, 4, 200, 3},
+		{"rate limited", []int{429}, 4, 200, 2},
+		{"client error", []int{400}, 4, 400, 1},
+		{"not found", []int{404}, 4, 404, 1},
+		{"attempts exhausted", []int{500, 500, 500}, 3, 500, 3},
+		{"no retries", []int{503}, 0, 503, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &scriptedServer{statuses: tt.statuses}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			tr := newTransport(5*time.Second, Resilience{Retry: fastRetries(tt.attempts)})
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+			resp, err := tr.do(req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if n := srv.count(); n != tt.wantRequests {
+				t.Errorf("%d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestTransportRetriesRequestBody(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v []midpointsRequest
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		mu.Lock()
+		bodies = append(bodies, fmt.Sprint(v))
+		first := len(bodies) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"a":"0.5"}`)
+	}))
+	defer ts.Close()
+
+	clob := NewCLOBClient(ts.URL, 5*time.Second, Resilience{Retry: fastRetries(2)}, CLOBLimits{BatchSize: 10})
+	mids, err := clob.GetMidpoints(context.Background(), []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mids["a"] != 0.5 {
+		t.Errorf("midpoints %v, want a: 0.5", mids)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("request bodies %q, want the same body twice", bodies)
+	}
+}
+
+func TestTransportHonoursRetryAfter(t *testing.T) {
+	srv := &scriptedServer{statuses: []int{429}, retryAfter: "1"}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}
+	tr := newTransport(5*time.Second, Resilience{Retry: policy})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+
+	start := time.Now()
+	resp, err := tr.do(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %v, want the 1s Retry-After", elapsed)
+	}
+
+	// Retry-After is capped at MaxDelay.
+	srv = &scriptedServer{statuses: []int{503}, retryAfter: "3600"}
+	ts2 := httptest.NewServer(srv)
+	defer ts2.Close()
+	tr = newTransport(5*time.Second, Resilience{Retry: fastRetries(2)})
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, ts2.URL, nil)
+
+	start = time.Now()
+	resp, err = tr.do(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retried after %v, want Retry-After capped at the max delay", elapsed)
+	}
+}
+
+func TestTransportRetryCancelled(t *testing.T) {
+	srv := &scriptedServer{statuses: []int{503}, retryAfter: "60"}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Minute}
+	tr := newTransport(5*time.Second, Resilience{Retry: policy})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+
+	if _, err := tr.do(req, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v, want the context's", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := &scriptedServer{statuses: []int{500, 500, 500}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	breakers := NewBreakers(2, time.Minute)
+	now := time.Now()
+	host := ts.Listener.Addr().String()
+	breakers.forHost(host).now = func() time.Time { return now }
+
+	tr := newTransport(5*time.Second, Resilience{Breakers: breakers})
+	get := func() error {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL, nil)
+		resp, err := tr.do(req, nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// Two failures open the breaker, after which requests fail fast.
+	for i := 0; i < 2; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error %v with the breaker open, want ErrCircuitOpen", err)
+	}
+	if n := srv.count(); n != 2 {
+		t.Errorf("%d requests sent, want none while open", n)
+	}
+
+	// After the cooldown a failed probe reopens it...
+	now = now.Add(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error %v after a failed probe, want ErrCircuitOpen", err)
+	}
+
+	// ...and a successful one closes it.
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("request %d after recovery: %v", i+1, err)
+		}
+	}
+	if n := srv.count(); n != 6 {
+		t.Errorf("%d requests sent, want 6", n)
+	}
+}
+
+func TestBreakersDisabled(t *testing.T) {
+	if br := NewBreakers(0, time.Minute).forHost("example.com"); br != nil {
+		t.Error("breaker with threshold 0, want none")
+	}
+	var none *Breakers
+	if err := none.forHost("example.com").allow(); err != nil {
+		t.Errorf("allow with no breakers: %v", err)
+	}
+}
+
+// pagedServer serves markets in pages, failing requests for offsets in
+// failures as many times as given.
+type pagedServer struct {
+	total int
+
+	mu       sync.Mutex
+	failures map[int]int
+	requests map[int]int
+}
+
+func (s *pagedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	s.mu.Lock()
+	s.requests[offset]++
+	fail := s.failures[offset] > 0
+	if fail {
+		s.failures[offset]--
+	}
+	s.mu.Unlock()
+
+	if fail {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	markets := []GammaMarket{}
+	for i := offset; i < min(offset+limit, s.total); i++ {
+		markets = append(markets, GammaMarket{ID: strconv.Itoa(i), ConditionID: fmt.Sprintf("0x%d", i)})
+	}
+	json.NewEncoder(w).Encode(markets) //nolint:errcheck
+}
+
+func TestFetchMarketsResumes(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     map[int]int
+		resumes      int
+		wantMarkets  int
+		wantOffset   int // of the *PageError, or -1 for none
+		wantRequests map[int]int
+	}{
+		{
+			name:         "retried page",
+			failures:     map[int]int{4: 1},
+			wantMarkets:  9,
+			wantOffset:   -1,
+			wantRequests: map[int]int{4: 2},
+		},
+		{
+			name:         "resumed page",
+			failures:     map[int]int{4: 3},
+			resumes:      1,
+			wantMarkets:  9,
+			wantOffset:   -1,
+			wantRequests: map[int]int{0: 1, 2: 1, 4: 4},
+		},
+		{
+			name:         "partial feed",
+			failures:     map[int]int{4: 10},
+			resumes:      1,
+			wantMarkets:  4,
+			wantOffset:   4,
+			wantRequests: map[int]int{4: 4, 6: 0},
+		},
+		{
+			name:         "first page is not resumed",
+			failures:     map[int]int{0: 10},
+			resumes:      3,
+			wantMarkets:  0,
+			wantOffset:   0,
+			wantRequests: map[int]int{0: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &pagedServer{total: 9, failures: tt.failures, requests: map[int]int{}}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			policy := fastRetries(2)
+			policy.PageResumes = tt.resumes
+			gamma := NewGammaClient(ts.URL, 5*time.Second, Resilience{Retry: policy})
+			gamma.pageLimit = 2
+
+			markets, err := gamma.FetchMarkets(context.Background())
+			if len(markets) != tt.wantMarkets {
+				t.Errorf("%d markets, want %d", len(markets), tt.wantMarkets)
+			}
+			for i, m := range markets {
+				if m.ID != strconv.Itoa(i) {
+					t.Errorf("market %d has ID %s, want pages in order without gaps", i, m.ID)
+					break
+				}
+			}
+
+			var pageErr *PageError
+			switch {
+			case tt.wantOffset < 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantOffset >= 0 && !errors.As(err, &pageErr):
+				t.Errorf("error %v, want a *PageError", err)
+			case tt.wantOffset >= 0 && pageErr.Offset != tt.wantOffset:
+				t.Errorf("page error at offset %d, want %d", pageErr.Offset, tt.wantOffset)
+			}
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			for offset, want := range tt.wantRequests {
+				if got := srv.requests[offset]; got != want {
+					t.Errorf("%d requests for offset %d, want %d", got, offset, want)
+				}
+			}
+		})
+	}
+}
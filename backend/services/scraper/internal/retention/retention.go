@@ -0,0 +1,231 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// partitionPrefix names the monthly partitions of price_history; the suffix
+// is the UTC month as YYYYMM.
+const partitionPrefix = "price_history_p"
+
+// Policy controls how long each price history tier is kept. Daily rollups
+// are kept indefinitely.
+type Policy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+}
+
+// Retainer compacts raw price history into hourly and daily rollups and
+// removes data that has aged out of its tier.
+type Retainer struct {
+	pool   *pgxpool.Pool
+	policy Policy
+}
+
+// New creates a new Retainer.
+func New(pool *pgxpool.Pool, policy Policy) *Retainer {
+	return &Retainer{
+		pool:   pool,
+		policy: policy,
+	}
+}
+
+// Run performs one retention pass. Rollups always run before anything is
+// deleted, so a failed rollup never loses data.
+func (r *Retainer) Run(ctx context.Context) error {
+	startTime := time.Now()
+
+	if err := r.EnsurePartitions(ctx, startTime); err != nil {
+		return err
+	}
+
+	hourly, err := r.rollupHourly(ctx)
+	if err != nil {
+		return err
+	}
+
+	daily, err := r.rollupDaily(ctx)
+	if err != nil {
+		return err
+	}
+
+	rawCutoff := startTime.Add(-r.policy.Raw)
+	dropped, err := r.dropExpiredPartitions(ctx, rawCutoff)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM price_history WHERE recorded_at < $1`, rawCutoff)
+	if err != nil {
+		return fmt.Errorf("deleting expired raw prices: %w", err)
+	}
+	rawDeleted := tag.RowsAffected()
+
+	tag, err = r.pool.Exec(ctx, `DELETE FROM price_history_hourly WHERE bucket_start < $1`, startTime.Add(-r.policy.Hourly))
+	if err != nil {
+		return fmt.Errorf("deleting expired hourly prices: %w", err)
+	}
+
+	log.Info().
+		Int64("hourly_buckets", hourly).
+		Int64("daily_buckets", daily).
+		Int("partitions_dropped", dropped).
+		Int64("raw_deleted", rawDeleted).
+		Int64("hourly_deleted", tag.RowsAffected()).
+		Dur("duration", time.Since(startTime)).
+		Msg("price history retention completed")
+
+	return nil
+}
+
+// EnsurePartitions creates the price_history partitions for the month
+// containing now and the following month, so inserts never hit a missing
+// partition.
+func (r *Retainer) EnsurePartitions(ctx context.Context, now time.Time) error {
+	month := monthStart(now)
+	for i := 0; i < 2; i++ {
+		next := month.AddDate(0, 1, 0)
+		query := fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF price_history FOR VALUES FROM ('%s') TO ('%s')`,
+			partitionName(month), month.Format(time.RFC3339), next.Format(time.RFC3339),
+		)
+		if _, err := r.pool.Exec(ctx, query); err != nil {
+			return fmt.Errorf("creating partition %s: %w", partitionName(month), err)
+		}
+		month = next
+	}
+	return nil
+}
+
+// rollupHourly aggregates complete hours of raw prices into
+// price_history_hourly. The most recent existing bucket is recomputed, so the
+// pass is idempotent and picks up points that arrived after the last run.
+func (r *Retainer) rollupHourly(ctx context.Context) (int64, error) {
+	query := `
+		INSERT INTO price_history_hourly (event_id, outcome_label, bucket_start, open, high, low, close, samples)
+		SELECT event_id, outcome_label, date_trunc('hour', recorded_at, 'UTC') AS bucket,
+		       (array_agg(price ORDER BY recorded_at ASC))[1],
+		       MAX(price), MIN(price),
+		       (array_agg(price ORDER BY recorded_at DESC))[1],
+		       COUNT(*)
+		FROM price_history
+		WHERE recorded_at >= (SELECT COALESCE(MAX(bucket_start), '-infinity') FROM price_history_hourly)
+		  AND recorded_at < date_trunc('hour', NOW(), 'UTC')
+		GROUP BY event_id, outcome_label, bucket
+		ON CONFLICT (event_id, outcome_label, bucket_start) DO UPDATE SET
+			open    = EXCLUDED.open,
+			high    = EXCLUDED.high,
+			low     = EXCLUDED.low,
+			close   = EXCLUDED.close,
+			samples = EXCLUDED.samples
+	`
+
+	tag, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("rolling up hourly prices: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// rollupDaily aggregates complete UTC days of hourly rollups into
+// price_history_daily, recomputing the most recent existing bucket.
+func (r *Retainer) rollupDaily(ctx context.Context) (int64, error) {
+	query := `
+		INSERT INTO price_history_daily (event_id, outcome_label, bucket_start, open, high, low, close, samples)
+		SELECT event_id, outcome_label, date_trunc('day', bucket_start, 'UTC') AS bucket,
+		       (array_agg(open ORDER BY bucket_start ASC))[1],
+		       MAX(high), MIN(low),
+		       (array_agg(close ORDER BY bucket_start DESC))[1],
+		       SUM(samples)
+		FROM price_history_hourly
+		WHERE bucket_start >= (SELECT COALESCE(MAX(bucket_start), '-infinity') FROM price_history_daily)
+		  AND bucket_start < date_trunc('day', NOW(), 'UTC')
+		GROUP BY event_id, outcome_label, bucket
+		ON CONFLICT (event_id, outcome_label, bucket_start) DO UPDATE SET
+			open    = EXCLUDED.open,
+			high    = EXCLUDED.high,
+			low     = EXCLUDED.low,
+			close   = EXCLUDED.close,
+			samples = EXCLUDED.samples
+	`
+
+	tag, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("rolling up daily prices: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// dropExpiredPartitions drops every price_history partition whose whole
+// month lies before cutoff. Rows in the partition straddling the cutoff are
+// left for the row-level delete.
+func (r *Retainer) dropExpiredPartitions(ctx context.Context, cutoff time.Time) (int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'price_history'::regclass
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("listing price history partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return 0, fmt.Errorf("scanning partition name: %w", err)
+		}
+		month, ok := partitionMonth(name)
+		if !ok {
+			continue
+		}
+		if !month.AddDate(0, 1, 0).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterating partitions: %w", err)
+	}
+
+	for _, name := range expired {
+		if _, err := r.pool.Exec(ctx, "DROP TABLE "+name); err != nil {
+			return 0, fmt.Errorf("dropping partition %s: %w", name, err)
+		}
+		log.Info().Str("partition", name).Msg("dropped expired price history partition")
+	}
+
+	return len(expired), nil
+}
+
+// monthStart returns the first instant of t's month in UTC.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionName returns the name of the partition holding month.
+func partitionName(month time.Time) string {
+	return partitionPrefix + month.UTC().Format("200601")
+}
+
+// partitionMonth parses the month out of a partition name produced by
+// partitionName.
+func partitionMonth(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("200601", suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
@@ -2,30 +2,44 @@ package scheduler
 
 import (
 	"context"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
 
+	"github.com/poly-predict/backend/services/scraper/internal/retention"
 	"github.com/poly-predict/backend/services/scraper/internal/syncer"
 )
 
-// Scheduler wraps a cron scheduler that periodically triggers market syncs.
+// Scheduler wraps a cron scheduler that periodically triggers market syncs
+// and price history retention.
 type Scheduler struct {
-	cron   *cron.Cron
-	syncer *syncer.Syncer
+	cron      *cron.Cron
+	syncer    *syncer.Syncer
+	retainer  *retention.Retainer
+	intervals Intervals
+}
+
+// Intervals sets how often each job runs.
+type Intervals struct {
+	Sync      time.Duration
+	Retention time.Duration
 }
 
 // New creates a new Scheduler.
-func New(s *syncer.Syncer) *Scheduler {
+func New(s *syncer.Syncer, r *retention.Retainer, intervals Intervals) *Scheduler {
 	return &Scheduler{
-		cron:   cron.New(),
-		syncer: s,
+		cron:      cron.New(),
+		syncer:    s,
+		retainer:  r,
+		intervals: intervals,
 	}
 }
 
-// Start adds the sync job on a 30-minute interval and starts the cron scheduler.
+// Start adds the sync and retention jobs at their intervals, then starts the
+// cron scheduler.
 func (s *Scheduler) Start() {
-	_, err := s.cron.AddFunc("@every 30m", func() {
+	_, err := s.cron.AddFunc("@every "+s.intervals.Sync.String(), func() {
 		log.Info().Msg("cron triggered: starting scheduled sync")
 		ctx := context.Background()
 		if err := s.syncer.SyncAll(ctx); err != nil {
@@ -36,8 +50,22 @@ func (s *Scheduler) Start() {
 		log.Fatal().Err(err).Msg("failed to add cron job")
 	}
 
+	_, err = s.cron.AddFunc("@every "+s.intervals.Retention.String(), func() {
+		log.Info().Msg("cron triggered: starting price history retention")
+		ctx := context.Background()
+		if err := s.retainer.Run(ctx); err != nil {
+			log.Error().Err(err).Msg("price history retention failed")
+		}
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to add retention cron job")
+	}
+
 	s.cron.Start()
-	log.Info().Msg("cron scheduler started with @every 30m schedule")
+	log.Info().
+		Dur("sync_interval", s.intervals.Sync).
+		Dur("retention_interval", s.intervals.Retention).
+		Msg("cron scheduler started")
 }
 
 // Stop gracefully stops the cron scheduler, waiting for running jobs to finish.
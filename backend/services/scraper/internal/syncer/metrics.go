@@ -0,0 +1,29 @@
+package syncer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/poly-predict/backend/pkg/metrics"
+)
+
+var (
+	marketsFetched = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "scraper_markets_fetched_total",
+		Help:      "Markets fetched from the Gamma API across all syncs.",
+	})
+
+	syncDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "scraper_sync_duration_seconds",
+		Help:      "Duration of full market syncs, successful or not.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	lastSuccessfulSync = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "scraper_last_successful_sync_timestamp_seconds",
+		Help:      "Unix time at which the last successful market sync completed.",
+	})
+)
@@ -7,9 +7,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 
+	"github.com/poly-predict/backend/pkg/health"
+	"github.com/poly-predict/backend/pkg/model"
 	"github.com/poly-predict/backend/services/scraper/internal/polymarket"
 )
 
@@ -18,6 +21,7 @@ type Syncer struct {
 	pool   *pgxpool.Pool
 	gamma  *polymarket.GammaClient
 	clob   *polymarket.CLOBClient
+	runs   health.RunTracker
 }
 
 // New creates a new Syncer.
@@ -35,27 +39,56 @@ type syncStats struct {
 	New      int
 	Updated  int
 	Resolved int
+	Closed   int
+	Groups   int
 	Errors   int
 }
 
+// groupAggregate accumulates a parent event's details and the totals of its
+// child markets seen during a sync run.
+type groupAggregate struct {
+	event       polymarket.GammaEvent
+	volume24h   float64
+	marketCount int
+}
+
+// Runs returns the tracker of sync outcomes, for readiness checks.
+func (s *Syncer) Runs() *health.RunTracker {
+	return &s.runs
+}
+
 // SyncAll performs a full sync of all active markets from Polymarket.
-func (s *Syncer) SyncAll(ctx context.Context) error {
+func (s *Syncer) SyncAll(ctx context.Context) (err error) {
 	startTime := time.Now()
 	log.Info().Msg("starting market sync")
-
-	// 1. Fetch all active markets from Gamma.
-	markets, err := s.gamma.FetchMarkets(ctx)
-	if err != nil {
-		return fmt.Errorf("fetching markets: %w", err)
+	defer func() {
+		syncDuration.Observe(time.Since(startTime).Seconds())
+		s.runs.Record(startTime, err)
+	}()
+
+	// 1. Fetch all active markets from Gamma. If the feed fails part way the
+	// pages already fetched are still synced and the error is returned
+	// afterwards, so the run counts as failed.
+	markets, fetchErr := s.gamma.FetchMarkets(ctx)
+	if fetchErr != nil {
+		if len(markets) == 0 {
+			return fmt.Errorf("fetching markets: %w", fetchErr)
+		}
+		log.Warn().
+			Err(fetchErr).
+			Int("market_count", len(markets)).
+			Msg("market feed incomplete, syncing the markets fetched so far")
 	}
 
 	log.Info().Int("market_count", len(markets)).Msg("fetched markets from Gamma API")
+	marketsFetched.Add(float64(len(markets)))
 
 	// 2. Collect the set of condition IDs we see from the API for resolution detection.
 	activeConditionIDs := make(map[string]struct{}, len(markets))
 	stats := syncStats{Total: len(markets)}
 
-	// 3. Upsert each market and record price history.
+	// 3. Upsert each market.
+	synced := make([]polymarket.GammaMarket, 0, len(markets))
 	for _, market := range markets {
 		if market.ConditionID == "" {
 			log.Warn().
@@ -84,29 +117,69 @@ func (s *Syncer) SyncAll(ctx context.Context) error {
 		} else {
 			stats.Updated++
 		}
+		synced = append(synced, market)
+	}
 
-		// Record price history for each outcome with a CLOB token.
-		if err := s.recordPriceHistory(ctx, market); err != nil {
-			log.Error().
-				Err(err).
-				Str("condition_id", market.ConditionID).
-				Msg("failed to record price history, continuing")
+	// 4. Fetch the midpoints of every synced outcome at once, so the CLOB
+	// requests run concurrently, and record them as price history.
+	var tokenIDs []string
+	for _, market := range synced {
+		if len(market.Outcomes) > 0 {
+			tokenIDs = append(tokenIDs, market.ClobTokenIDs...)
 		}
 	}
+	midpoints, err := s.clob.GetMidpoints(ctx, tokenIDs)
+	if err != nil {
+		return fmt.Errorf("fetching midpoints: %w", err)
+	}
+	log.Info().
+		Int("token_count", len(tokenIDs)).
+		Int("midpoint_count", len(midpoints)).
+		Msg("fetched midpoints from CLOB API")
+
+	for _, market := range synced {
+		s.recordPriceHistory(ctx, market, midpoints)
+	}
+
+	// 5. Upsert the parent events that group the markets. Their totals need
+	// every child market, so they wait for a complete feed.
+	if fetchErr == nil {
+		stats.Groups = s.syncGroups(ctx, markets)
+	}
 
-	// 4. Detect resolutions: markets where one outcome price is "1" and another is "0".
+	// 6. Detect resolutions: markets where one outcome price is "1" and another
+	// is "0", or closed markets that were voided or split.
 	resolved := s.detectResolutions(ctx, markets)
 	stats.Resolved = resolved
 
+	// 7. Look up the events that dropped out of the feed. An incomplete feed
+	// would make every market after the failed page look dropped, so this
+	// waits for a complete one.
+	if fetchErr == nil {
+		closed, resolved, err := s.reconcileDropped(ctx, activeConditionIDs)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to look up markets missing from the feed, continuing")
+			stats.Errors++
+		}
+		stats.Closed = closed
+		stats.Resolved += resolved
+	}
+
 	elapsed := time.Since(startTime)
 	log.Info().
 		Int("total", stats.Total).
 		Int("new", stats.New).
 		Int("updated", stats.Updated).
 		Int("resolved", stats.Resolved).
+		Int("closed", stats.Closed).
+		Int("groups", stats.Groups).
 		Int("errors", stats.Errors).
 		Dur("elapsed", elapsed).
 		Msg("market sync completed")
+	if fetchErr != nil {
+		return fmt.Errorf("fetching markets: %w", fetchErr)
+	}
+	lastSuccessfulSync.SetToCurrentTime()
 
 	return nil
 }
@@ -151,6 +224,10 @@ func (s *Syncer) upsertMarket(ctx context.Context, m polymarket.GammaMarket) (bo
 	}
 
 	// Optional string fields.
+	parentEventID := ""
+	if parent := m.ParentEvent(); parent != nil {
+		parentEventID = parent.ID
+	}
 	description := nilIfEmpty(m.Description)
 	category := nilIfEmpty(m.Category)
 	imageURL := nilIfEmpty(m.Image)
@@ -159,17 +236,25 @@ func (s *Syncer) upsertMarket(ctx context.Context, m polymarket.GammaMarket) (bo
 		INSERT INTO events (
 			id, polymarket_event_id, slug, question, description, category, image_url,
 			outcomes, outcome_prices, clob_token_ids, status, volume, volume_24h,
-			liquidity, end_date, synced_at
+			liquidity, end_date, synced_at, search_vector
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7,
 			$8, $9, $10, 'open', $11, $12,
-			$13, $14, NOW()
+			$13, $14, NOW(),
+			setweight(to_tsvector('english', $4), 'A') ||
+			setweight(to_tsvector('english', COALESCE($5, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE($6, '')), 'C')
 		)
 		ON CONFLICT (id) DO UPDATE SET
+			polymarket_event_id = COALESCE(NULLIF(EXCLUDED.polymarket_event_id, ''), events.polymarket_event_id),
 			outcome_prices = EXCLUDED.outcome_prices,
 			volume = EXCLUDED.volume,
 			volume_24h = EXCLUDED.volume_24h,
 			liquidity = EXCLUDED.liquidity,
+			search_vector =
+				setweight(to_tsvector('english', events.question), 'A') ||
+				setweight(to_tsvector('english', COALESCE(events.description, '')), 'B') ||
+				setweight(to_tsvector('english', COALESCE(events.category, '')), 'C'),
 			synced_at = NOW(),
 			updated_at = NOW()
 		RETURNING (xmax = 0) AS is_new
@@ -178,7 +263,7 @@ func (s *Syncer) upsertMarket(ctx context.Context, m polymarket.GammaMarket) (bo
 	var isNew bool
 	err = s.pool.QueryRow(ctx, query,
 		m.ConditionID,   // $1  id
-		m.EventID,       // $2  polymarket_event_id
+		parentEventID,   // $2  polymarket_event_id
 		m.Slug,          // $3  slug
 		m.Question,      // $4  question
 		description,     // $5  description
@@ -199,23 +284,105 @@ func (s *Syncer) upsertMarket(ctx context.Context, m polymarket.GammaMarket) (bo
 	return isNew, nil
 }
 
-// recordPriceHistory fetches midpoints for each outcome's CLOB token and
-// inserts a price_history row.
-func (s *Syncer) recordPriceHistory(ctx context.Context, m polymarket.GammaMarket) error {
-	if len(m.ClobTokenIDs) == 0 || len(m.Outcomes) == 0 {
-		return nil
+// syncGroups upserts a market_groups row for every parent event referenced by
+// the fetched markets, with child volume and count totals from this run. It
+// returns the number of groups written.
+func (s *Syncer) syncGroups(ctx context.Context, markets []polymarket.GammaMarket) int {
+	groups := make(map[string]*groupAggregate)
+	for _, m := range markets {
+		parent := m.ParentEvent()
+		if m.ConditionID == "" || parent == nil {
+			continue
+		}
+
+		g, ok := groups[parent.ID]
+		if !ok {
+			g = &groupAggregate{event: *parent}
+			groups[parent.ID] = g
+		}
+
+		volume24h, _ := strconv.ParseFloat(m.Volume24hr.String(), 64)
+		g.volume24h += volume24h
+		g.marketCount++
 	}
 
-	midpoints, err := s.clob.GetMidpoints(ctx, m.ClobTokenIDs)
+	written := 0
+	for id, g := range groups {
+		if err := s.upsertGroup(ctx, g); err != nil {
+			log.Error().
+				Err(err).
+				Str("group_id", id).
+				Msg("failed to upsert market group, continuing")
+			continue
+		}
+		written++
+	}
+
+	return written
+}
+
+// upsertGroup inserts or updates a single parent event in market_groups.
+// Events embedded with only an ID keep any title and image stored earlier.
+func (s *Syncer) upsertGroup(ctx context.Context, g *groupAggregate) error {
+	tags := make([]string, 0, len(g.event.Tags))
+	for _, t := range g.event.Tags {
+		if t.Label != "" {
+			tags = append(tags, t.Label)
+		}
+	}
+
+	tagsJSON, err := json.Marshal(tags)
 	if err != nil {
-		return fmt.Errorf("fetching midpoints: %w", err)
+		return fmt.Errorf("marshaling tags: %w", err)
+	}
+
+	query := `
+		INSERT INTO market_groups (
+			id, slug, title, description, image_url, tags, volume_24h, market_count, synced_at
+		) VALUES (
+			$1, $2, COALESCE($3, ''), $4, $5, $6, $7, $8, NOW()
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			slug = COALESCE(EXCLUDED.slug, market_groups.slug),
+			title = COALESCE($3, market_groups.title),
+			description = COALESCE(EXCLUDED.description, market_groups.description),
+			image_url = COALESCE(EXCLUDED.image_url, market_groups.image_url),
+			tags = CASE WHEN jsonb_array_length(EXCLUDED.tags) > 0 THEN EXCLUDED.tags ELSE market_groups.tags END,
+			volume_24h = EXCLUDED.volume_24h,
+			market_count = EXCLUDED.market_count,
+			synced_at = NOW(),
+			updated_at = NOW()
+	`
+
+	_, err = s.pool.Exec(ctx, query,
+		g.event.ID,                      // $1 id
+		nilIfEmpty(g.event.Slug),        // $2 slug
+		nilIfEmpty(g.event.Title),       // $3 title
+		nilIfEmpty(g.event.Description), // $4 description
+		nilIfEmpty(g.event.Image),       // $5 image_url
+		tagsJSON,                        // $6 tags
+		g.volume24h,                     // $7 volume_24h
+		g.marketCount,                   // $8 market_count
+	)
+	if err != nil {
+		return fmt.Errorf("upserting market group %s: %w", g.event.ID, err)
 	}
 
+	return nil
+}
+
+// recordPriceHistory inserts a price_history row for each outcome of m
+// whose CLOB token has a midpoint. Failed rows are logged and skipped.
+func (s *Syncer) recordPriceHistory(ctx context.Context, m polymarket.GammaMarket, midpoints map[string]float64) {
 	query := `
 		INSERT INTO price_history (event_id, outcome_label, price, recorded_at)
 		VALUES ($1, $2, $3, NOW())
 	`
 
+	if len(m.Outcomes) == 0 {
+		return
+	}
+
 	for i, tokenID := range m.ClobTokenIDs {
 		if tokenID == "" {
 			continue
@@ -241,8 +408,6 @@ func (s *Syncer) recordPriceHistory(ctx context.Context, m polymarket.GammaMarke
 				Msg("failed to insert price history row")
 		}
 	}
-
-	return nil
 }
 
 // detectResolutions checks if any markets have been resolved by looking for
@@ -252,61 +417,212 @@ func (s *Syncer) detectResolutions(ctx context.Context, markets []polymarket.Gam
 	resolved := 0
 
 	for _, m := range markets {
-		if m.ConditionID == "" || len(m.OutcomePrices) == 0 || len(m.Outcomes) == 0 {
+		if m.ConditionID == "" {
 			continue
 		}
-
-		// Check if there is an outcome with price "1" (the winner).
-		winnerIdx := -1
-		hasZero := false
-		for i, price := range m.OutcomePrices {
-			if price == "1" {
-				winnerIdx = i
-			}
-			if price == "0" {
-				hasZero = true
-			}
+		winnerOutcome, ok := resolvedOutcome(m)
+		if !ok {
+			continue
 		}
 
-		if winnerIdx < 0 || !hasZero {
+		ok, err := s.resolveEvent(ctx, m, winnerOutcome)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("condition_id", m.ConditionID).
+				Str("winner", winnerOutcome).
+				Msg("failed to update resolved event")
 			continue
 		}
+		if ok {
+			resolved++
+		}
+	}
 
-		winnerOutcome := "Unknown"
-		if winnerIdx < len(m.Outcomes) {
-			winnerOutcome = m.Outcomes[winnerIdx]
+	return resolved
+}
+
+// reconcileDropped looks up, by condition ID, the open and closed events
+// that are missing from a complete feed of active markets. Markets that have
+// settled are resolved, including ones that settled while the scraper was
+// down, and markets that are closed but not yet settled are closed, so they
+// take no more bets and are looked up again on later syncs until they
+// resolve. Markets Gamma no longer knows are left as they are. It returns
+// how many events it closed and resolved, counting those from the batches
+// looked up before any lookup error.
+func (s *Syncer) reconcileDropped(ctx context.Context, active map[string]struct{}) (closed, resolved int, err error) {
+	rows, err := s.pool.Query(ctx, `SELECT id FROM events WHERE status IN ('open', 'closed')`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("listing unresolved events: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, 0, fmt.Errorf("listing unresolved events: %w", err)
+	}
+
+	var dropped []string
+	for _, id := range ids {
+		if _, ok := active[id]; !ok {
+			dropped = append(dropped, id)
 		}
+	}
+	if len(dropped) == 0 {
+		return 0, 0, nil
+	}
+
+	// A failed batch still returns the markets of the batches before it,
+	// which are reconciled before the error is reported.
+	markets, lookupErr := s.gamma.FetchMarketsByConditionIDs(ctx, dropped)
+	log.Info().
+		Int("dropped", len(dropped)).
+		Int("found", len(markets)).
+		Msg("looked up markets missing from the feed")
 
-		query := `
+	found := make(map[string]bool, len(markets))
+	for _, m := range markets {
+		found[m.ConditionID] = true
+
+		if outcome, ok := resolvedOutcome(m); ok {
+			ok, err := s.resolveEvent(ctx, m, outcome)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("condition_id", m.ConditionID).
+					Str("winner", outcome).
+					Msg("failed to update resolved event")
+				continue
+			}
+			if ok {
+				resolved++
+			}
+			continue
+		}
+
+		if !m.Closed {
+			continue
+		}
+		tag, err := s.pool.Exec(ctx, `
 			UPDATE events
-			SET status = 'resolved',
-				resolved_outcome = $2,
-				resolved_at = NOW(),
+			SET status = 'closed',
 				updated_at = NOW()
 			WHERE id = $1
 			  AND status = 'open'
-		`
-
-		tag, err := s.pool.Exec(ctx, query, m.ConditionID, winnerOutcome)
+		`, m.ConditionID)
 		if err != nil {
 			log.Error().
 				Err(err).
 				Str("condition_id", m.ConditionID).
-				Str("winner", winnerOutcome).
-				Msg("failed to update resolved event")
+				Msg("failed to close event")
 			continue
 		}
-
 		if tag.RowsAffected() > 0 {
-			resolved++
+			closed++
 			log.Info().
 				Str("condition_id", m.ConditionID).
-				Str("resolved_outcome", winnerOutcome).
-				Msg("event resolved")
+				Msg("event closed")
 		}
 	}
 
-	return resolved
+	// The IDs of a failed batch were never looked up, so they cannot be
+	// reported as unknown to Gamma.
+	if lookupErr != nil {
+		return closed, resolved, lookupErr
+	}
+
+	for _, id := range dropped {
+		if !found[id] {
+			log.Warn().
+				Str("condition_id", id).
+				Msg("market missing from the feed and unknown to Gamma, leaving it unchanged")
+		}
+	}
+
+	return closed, resolved, nil
+}
+
+// resolvedOutcome returns what a market settled on: the winning outcome if
+// one of its prices is "1" and another "0". Once the market is closed and
+// its resolution final it may instead be model.ResolutionVoid, when every
+// price is 0, or model.ResolutionSplit, when the prices otherwise make a
+// valid split such as 50/50.
+func resolvedOutcome(m polymarket.GammaMarket) (string, bool) {
+	if len(m.OutcomePrices) == 0 || len(m.Outcomes) == 0 {
+		return "", false
+	}
+
+	// Check if there is an outcome with price "1" (the winner).
+	winnerIdx := -1
+	hasZero := false
+	for i, price := range m.OutcomePrices {
+		if price == "1" {
+			winnerIdx = i
+		}
+		if price == "0" {
+			hasZero = true
+		}
+	}
+
+	if winnerIdx >= 0 && hasZero {
+		if winnerIdx < len(m.Outcomes) {
+			return m.Outcomes[winnerIdx], true
+		}
+		return "Unknown", true
+	}
+
+	// Prices part way through a live market can look like a split, so only
+	// a final resolution counts.
+	if !m.Closed || m.UMAResolutionStatus != polymarket.UMAResolved {
+		return "", false
+	}
+
+	void := true
+	for _, price := range m.OutcomePrices {
+		if p, err := strconv.ParseFloat(price, 64); err != nil || p != 0 {
+			void = false
+		}
+	}
+	if void {
+		return model.ResolutionVoid, true
+	}
+	if _, err := model.SplitPrices(m.Outcomes, m.OutcomePrices); err == nil {
+		return model.ResolutionSplit, true
+	}
+	return "", false
+}
+
+// resolveEvent marks the open or closed event of market m resolved with
+// outcome, storing the market's final prices, which a split settles at, and
+// reports whether it did.
+func (s *Syncer) resolveEvent(ctx context.Context, m polymarket.GammaMarket, outcome string) (bool, error) {
+	pricesJSON, err := json.Marshal(m.OutcomePrices)
+	if err != nil {
+		return false, fmt.Errorf("marshaling outcome prices: %w", err)
+	}
+
+	query := `
+		UPDATE events
+		SET status = 'resolved',
+			resolved_outcome = $2,
+			outcome_prices = $3,
+			resolved_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+		  AND status IN ('open', 'closed')
+	`
+
+	tag, err := s.pool.Exec(ctx, query, m.ConditionID, outcome, pricesJSON)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	log.Info().
+		Str("condition_id", m.ConditionID).
+		Str("resolved_outcome", outcome).
+		Msg("event resolved")
+	return true, nil
 }
 
 // nilIfEmpty returns a pointer to s if s is non-empty, otherwise nil.
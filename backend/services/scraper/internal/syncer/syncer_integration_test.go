@@ -0,0 +1,216 @@
+//go:build integration
+
+package syncer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/testdb"
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket"
+	"github.com/poly-predict/backend/services/scraper/internal/polymarket/stub"
+)
+
+// Markets in the stub's built-in fixture.
+const (
+	btc     = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+	fed     = "0x2c9e9b4f1b4a3e3d8f2a6c0e5d7b9a1c3e5f7a9b2d4c6e8f0a1b3c5d7e9f2a4c"
+	madrid  = "0x9d3b1a7c5e2f4d6b8a0c1e3f5a7b9d2c4e6f8a0b1c3d5e7f9a2b4c6d8e0f1a3b"
+	arsenal = "0x4e6a8c0b2d4f6a8c0e2b4d6f8a0c2e4b6d8f0a2c4e6b8d0f2a4c6e8b0d2f4a6c"
+	btcDip  = "0x8a1c9f0e6b5d7c3a2e4f6b8d0c1e3a5f7b9d2c4e6a8f0b1d3e5c7a9f2b4d6e8a"
+)
+
+// A market the scenario adds, to be voided.
+const debate = "0x6c8e0a2c4e6b8d0f2a4c6e8b0d2f4a6c8e0b2d4f6a8c0e2b4d6f8a0c2e4b6d8f"
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func newSyncer(t *testing.T, pool *pgxpool.Pool, sc *stub.Scenario) *Syncer {
+	t.Helper()
+	srv, err := stub.New(stub.DefaultFixture(), sc, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return New(pool, polymarket.NewGammaClient(ts.URL, 5*time.Second, polymarket.Resilience{}), polymarket.NewCLOBClient(ts.URL, 5*time.Second, polymarket.Resilience{}, polymarket.CLOBLimits{
+		Concurrency: 4,
+		BatchSize:   100,
+	}))
+}
+
+func count(t *testing.T, pool *pgxpool.Pool, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := pool.QueryRow(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+type eventRow struct {
+	status   string
+	prices   []string
+	outcome  *string
+	parentID *string
+}
+
+func event(t *testing.T, pool *pgxpool.Pool, id string) eventRow {
+	t.Helper()
+	var e eventRow
+	if err := pool.QueryRow(context.Background(),
+		`SELECT status, outcome_prices, resolved_outcome, polymarket_event_id FROM events WHERE id = $1`, id,
+	).Scan(&e.status, &e.prices, &e.outcome, &e.parentID); err != nil {
+		t.Fatalf("event %s: %v", id, err)
+	}
+	return e
+}
+
+func TestSyncAllAgainstStub(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	s := newSyncer(t, pool, &stub.Scenario{Steps: []stub.Step{
+		{Round: 2, Action: stub.ActionPrice, Market: fed, Prices: []string{"0.9", "0.1"}},
+		{Round: 2, Action: stub.ActionFault, Fault: &stub.Fault{Path: "/midpoints", Status: http.StatusTooManyRequests}},
+		{Round: 2, Action: stub.ActionFault, Fault: &stub.Fault{Path: "/midpoint", Status: http.StatusTooManyRequests, Count: 2}},
+		{Round: 3, Action: stub.ActionResolve, Market: fed, Outcome: "Yes"},
+		{Round: 4, Action: stub.ActionFault, Fault: &stub.Fault{Path: "/markets", Malformed: true}},
+		{Round: 4, Action: stub.ActionClose, Market: btc},
+		{Round: 5, Action: stub.ActionResolve, Market: madrid, Outcome: "Yes"},
+		{Round: 5, Action: stub.ActionClose, Market: madrid},
+		{Round: 5, Action: stub.ActionRemove, Market: arsenal},
+		{Round: 6, Action: stub.ActionResolve, Market: btc, Outcome: "No"},
+		{Round: 6, Action: stub.ActionAdd, Data: map[string]any{
+			"id":            "560002",
+			"question":      "Will the second presidential debate take place?",
+			"conditionId":   debate,
+			"outcomes":      []string{"Yes", "No"},
+			"outcomePrices": []string{"0.4", "0.6"},
+			"closed":        false,
+			"active":        true,
+		}},
+		{Round: 7, Action: stub.ActionVoid, Market: debate},
+		{Round: 7, Action: stub.ActionSplit, Market: btcDip, Prices: []string{"0.5", "0.5"}},
+	}})
+
+	// A bet on the market that will resolve, to check balances along the way.
+	user := testdb.CreateUser(t, pool, 1000)
+
+	// Round 1: the fixture's open markets are inserted, except the one
+	// without a condition ID, with a price point per outcome.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM events WHERE status = 'open'`); n != 5 {
+		t.Errorf("round 1: %d open events, want 5", n)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM price_history`); n != 10 {
+		t.Errorf("round 1: %d price points, want 10", n)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM market_groups`); n != 3 {
+		t.Errorf("round 1: %d market groups, want 3", n)
+	}
+	if n := count(t, pool, `SELECT market_count FROM market_groups WHERE id = '16085' AND title <> '' AND tags ? 'Bitcoin'`); n != 2 {
+		t.Errorf("round 1: group 16085 has %d markets, want 2 with its title and tags", n)
+	}
+	if e := event(t, pool, fed); e.parentID == nil || *e.parentID != "15702" {
+		t.Errorf("round 1: bare eventId not stored as the parent: %v", e.parentID)
+	}
+	testdb.PlaceBet(t, pool, user, fed, "yes", 100, 0.62)
+	testdb.CheckBalances(t, pool)
+
+	// Round 2: prices are updated in place. The rate-limited batch falls
+	// back to single requests, and the two of those that are rate limited
+	// too are skipped without failing the sync.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM events`); n != 5 {
+		t.Errorf("round 2: %d events, want the same 5", n)
+	}
+	if e := event(t, pool, fed); e.status != "open" || e.prices[0] != "0.9" {
+		t.Errorf("round 2: fed market %+v, want open at 0.9", e)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM price_history`); n != 18 {
+		t.Errorf("round 2: %d price points, want 18 (two midpoints rate limited)", n)
+	}
+	testdb.CheckBalances(t, pool)
+
+	// Round 3: the market settles at 1/0 and is resolved.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	e := event(t, pool, fed)
+	if e.status != "resolved" || e.outcome == nil || *e.outcome != "Yes" {
+		t.Errorf("round 3: fed market %+v, want resolved as Yes", e)
+	}
+	if e := event(t, pool, btc); e.status != "open" {
+		t.Errorf("round 3: unrelated market is %s", e.status)
+	}
+	testdb.CheckBalances(t, pool)
+
+	// Round 4: a malformed page fails the sync without touching the data,
+	// and the market that closed meanwhile is not taken as dropped.
+	if err := s.SyncAll(ctx); err == nil {
+		t.Error("round 4: expected the malformed page to fail the sync")
+	}
+	if e := event(t, pool, fed); e.status != "resolved" {
+		t.Errorf("round 4: fed market is %s, want still resolved", e.status)
+	}
+	if e := event(t, pool, btc); e.status != "open" {
+		t.Errorf("round 4: btc market is %s after a failed sync, want open", e.status)
+	}
+	if n := count(t, pool, `SELECT COUNT(*) FROM events`); n != 5 {
+		t.Errorf("round 4: %d events, want 5", n)
+	}
+	testdb.CheckBalances(t, pool)
+
+	// Round 5: markets that dropped out of the feed are looked up. The one
+	// that closed is closed, the one that settled and closed between syncs
+	// is resolved, and the one Gamma no longer lists is left open.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := event(t, pool, btc); e.status != "closed" {
+		t.Errorf("round 5: btc market is %s, want closed", e.status)
+	}
+	if e := event(t, pool, madrid); e.status != "resolved" || e.outcome == nil || *e.outcome != "Yes" {
+		t.Errorf("round 5: madrid market %+v, want resolved as Yes", e)
+	}
+	if e := event(t, pool, arsenal); e.status != "open" {
+		t.Errorf("round 5: removed market is %s, want left open", e.status)
+	}
+
+	// Round 6: the closed market settles and is resolved.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := event(t, pool, btc); e.status != "resolved" || e.outcome == nil || *e.outcome != "No" {
+		t.Errorf("round 6: btc market %+v, want resolved as No", e)
+	}
+	if e := event(t, pool, debate); e.status != "open" {
+		t.Errorf("round 6: added market is %s, want open", e.status)
+	}
+
+	// Round 7: a voided and a split market drop out of the feed and are
+	// resolved as such, keeping the final prices a split settles at.
+	if err := s.SyncAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := event(t, pool, debate); e.status != "resolved" || e.outcome == nil || *e.outcome != model.ResolutionVoid {
+		t.Errorf("round 7: debate market %+v, want voided", e)
+	}
+	if e := event(t, pool, btcDip); e.status != "resolved" || e.outcome == nil || *e.outcome != model.ResolutionSplit ||
+		len(e.prices) != 2 || e.prices[0] != "0.5" || e.prices[1] != "0.5" {
+		t.Errorf("round 7: btc dip market %+v, want split at 0.5/0.5", e)
+	}
+	testdb.CheckBalances(t, pool)
+}
@@ -2,35 +2,34 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/poly-predict/backend/pkg/config"
 	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/health"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/metrics"
+	"github.com/poly-predict/backend/pkg/migrate"
+	"github.com/poly-predict/backend/services/settler/internal/repository"
 	"github.com/poly-predict/backend/services/settler/internal/scheduler"
 	"github.com/poly-predict/backend/services/settler/internal/settler"
 )
 
 func main() {
-	// Configure zerolog for human-friendly console output.
-	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
-		With().
-		Timestamp().
-		Str("service", "settler").
-		Logger()
-
-	log.Info().Msg("starting settler service")
-
 	// Load configuration from environment / .env file.
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.ServiceSettler)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
 
+	logging.Setup("settler", cfg.Environment, cfg.LogLevel)
+	log.Info().Msg("starting settler service")
+
 	// Initialise the database connection pool.
 	ctx := context.Background()
 	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
@@ -41,8 +40,32 @@ func main() {
 
 	log.Info().Msg("database pool initialised")
 
-	// Create the settler and run an initial settlement cycle immediately.
-	s := settler.New(pool)
+	if cfg.MigrateOnStart {
+		if err := migrate.ApplyEmbedded(ctx, pool); err != nil {
+			log.Fatal().Err(err).Msg("failed to apply database migrations")
+		}
+	}
+
+	// Create the settler.
+	s := settler.New(db.NewTransactor(pool), repository.NewSettlementRepository(pool, cfg.StartingBalance))
+
+	// Metrics and probes. Readiness fails when the last cycle failed or is
+	// older than SettlementStaleAfter, or when resolved events wait too long.
+	readiness := health.NewChecker(
+		health.Database(pool),
+		health.PoolSaturation(pool, 0.9),
+		health.LastRun(s.Runs(), cfg.Health.SettlementStaleAfter),
+		health.SettlementBacklog(pool, cfg.Health.SettlementStaleAfter, true),
+		health.SyncFreshness(pool, cfg.Health.SyncStaleAfter, false),
+	)
+	metrics.RegisterPool(pool)
+	metricsSrv := metrics.Serve(":"+cfg.MetricsPort(), map[string]http.Handler{
+		"/livez":  health.Live(),
+		"/readyz": readiness,
+	})
+	defer metricsSrv.Close()
+
+	// Run an initial settlement cycle immediately.
 
 	log.Info().Msg("running initial settlement cycle")
 	if err := s.Run(ctx); err != nil {
@@ -50,11 +73,11 @@ func main() {
 	}
 
 	// Set up the cron scheduler.
-	sched := scheduler.New(s)
+	sched := scheduler.New(s, cfg.Settler.Interval)
 	sched.Start()
 	defer sched.Stop()
 
-	log.Info().Msg("cron scheduler started – settling every 5 minutes")
+	log.Info().Dur("interval", cfg.Settler.Interval).Msg("cron scheduler started")
 
 	// Block until SIGINT or SIGTERM is received.
 	sigCh := make(chan os.Signal, 1)
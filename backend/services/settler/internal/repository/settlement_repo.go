@@ -0,0 +1,209 @@
+// Package repository provides the settler's database access.
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/settle"
+)
+
+// SettlementRepository provides database access for settling events.
+type SettlementRepository struct {
+	pool            *pgxpool.Pool
+	startingBalance int64
+}
+
+// NewSettlementRepository creates a new SettlementRepository. Rankings
+// measure each user's profit against startingBalance.
+func NewSettlementRepository(pool *pgxpool.Pool, startingBalance int64) *SettlementRepository {
+	return &SettlementRepository{pool: pool, startingBalance: startingBalance}
+}
+
+// ListUnsettled returns the resolved events that have no settlement yet.
+func (r *SettlementRepository) ListUnsettled(ctx context.Context) ([]*model.Event, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT e.id, e.polymarket_event_id, e.slug, e.question, e.description,
+		       e.category, e.image_url, e.outcomes, e.outcome_prices,
+		       e.clob_token_ids, e.status, e.resolved_outcome, e.resolved_at,
+		       e.volume, e.volume_24h, e.liquidity, e.end_date,
+		       e.created_at, e.updated_at, e.synced_at
+		FROM events e
+		WHERE e.status = 'resolved'
+		  AND e.resolved_outcome IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM settlements s WHERE s.event_id = e.id)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query unsettled events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var ev model.Event
+		if err := rows.Scan(
+			&ev.ID, &ev.PolymarketEventID, &ev.Slug, &ev.Question, &ev.Description,
+			&ev.Category, &ev.ImageURL, &ev.Outcomes, &ev.OutcomePrices,
+			&ev.ClobTokenIDs, &ev.Status, &ev.ResolvedOutcome, &ev.ResolvedAt,
+			&ev.Volume, &ev.Volume24h, &ev.Liquidity, &ev.EndDate,
+			&ev.CreatedAt, &ev.UpdatedAt, &ev.SyncedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan event row: %w", err)
+		}
+		events = append(events, &ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// IsSettled reports within tx whether the event already has a settlement.
+func (r *SettlementRepository) IsSettled(ctx context.Context, tx pgx.Tx, eventID string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM settlements WHERE event_id = $1)`, eventID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("idempotency check: %w", err)
+	}
+	return exists, nil
+}
+
+// LockPendingBets returns the event's pending bets, locked within tx to
+// prevent concurrent modifications.
+func (r *SettlementRepository) LockPendingBets(ctx context.Context, tx pgx.Tx, eventID string) ([]*model.Bet, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id, event_id, outcome, amount, locked_odds,
+		       potential_payout, status, payout, settled_at, created_at
+		FROM bets
+		WHERE event_id = $1 AND status = 'pending'
+		FOR UPDATE
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("lock bets: %w", err)
+	}
+	defer rows.Close()
+
+	var bets []*model.Bet
+	for rows.Next() {
+		var b model.Bet
+		if err := rows.Scan(
+			&b.ID, &b.UserID, &b.EventID, &b.Outcome, &b.Amount,
+			&b.LockedOdds, &b.PotentialPayout, &b.Status, &b.Payout,
+			&b.SettledAt, &b.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan bet row: %w", err)
+		}
+		bets = append(bets, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bet rows: %w", err)
+	}
+
+	return bets, nil
+}
+
+// SettleBet settles a locked bet within tx; see settle.Bet.
+func (r *SettlementRepository) SettleBet(ctx context.Context, tx pgx.Tx, bet *model.Bet, status model.BetStatus, payout int64, question string) error {
+	return settle.Bet(ctx, tx, bet, status, payout, question)
+}
+
+// PayReferralBonuses pays the bonuses the users' settled bets have unlocked
+// within tx; see settle.PayReferralBonuses.
+func (r *SettlementRepository) PayReferralBonuses(ctx context.Context, tx pgx.Tx, userIDs []string) error {
+	return settle.PayReferralBonuses(ctx, tx, userIDs)
+}
+
+// Create records the event's settlement within tx.
+func (r *SettlementRepository) Create(ctx context.Context, tx pgx.Tx, eventID, outcome string, totalBets int, totalPayouts int64) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO settlements (event_id, resolved_outcome, total_bets, total_payouts)
+		VALUES ($1, $2, $3, $4)
+	`, eventID, outcome, totalBets, totalPayouts)
+	if err != nil {
+		return fmt.Errorf("insert settlement: %w", err)
+	}
+	return nil
+}
+
+// RecalculateRankings rebuilds all rankings (all_time, weekly, monthly).
+func (r *SettlementRepository) RecalculateRankings(ctx context.Context) error {
+	// The statements run as one batch, which cannot take bind parameters, so
+	// the starting balance (an integer) is formatted in.
+	_, err := r.pool.Exec(ctx, fmt.Sprintf(`
+		DELETE FROM rankings WHERE category IS NULL;
+
+		-- All time rankings from user stats
+		INSERT INTO rankings (
+			user_id, period, total_assets, total_profit,
+			win_count, loss_count, win_rate, roi,
+			consecutive_wins, rank_position
+		)
+		SELECT
+			u.id, 'all_time',
+			u.balance + u.frozen_balance,
+			(u.balance + u.frozen_balance) - %[1]d,
+			u.total_wins,
+			u.total_bets - u.total_wins,
+			CASE WHEN u.total_bets > 0 THEN u.total_wins::numeric / u.total_bets ELSE 0 END,
+			CASE WHEN u.total_bets > 0 THEN ((u.balance + u.frozen_balance) - %[1]d)::numeric / %[1]d ELSE 0 END,
+			u.current_streak,
+			ROW_NUMBER() OVER (ORDER BY (u.balance + u.frozen_balance) DESC)
+		FROM users u
+		WHERE u.total_bets > 0;
+
+		-- Weekly rankings from bets settled in the last 7 days. Lost bets
+		-- pay nothing except in a split, so profit counts every payout.
+		INSERT INTO rankings (
+			user_id, period, total_assets, total_profit,
+			win_count, loss_count, win_rate, roi,
+			consecutive_wins, rank_position
+		)
+		SELECT
+			b.user_id, 'weekly',
+			COALESCE(SUM(b.payout), 0),
+			COALESCE(SUM(COALESCE(b.payout, 0) - b.amount), 0),
+			COUNT(*) FILTER (WHERE b.status = 'won'),
+			COUNT(*) FILTER (WHERE b.status = 'lost'),
+			CASE WHEN COUNT(*) > 0 THEN COUNT(*) FILTER (WHERE b.status = 'won')::numeric / COUNT(*) ELSE 0 END,
+			CASE WHEN SUM(b.amount) > 0 THEN
+				SUM(COALESCE(b.payout, 0) - b.amount)::numeric / SUM(b.amount)
+			ELSE 0 END,
+			0,
+			ROW_NUMBER() OVER (ORDER BY SUM(COALESCE(b.payout, 0) - b.amount) DESC)
+		FROM bets b
+		WHERE b.status IN ('won', 'lost') AND b.settled_at >= NOW() - INTERVAL '7 days'
+		GROUP BY b.user_id;
+
+		-- Monthly rankings from bets settled in the last 30 days
+		INSERT INTO rankings (
+			user_id, period, total_assets, total_profit,
+			win_count, loss_count, win_rate, roi,
+			consecutive_wins, rank_position
+		)
+		SELECT
+			b.user_id, 'monthly',
+			COALESCE(SUM(b.payout), 0),
+			COALESCE(SUM(COALESCE(b.payout, 0) - b.amount), 0),
+			COUNT(*) FILTER (WHERE b.status = 'won'),
+			COUNT(*) FILTER (WHERE b.status = 'lost'),
+			CASE WHEN COUNT(*) > 0 THEN COUNT(*) FILTER (WHERE b.status = 'won')::numeric / COUNT(*) ELSE 0 END,
+			CASE WHEN SUM(b.amount) > 0 THEN
+				SUM(COALESCE(b.payout, 0) - b.amount)::numeric / SUM(b.amount)
+			ELSE 0 END,
+			0,
+			ROW_NUMBER() OVER (ORDER BY SUM(COALESCE(b.payout, 0) - b.amount) DESC)
+		FROM bets b
+		WHERE b.status IN ('won', 'lost') AND b.settled_at >= NOW() - INTERVAL '30 days'
+		GROUP BY b.user_id;
+	`, r.startingBalance))
+	if err != nil {
+		return fmt.Errorf("recalculate rankings: %w", err)
+	}
+
+	return nil
+}
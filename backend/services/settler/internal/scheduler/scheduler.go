@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
@@ -11,21 +12,23 @@ import (
 
 // Scheduler wraps a cron scheduler that periodically triggers the settler.
 type Scheduler struct {
-	cron    *cron.Cron
-	settler *settler.Settler
+	cron     *cron.Cron
+	settler  *settler.Settler
+	interval time.Duration
 }
 
-// New creates a Scheduler that will invoke the given Settler on each tick.
-func New(s *settler.Settler) *Scheduler {
+// New creates a Scheduler that will invoke the given Settler every interval.
+func New(s *settler.Settler, interval time.Duration) *Scheduler {
 	return &Scheduler{
-		cron:    cron.New(),
-		settler: s,
+		cron:     cron.New(),
+		settler:  s,
+		interval: interval,
 	}
 }
 
 // Start registers the settlement job and starts the cron scheduler.
 func (s *Scheduler) Start() {
-	_, err := s.cron.AddFunc("@every 5m", func() {
+	_, err := s.cron.AddFunc("@every "+s.interval.String(), func() {
 		log.Info().Msg("cron triggered settlement cycle")
 		if err := s.settler.Run(context.Background()); err != nil {
 			log.Error().Err(err).Msg("scheduled settlement cycle failed")
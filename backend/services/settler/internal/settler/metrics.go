@@ -0,0 +1,32 @@
+package settler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/poly-predict/backend/pkg/metrics"
+)
+
+var (
+	cycleDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "settlement_cycle_duration_seconds",
+		Help:      "Duration of settlement cycles, including ranking recalculation.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	})
+
+	eventsSettled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "events_settled_total",
+		Help:      "Resolved events settled by the settler.",
+	})
+
+	// settlementFailures is labelled by the stage that failed: "query" when
+	// unsettled events could not be listed, "event" per event that failed to
+	// settle, and "rankings" when rankings could not be recalculated.
+	settlementFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "settlement_failures_total",
+		Help:      "Settlement failures by stage.",
+	}, []string{"stage"})
+)
@@ -0,0 +1,259 @@
+//go:build integration
+
+package settler
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/model"
+	"github.com/poly-predict/backend/pkg/testdb"
+	"github.com/poly-predict/backend/services/settler/internal/repository"
+)
+
+const startingBalance = 1000
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func newSettler(pool *pgxpool.Pool) *Settler {
+	return New(db.NewTransactor(pool), repository.NewSettlementRepository(pool, startingBalance))
+}
+
+// resolvedEvent creates an event with a winning Yes bet of 100 at 0.4 and a
+// losing No bet of 200 at 0.6, resolved as Yes. It returns the event and the
+// winner's and loser's IDs.
+func resolvedEvent(t *testing.T, pool *pgxpool.Pool) (*model.Event, string, string) {
+	t.Helper()
+	winner := testdb.CreateUser(t, pool, startingBalance)
+	loser := testdb.CreateUser(t, pool, startingBalance)
+	eventID := testdb.CreateEvent(t, pool, "0.4", "0.6")
+	testdb.PlaceBet(t, pool, winner, eventID, "yes", 100, 0.4)
+	testdb.PlaceBet(t, pool, loser, eventID, "No", 200, 0.6)
+
+	if _, err := pool.Exec(context.Background(), `
+		UPDATE events SET status = 'resolved', resolved_outcome = 'Yes', resolved_at = NOW() WHERE id = $1
+	`, eventID); err != nil {
+		t.Fatal(err)
+	}
+	outcome := "Yes"
+	return &model.Event{ID: eventID, Question: "Will the test pass?", ResolvedOutcome: &outcome}, winner, loser
+}
+
+func balances(t *testing.T, pool *pgxpool.Pool, userID string) (balance, frozen int64) {
+	t.Helper()
+	if err := pool.QueryRow(context.Background(),
+		`SELECT balance, frozen_balance FROM users WHERE id = $1`, userID,
+	).Scan(&balance, &frozen); err != nil {
+		t.Fatal(err)
+	}
+	return balance, frozen
+}
+
+func checkSettled(t *testing.T, pool *pgxpool.Pool, event *model.Event, winner, loser string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var settlements int
+	var totalBets int
+	var totalPayouts int64
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(MAX(total_bets), 0), COALESCE(MAX(total_payouts), 0)
+		FROM settlements WHERE event_id = $1
+	`, event.ID).Scan(&settlements, &totalBets, &totalPayouts); err != nil {
+		t.Fatal(err)
+	}
+	if settlements != 1 || totalBets != 2 || totalPayouts != 250 {
+		t.Errorf("%d settlements for %d bets paying %d; want 1 for 2 paying 250", settlements, totalBets, totalPayouts)
+	}
+
+	if balance, frozen := balances(t, pool, winner); balance != startingBalance-100+250 || frozen != 0 {
+		t.Errorf("winner: balance %d, frozen %d; want %d, 0", balance, frozen, startingBalance-100+250)
+	}
+	if balance, frozen := balances(t, pool, loser); balance != startingBalance-200 || frozen != 0 {
+		t.Errorf("loser: balance %d, frozen %d; want %d, 0", balance, frozen, startingBalance-200)
+	}
+
+	var won, lost int
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = 'won' AND payout = 250),
+		       COUNT(*) FILTER (WHERE status = 'lost' AND payout = 0)
+		FROM bets WHERE event_id = $1
+	`, event.ID).Scan(&won, &lost); err != nil {
+		t.Fatal(err)
+	}
+	if won != 1 || lost != 1 {
+		t.Errorf("%d won and %d lost bets, want 1 and 1", won, lost)
+	}
+	testdb.CheckBalances(t, pool)
+}
+
+func TestSettleEventIsIdempotent(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	s := newSettler(pool)
+	event, winner, loser := resolvedEvent(t, pool)
+
+	for i := 0; i < 2; i++ {
+		if err := s.settleEvent(ctx, event); err != nil {
+			t.Fatalf("settle %d: %v", i+1, err)
+		}
+	}
+	checkSettled(t, pool, event, winner, loser)
+}
+
+func TestSettleEventConcurrently(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	event, winner, loser := resolvedEvent(t, pool)
+
+	// Two settlers racing for the same event must pay out once. The loser of
+	// the race may fail on the settlements unique key, which is fine.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newSettler(pool).settleEvent(ctx, event)
+		}()
+	}
+	wg.Wait()
+	checkSettled(t, pool, event, winner, loser)
+}
+
+func TestRunRecalculatesRankings(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	s := newSettler(pool)
+	event, winner, loser := resolvedEvent(t, pool)
+
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkSettled(t, pool, event, winner, loser)
+
+	// Recalculating again must replace the rankings, not add to them.
+	if err := s.repo.RecalculateRankings(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT user_id, period, total_assets, total_profit, win_count, loss_count, rank_position
+		FROM rankings WHERE category IS NULL
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type ranking struct {
+		assets, profit int64
+		wins, losses   int
+		position       int
+	}
+	got := make(map[string]ranking)
+	for rows.Next() {
+		var user, period string
+		var r ranking
+		if err := rows.Scan(&user, &period, &r.assets, &r.profit, &r.wins, &r.losses, &r.position); err != nil {
+			t.Fatal(err)
+		}
+		key := period + "/" + user
+		if _, dup := got[key]; dup {
+			t.Errorf("duplicate %s ranking for %s", period, user)
+		}
+		got[key] = r
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]ranking{
+		"all_time/" + winner: {assets: 1150, profit: 150, wins: 1, position: 1},
+		"all_time/" + loser:  {assets: 800, profit: -200, losses: 1, position: 2},
+		"weekly/" + winner:   {assets: 250, profit: 150, wins: 1, position: 1},
+		"weekly/" + loser:    {assets: 0, profit: -200, losses: 1, position: 2},
+		"monthly/" + winner:  {assets: 250, profit: 150, wins: 1, position: 1},
+		"monthly/" + loser:   {assets: 0, profit: -200, losses: 1, position: 2},
+	}
+	for key, w := range want {
+		g, ok := got[key]
+		if !ok {
+			t.Errorf("missing ranking %s", key)
+			continue
+		}
+		if g != w {
+			t.Errorf("ranking %s = %+v, want %+v", key, g, w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("%d rankings, want %d", len(got), len(want))
+	}
+}
+
+func TestRunSettlesVoidAndSplit(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	s := newSettler(pool)
+	yes := testdb.CreateUser(t, pool, startingBalance)
+	no := testdb.CreateUser(t, pool, startingBalance)
+
+	// The voided event refunds both stakes. The split one pays 100 / 0.4 *
+	// 0.5 = 125 on Yes, won, and 200 / 0.6 * 0.5 = 167 on No, lost.
+	voided := testdb.CreateEvent(t, pool, "0.4", "0.6")
+	testdb.PlaceBet(t, pool, yes, voided, "Yes", 100, 0.4)
+	testdb.PlaceBet(t, pool, no, voided, "No", 200, 0.6)
+	split := testdb.CreateEvent(t, pool, "0.4", "0.6")
+	testdb.PlaceBet(t, pool, yes, split, "Yes", 100, 0.4)
+	testdb.PlaceBet(t, pool, no, split, "no", 200, 0.6)
+
+	for id, outcome := range map[string]string{voided: model.ResolutionVoid, split: model.ResolutionSplit} {
+		if _, err := pool.Exec(ctx, `
+			UPDATE events
+			SET status = 'resolved', resolved_outcome = $2, outcome_prices = '["0.5", "0.5"]', resolved_at = NOW()
+			WHERE id = $1
+		`, id, outcome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance, frozen := balances(t, pool, yes); balance != startingBalance-100+125 || frozen != 0 {
+		t.Errorf("yes: balance %d, frozen %d; want %d, 0", balance, frozen, startingBalance-100+125)
+	}
+	if balance, frozen := balances(t, pool, no); balance != startingBalance-200+167 || frozen != 0 {
+		t.Errorf("no: balance %d, frozen %d; want %d, 0", balance, frozen, startingBalance-200+167)
+	}
+
+	var refunded, won, lost int
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = 'refunded' AND payout = amount AND event_id = $1),
+		       COUNT(*) FILTER (WHERE status = 'won' AND payout = 125 AND event_id = $2),
+		       COUNT(*) FILTER (WHERE status = 'lost' AND payout = 167 AND event_id = $2)
+		FROM bets
+	`, voided, split).Scan(&refunded, &won, &lost); err != nil {
+		t.Fatal(err)
+	}
+	if refunded != 2 || won != 1 || lost != 1 {
+		t.Errorf("%d refunded, %d won and %d lost bets; want 2, 1 and 1", refunded, won, lost)
+	}
+
+	// Refunded bets no longer count as bets, so each user is left with the
+	// one on the split event.
+	var totalBets int
+	if err := pool.QueryRow(ctx,
+		`SELECT SUM(total_bets) FROM users WHERE id IN ($1, $2)`, yes, no,
+	).Scan(&totalBets); err != nil {
+		t.Fatal(err)
+	}
+	if totalBets != 2 {
+		t.Errorf("%d bets counted, want 2", totalBets)
+	}
+	testdb.CheckBalances(t, pool)
+}
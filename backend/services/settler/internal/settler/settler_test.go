@@ -0,0 +1,266 @@
+package settler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+func TestSettleBets(t *testing.T) {
+	bet := func(id, outcome string, payout int64) *model.Bet {
+		return &model.Bet{ID: id, Outcome: outcome, Amount: 100, LockedOdds: 100 / float64(payout), PotentialPayout: payout}
+	}
+	split := func(prices map[string]float64) model.Resolution {
+		return model.Resolution{Outcome: model.ResolutionSplit, Prices: prices}
+	}
+
+	tests := []struct {
+		name        string
+		bets        []*model.Bet
+		res         model.Resolution
+		wantWinners []string
+		wantRefunds []string
+		wantPayouts int64
+	}{
+		{
+			name:        "winners and losers",
+			bets:        []*model.Bet{bet("a", "Yes", 250), bet("b", "No", 160), bet("c", "Yes", 125)},
+			res:         model.Resolution{Outcome: "Yes"},
+			wantWinners: []string{"a", "c"},
+			wantPayouts: 375,
+		},
+		{
+			name:        "outcomes compare case-insensitively",
+			bets:        []*model.Bet{bet("a", "yes", 250), bet("b", "NO", 160)},
+			res:         model.Resolution{Outcome: "Yes"},
+			wantWinners: []string{"a"},
+			wantPayouts: 250,
+		},
+		{
+			name: "nobody won",
+			bets: []*model.Bet{bet("a", "Yes", 250), bet("b", "Yes", 250)},
+			res:  model.Resolution{Outcome: "No"},
+		},
+		{
+			name: "empty outcome wins nothing",
+			bets: []*model.Bet{bet("a", "", 250)},
+			res:  model.Resolution{},
+		},
+		{
+			name: "no bets",
+			res:  model.Resolution{Outcome: "Yes"},
+		},
+		{
+			name:        "multi-outcome market",
+			bets:        []*model.Bet{bet("a", "Real Madrid", 400), bet("b", "Arsenal", 300), bet("c", "real madrid", 200)},
+			res:         model.Resolution{Outcome: "Real Madrid"},
+			wantWinners: []string{"a", "c"},
+			wantPayouts: 600,
+		},
+		{
+			name:        "void refunds every stake",
+			bets:        []*model.Bet{bet("a", "Yes", 250), bet("b", "No", 160)},
+			res:         model.Resolution{Outcome: model.ResolutionVoid},
+			wantRefunds: []string{"a", "b"},
+			wantPayouts: 200,
+		},
+		{
+			// 400 shares at 0.5 win, 160 shares at 0.5 lose but pay 80.
+			name:        "50/50 split",
+			bets:        []*model.Bet{bet("a", "Yes", 400), bet("b", "no", 160)},
+			res:         split(map[string]float64{"yes": 0.5, "no": 0.5}),
+			wantWinners: []string{"a"},
+			wantPayouts: 280,
+		},
+		{
+			name:        "uneven split",
+			bets:        []*model.Bet{bet("a", "Yes", 200), bet("b", "No", 200)},
+			res:         split(map[string]float64{"yes": 0.75, "no": 0.25}),
+			wantWinners: []string{"a"},
+			wantPayouts: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, payouts := settleBets(tt.bets, tt.res)
+			if payouts != tt.wantPayouts {
+				t.Errorf("total payouts %d, want %d", payouts, tt.wantPayouts)
+			}
+			if len(results) != len(tt.bets) {
+				t.Errorf("%d results for %d bets", len(results), len(tt.bets))
+			}
+
+			want := make(map[string]model.BetStatus)
+			for _, id := range tt.wantWinners {
+				want[id] = model.BetStatusWon
+			}
+			for _, id := range tt.wantRefunds {
+				want[id] = model.BetStatusRefunded
+			}
+			for _, b := range tt.bets {
+				status, ok := want[b.ID]
+				if !ok {
+					status = model.BetStatusLost
+				}
+				if got := results[b.ID].status; got != status {
+					t.Errorf("bet %s %s, want %s", b.ID, got, status)
+				}
+			}
+		})
+	}
+}
+
+// fakeTransactor runs fn without a database and records whether the
+// transaction would have been committed.
+type fakeTransactor struct {
+	committed bool
+}
+
+func (f *fakeTransactor) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if err := fn(nil); err != nil {
+		return err
+	}
+	f.committed = true
+	return nil
+}
+
+// fakeRepository serves one event's pending bets and records the writes a
+// settlement makes, in order. Methods the tests do not use panic through the
+// nil embedded interface.
+type fakeRepository struct {
+	Repository
+	settled bool
+	bets    []*model.Bet
+	failBet string
+	writes  []string
+}
+
+func (f *fakeRepository) IsSettled(ctx context.Context, tx pgx.Tx, eventID string) (bool, error) {
+	return f.settled, nil
+}
+
+func (f *fakeRepository) LockPendingBets(ctx context.Context, tx pgx.Tx, eventID string) ([]*model.Bet, error) {
+	return f.bets, nil
+}
+
+func (f *fakeRepository) SettleBet(ctx context.Context, tx pgx.Tx, bet *model.Bet, status model.BetStatus, payout int64, question string) error {
+	if bet.ID == f.failBet {
+		return errBoom
+	}
+	f.writes = append(f.writes, fmt.Sprintf("bet %s %s %d", bet.ID, status, payout))
+	return nil
+}
+
+func (f *fakeRepository) PayReferralBonuses(ctx context.Context, tx pgx.Tx, userIDs []string) error {
+	f.writes = append(f.writes, "referrals "+strings.Join(userIDs, ","))
+	return nil
+}
+
+func (f *fakeRepository) Create(ctx context.Context, tx pgx.Tx, eventID, outcome string, totalBets int, totalPayouts int64) error {
+	f.writes = append(f.writes, fmt.Sprintf("settlement %s %s %d %d", eventID, outcome, totalBets, totalPayouts))
+	return nil
+}
+
+var errBoom = errors.New("boom")
+
+func TestSettleEvent(t *testing.T) {
+	bets := func() []*model.Bet {
+		return []*model.Bet{
+			{ID: "a", UserID: "alice", Outcome: "yes", Amount: 100, LockedOdds: 0.4, PotentialPayout: 250},
+			{ID: "b", UserID: "bob", Outcome: "No", Amount: 200, LockedOdds: 0.6, PotentialPayout: 333},
+		}
+	}
+	event := func(outcome string) *model.Event {
+		return &model.Event{
+			ID:              "e1",
+			Question:        "Will it settle?",
+			Outcomes:        []byte(`["Yes", "No"]`),
+			OutcomePrices:   []byte(`["0.5", "0.5"]`),
+			ResolvedOutcome: &outcome,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		outcome    string
+		settled    bool
+		failBet    string
+		wantErr    error
+		wantWrites []string
+	}{
+		{
+			name:    "pays winners, then referral bonuses, then records the settlement",
+			outcome: "Yes",
+			wantWrites: []string{
+				"bet a won 250",
+				"bet b lost 0",
+				"referrals alice,bob",
+				"settlement e1 Yes 2 250",
+			},
+		},
+		{
+			name:    "void refunds every stake",
+			outcome: model.ResolutionVoid,
+			wantWrites: []string{
+				"bet a refunded 100",
+				"bet b refunded 200",
+				"referrals alice,bob",
+				"settlement e1 void 2 300",
+			},
+		},
+		{
+			// 250 yes shares and 333.3 no shares, each paid 0.5 and rounded.
+			name:    "split pays both sides",
+			outcome: model.ResolutionSplit,
+			wantWrites: []string{
+				"bet a won 125",
+				"bet b lost 167",
+				"referrals alice,bob",
+				"settlement e1 split 2 292",
+			},
+		},
+		{
+			name:    "already settled event is skipped",
+			outcome: "Yes",
+			settled: true,
+		},
+		{
+			name:    "failed bet rolls back the settlement",
+			outcome: "Yes",
+			failBet: "b",
+			wantErr: errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTransactor{}
+			repo := &fakeRepository{settled: tt.settled, bets: bets(), failBet: tt.failBet}
+
+			err := New(tx, repo).settleEvent(context.Background(), event(tt.outcome))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("settleEvent() error = %v, want %v", err, tt.wantErr)
+				}
+				if tx.committed {
+					t.Error("failed settlement was committed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("settleEvent() error = %v", err)
+			}
+
+			if got, want := strings.Join(repo.writes, "; "), strings.Join(tt.wantWrites, "; "); got != want {
+				t.Errorf("writes %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Command migrate applies and rolls back the embedded schema migrations.
+//
+//	migrate up                apply every pending migration
+//	migrate down [N]          roll back the last N migrations (default 1)
+//	migrate to VERSION        migrate up or down to VERSION (0 rolls back everything)
+//	migrate status            list migrations and when they were applied
+//	migrate baseline VERSION  record migrations up to VERSION as applied
+//	                          without running them, for databases created
+//	                          before versions were tracked
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/poly-predict/backend/migrations"
+	"github.com/poly-predict/backend/pkg/config"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/pkg/migrate"
+)
+
+const usage = `usage: migrate up | down [N] | to VERSION | status | baseline VERSION`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(config.ServiceTools)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load config")
+	}
+	logging.Setup("migrate", cfg.Environment, cfg.LogLevel)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to database")
+	}
+	defer db.Close(pool)
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load migrations")
+	}
+
+	if err := run(ctx, m, os.Args[1], os.Args[2:]); err != nil {
+		log.Error().Err(err).Str("command", os.Args[1]).Msg("migrate failed")
+		db.Close(pool)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, m *migrate.Migrator, cmd string, args []string) error {
+	var n int
+	var err error
+
+	switch cmd {
+	case "up":
+		n, err = m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("down: N must be a positive number, got %q", args[0])
+			}
+		}
+		n, err = m.Down(ctx, steps)
+	case "to", "baseline":
+		if len(args) != 1 {
+			return fmt.Errorf("%s: VERSION is required", cmd)
+		}
+		version, perr := strconv.ParseInt(args[0], 10, 64)
+		if perr != nil || version < 0 {
+			return fmt.Errorf("%s: VERSION must be a migration number, got %q", cmd, args[0])
+		}
+		if cmd == "to" {
+			n, err = m.To(ctx, version)
+		} else {
+			n, err = m.Baseline(ctx, version)
+		}
+	case "status":
+		return printStatus(ctx, m)
+	default:
+		return fmt.Errorf("unknown command %q; %s", cmd, usage)
+	}
+
+	if err != nil {
+		return err
+	}
+	log.Info().Int("migrations", n).Msg(cmd + " complete")
+	return nil
+}
+
+func printStatus(ctx context.Context, m *migrate.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		name := s.Name
+		if s.Missing {
+			name = "(no migration file)"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\n", s.Version, name, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,113 @@
+// Command seed fills a freshly migrated database with demo data and creates
+// an admin account, so the frontends and docs can be worked on without a
+// live Polymarket sync.
+//
+//	seed                     generate demo data and an admin account
+//	seed -admin-only         only create the admin account (or reset its password)
+//
+// The admin password is taken from -admin-password or SEED_ADMIN_PASSWORD,
+// and resets the password of an existing account. If neither is set a random
+// one is generated and printed for a new account, and an existing account
+// keeps its password.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/poly-predict/backend/pkg/config"
+	"github.com/poly-predict/backend/pkg/db"
+	"github.com/poly-predict/backend/pkg/logging"
+	"github.com/poly-predict/backend/tools/internal/seed"
+)
+
+func main() {
+	users := flag.Int("users", 300, "number of demo users")
+	seedValue := flag.Uint64("seed", 1, "random seed; the same seed produces the same data")
+	adminEmail := flag.String("admin-email", "admin@polypredict.local", "admin account email")
+	adminPassword := flag.String("admin-password", os.Getenv("SEED_ADMIN_PASSWORD"), "admin account password")
+	adminOnly := flag.Bool("admin-only", false, "only create the admin account")
+	flag.Parse()
+
+	cfg, err := config.Load(config.ServiceTools)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load config")
+	}
+	logging.Setup("seed", cfg.Environment, cfg.LogLevel)
+
+	if cfg.IsProduction() && !*adminOnly {
+		log.Fatal().Msg("refusing to load demo data in production; use -admin-only to create an admin account")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to database")
+	}
+	defer db.Close(pool)
+
+	if !*adminOnly {
+		start := time.Now()
+		data := seed.Generate(seed.Options{
+			Users:           *users,
+			Seed:            *seedValue,
+			StartingBalance: cfg.StartingBalance,
+			Now:             start,
+		})
+		if err := data.Insert(ctx, pool); err != nil {
+			if errors.Is(err, seed.ErrNotEmpty) {
+				log.Error().Msg(err.Error())
+			} else {
+				log.Error().Err(err).Msg("failed to insert demo data")
+			}
+			db.Close(pool)
+			os.Exit(1)
+		}
+		log.Info().
+			Int("events", len(data.Events)).
+			Int("price_points", len(data.Prices)).
+			Int("users", len(data.Users)).
+			Int("bets", len(data.Bets)).
+			Int("settlements", len(data.Settlements)).
+			Int("transactions", len(data.Transactions)).
+			Dur("elapsed", time.Since(start)).
+			Msg("demo data inserted")
+	}
+
+	password := *adminPassword
+	generated := password == ""
+	if generated {
+		b := make([]byte, 12)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatal().Err(err).Msg("failed to generate admin password")
+		}
+		password = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	created, err := seed.CreateAdmin(ctx, pool, *adminEmail, password, !generated)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create admin account")
+		db.Close(pool)
+		os.Exit(1)
+	}
+	if !created && generated {
+		log.Info().Str("email", *adminEmail).Msg("admin account exists, password left unchanged; pass -admin-password to reset it")
+		return
+	}
+	log.Info().Str("email", *adminEmail).Bool("created", created).Msg("admin account ready")
+	if generated {
+		fmt.Printf("admin login: %s / %s\n", *adminEmail, password)
+	}
+}
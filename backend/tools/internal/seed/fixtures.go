@@ -0,0 +1,208 @@
+package seed
+
+// groupFixture is a synthetic Polymarket event: a titled group of yes/no
+// markets sharing a category, such as every candidate in one election.
+type groupFixture struct {
+	title    string
+	category string
+	tags     []string
+	markets  []string
+}
+
+var groupFixtures = []groupFixture{
+	{
+		title:    "2028 Presidential Election Winner",
+		category: "Politics",
+		tags:     []string{"Politics", "Elections", "US"},
+		markets: []string{
+			"Will Gavin Newsom win the 2028 US Presidential Election?",
+			"Will JD Vance win the 2028 US Presidential Election?",
+			"Will Josh Shapiro win the 2028 US Presidential Election?",
+			"Will Marco Rubio win the 2028 US Presidential Election?",
+			"Will Alexandria Ocasio-Cortez win the 2028 US Presidential Election?",
+		},
+	},
+	{
+		title:    "Which party will control the House after the midterms?",
+		category: "Politics",
+		tags:     []string{"Politics", "Elections", "US"},
+		markets: []string{
+			"Will Democrats win the House in the midterms?",
+			"Will Republicans win the House in the midterms?",
+		},
+	},
+	{
+		title:    "UK Politics",
+		category: "Politics",
+		tags:     []string{"Politics", "UK"},
+		markets: []string{
+			"Will the UK hold a general election before 2027?",
+			"Will Reform UK lead in a YouGov poll this month?",
+		},
+	},
+	{
+		title:    "Government shutdown",
+		category: "Politics",
+		tags:     []string{"Politics", "US"},
+		markets:  []string{"Will the US government shut down before the end of the year?"},
+	},
+	{
+		title:    "Bitcoin price at the end of the month",
+		category: "Crypto",
+		tags:     []string{"Crypto", "Bitcoin"},
+		markets: []string{
+			"Will Bitcoin close the month above $100,000?",
+			"Will Bitcoin close the month above $120,000?",
+			"Will Bitcoin close the month above $150,000?",
+		},
+	},
+	{
+		title:    "Ethereum price at the end of the month",
+		category: "Crypto",
+		tags:     []string{"Crypto", "Ethereum"},
+		markets: []string{
+			"Will Ethereum close the month above $3,000?",
+			"Will Ethereum close the month above $5,000?",
+		},
+	},
+	{
+		title:    "Spot Solana ETF",
+		category: "Crypto",
+		tags:     []string{"Crypto", "ETFs"},
+		markets:  []string{"Will a spot Solana ETF be approved this year?"},
+	},
+	{
+		title:    "Stablecoin legislation",
+		category: "Crypto",
+		tags:     []string{"Crypto", "Regulation"},
+		markets:  []string{"Will the Senate pass a stablecoin bill this quarter?"},
+	},
+	{
+		title:    "Champions League Winner",
+		category: "Sports",
+		tags:     []string{"Sports", "Soccer"},
+		markets: []string{
+			"Will Real Madrid win the Champions League?",
+			"Will Manchester City win the Champions League?",
+			"Will Arsenal win the Champions League?",
+			"Will Bayern Munich win the Champions League?",
+		},
+	},
+	{
+		title:    "NBA Finals",
+		category: "Sports",
+		tags:     []string{"Sports", "NBA"},
+		markets: []string{
+			"Will the Boston Celtics win the NBA Finals?",
+			"Will the Oklahoma City Thunder win the NBA Finals?",
+			"Will the Denver Nuggets win the NBA Finals?",
+		},
+	},
+	{
+		title:    "Super Bowl Champion",
+		category: "Sports",
+		tags:     []string{"Sports", "NFL"},
+		markets: []string{
+			"Will the Kansas City Chiefs win the Super Bowl?",
+			"Will the Philadelphia Eagles win the Super Bowl?",
+		},
+	},
+	{
+		title:    "Wimbledon Men's Singles",
+		category: "Sports",
+		tags:     []string{"Sports", "Tennis"},
+		markets: []string{
+			"Will Carlos Alcaraz win Wimbledon?",
+			"Will Jannik Sinner win Wimbledon?",
+		},
+	},
+	{
+		title:    "Fed decision at the next meeting",
+		category: "Economics",
+		tags:     []string{"Economics", "Fed"},
+		markets: []string{
+			"Will the Fed cut rates by 25 bps at the next meeting?",
+			"Will the Fed hold rates at the next meeting?",
+		},
+	},
+	{
+		title:    "US recession",
+		category: "Economics",
+		tags:     []string{"Economics", "US"},
+		markets:  []string{"Will the US enter a recession this year?"},
+	},
+	{
+		title:    "US inflation",
+		category: "Economics",
+		tags:     []string{"Economics", "Inflation"},
+		markets: []string{
+			"Will US CPI inflation come in above 3% this month?",
+			"Will US unemployment reach 5% this year?",
+		},
+	},
+	{
+		title:    "SpaceX Starship",
+		category: "Science",
+		tags:     []string{"Science", "Space"},
+		markets: []string{
+			"Will Starship reach orbit on its next flight?",
+			"Will SpaceX catch a Starship upper stage this year?",
+		},
+	},
+	{
+		title:    "AI model releases",
+		category: "Science",
+		tags:     []string{"Science", "AI"},
+		markets: []string{
+			"Will OpenAI release GPT-6 this year?",
+			"Will an AI model top the LMArena leaderboard for a full month?",
+		},
+	},
+	{
+		title:    "Hottest year on record",
+		category: "Science",
+		tags:     []string{"Science", "Climate"},
+		markets:  []string{"Will this year be the hottest on record?"},
+	},
+	{
+		title:    "Oscars Best Picture",
+		category: "Culture",
+		tags:     []string{"Culture", "Movies"},
+		markets: []string{
+			"Will a sequel win Best Picture at the Oscars?",
+			"Will an animated film be nominated for Best Picture?",
+		},
+	},
+	{
+		title:    "Taylor Swift",
+		category: "Culture",
+		tags:     []string{"Culture", "Music"},
+		markets: []string{
+			"Will Taylor Swift announce a new album this month?",
+			"Will Taylor Swift top the Billboard Hot 100 this year?",
+		},
+	},
+	{
+		title:    "Top grossing movie of the summer",
+		category: "Culture",
+		tags:     []string{"Culture", "Movies"},
+		markets:  []string{"Will a superhero film be the top grossing movie of the summer?"},
+	},
+}
+
+// Word lists for generated users, e.g. handle "swift_otter42" with display
+// name "Swift Otter".
+var (
+	adjectives = []string{
+		"bold", "brave", "bright", "calm", "clever", "cosmic", "crafty", "daring",
+		"eager", "fierce", "gentle", "golden", "happy", "humble", "jolly", "keen",
+		"lucky", "mellow", "mighty", "nimble", "quick", "quiet", "rapid", "silent",
+		"sly", "steady", "sunny", "swift", "witty", "zesty",
+	}
+	animals = []string{
+		"badger", "bear", "beaver", "bison", "cobra", "condor", "coyote", "crane",
+		"falcon", "ferret", "fox", "gecko", "hawk", "heron", "ibex", "jaguar",
+		"koala", "lemur", "lynx", "marmot", "moose", "otter", "owl", "panda",
+		"puffin", "raven", "seal", "tiger", "walrus", "wolf",
+	}
+)
@@ -0,0 +1,479 @@
+// Package seed generates demo data for a fresh database: synthetic markets
+// with price history, users with bets in every status, settlements, and a
+// credit ledger that adds up to every user's balance. Generation is
+// deterministic for a given seed and time, so fixtures can be reproduced.
+package seed
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+// Options controls the generated data.
+type Options struct {
+	Users           int
+	Seed            uint64
+	StartingBalance int64
+	Now             time.Time
+}
+
+// Dataset is the generated data, one slice per table.
+type Dataset struct {
+	Groups       []model.MarketGroup
+	Events       []model.Event
+	Prices       []model.PriceHistory
+	Users        []model.User
+	Bets         []model.Bet
+	Settlements  []model.Settlement
+	Transactions []model.CreditTransaction
+}
+
+// market is the simulation state of one event: its price path, sampled
+// hourly from start, and the window in which it accepted bets.
+type market struct {
+	event    *model.Event
+	start    time.Time
+	closeAt  time.Time
+	settleAt *time.Time
+	yesWins  bool
+	path     []float64
+}
+
+// yesPrice returns the Yes price at t.
+func (m *market) yesPrice(t time.Time) float64 {
+	i := int(t.Sub(m.start) / time.Hour)
+	return m.path[max(0, min(i, len(m.path)-1))]
+}
+
+// Generate builds a dataset from opts. Every event starts between 20 and 45
+// days before opts.Now. A third of them are resolved and settled, except
+// the most recently resolved one, which is left for the settler so that its
+// first cycle also builds the leaderboards.
+func Generate(opts Options) *Dataset {
+	g := &generator{
+		opts: opts,
+		rng:  rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed)),
+		now:  opts.Now.UTC().Truncate(time.Minute),
+		data: &Dataset{},
+	}
+	g.markets()
+	g.users()
+	return g.data
+}
+
+type generator struct {
+	opts    Options
+	rng     *rand.Rand
+	now     time.Time
+	data    *Dataset
+	byEvent []*market
+}
+
+// between returns a random duration in [lo, hi).
+func (g *generator) between(lo, hi time.Duration) time.Duration {
+	return lo + time.Duration(g.rng.Int64N(int64(hi-lo)))
+}
+
+func (g *generator) markets() {
+	var total int
+	for _, f := range groupFixtures {
+		total += len(f.markets)
+	}
+
+	// Assign statuses up front so every status is always represented.
+	statuses := make([]model.EventStatus, total)
+	for i, p := range g.rng.Perm(total) {
+		switch {
+		case i < total/3:
+			statuses[p] = model.EventStatusResolved
+		case i < total/3+max(1, total/10):
+			statuses[p] = model.EventStatusClosed
+		default:
+			statuses[p] = model.EventStatusOpen
+		}
+	}
+
+	// Markets point into Events, so it must not grow past its capacity.
+	g.data.Events = make([]model.Event, 0, total)
+	for gi, f := range groupFixtures {
+		groupID := strconv.Itoa(900001 + gi)
+		slug := slugify(f.title)
+		tags, _ := json.Marshal(f.tags)
+		group := model.MarketGroup{
+			ID:          groupID,
+			Slug:        &slug,
+			Title:       f.title,
+			Tags:        tags,
+			MarketCount: len(f.markets),
+		}
+
+		for _, question := range f.markets {
+			m := g.market(groupID, f.category, question, statuses[len(g.data.Events)])
+			group.Volume24h += m.event.Volume24h
+		}
+		g.data.Groups = append(g.data.Groups, group)
+	}
+
+	// Leave the most recently resolved event unsettled.
+	var last *market
+	for _, m := range g.byEvent {
+		if m.settleAt != nil && (last == nil || m.event.ResolvedAt.After(*last.event.ResolvedAt)) {
+			last = m
+		}
+	}
+	if last != nil {
+		last.settleAt = nil
+	}
+}
+
+func (g *generator) market(groupID, category, question string, status model.EventStatus) *market {
+	start := g.now.Add(-g.between(20*24*time.Hour, 45*24*time.Hour)).Truncate(time.Hour)
+	m := &market{start: start, closeAt: g.now}
+
+	var end time.Time
+	var resolvedAt *time.Time
+	switch status {
+	case model.EventStatusOpen:
+		end = g.now.Add(g.between(3*24*time.Hour, 120*24*time.Hour))
+	case model.EventStatusClosed:
+		end = g.now.Add(-g.between(time.Hour, 48*time.Hour))
+		m.closeAt = end
+	case model.EventStatusResolved:
+		end = g.now.Add(-g.between(2*24*time.Hour, 18*24*time.Hour))
+		at := end.Add(g.between(time.Hour, 12*time.Hour))
+		settled := at.Add(g.between(time.Minute, 5*time.Minute))
+		m.closeAt, resolvedAt, m.settleAt = end, &at, &settled
+		m.yesWins = g.rng.Float64() < 0.4
+	}
+
+	m.path = g.pricePath(start, m.closeAt, resolvedAt != nil, m.yesWins)
+	last := m.path[len(m.path)-1]
+
+	prices := []string{formatPrice(last), formatPrice(1 - last)}
+	var resolvedOutcome *string
+	if resolvedAt != nil {
+		outcome := "No"
+		prices = []string{"0", "1"}
+		if m.yesWins {
+			outcome = "Yes"
+			prices = []string{"1", "0"}
+		}
+		resolvedOutcome = &outcome
+	}
+
+	idBytes := make([]byte, 32)
+	for i := range idBytes {
+		idBytes[i] = byte(g.rng.UintN(256))
+	}
+	description := fmt.Sprintf("This market resolves to \"Yes\" if the answer to the question is yes by %s, and to \"No\" otherwise. Demo data, not a real market.",
+		end.Format("January 2, 2006"))
+	categoryCopy := category
+	outcomes, _ := json.Marshal([]string{"Yes", "No"})
+	outcomePrices, _ := json.Marshal(prices)
+
+	volume := math.Round(math.Exp(g.rng.Float64()*6+9)*100) / 100 // ~$8k to ~$3M
+	volume24h := 0.0
+	if status == model.EventStatusOpen {
+		volume24h = math.Round(volume*g.rng.Float64()*0.1*100) / 100
+	}
+
+	g.data.Events = append(g.data.Events, model.Event{
+		ID:                "0x" + hex.EncodeToString(idBytes),
+		PolymarketEventID: groupID,
+		Slug:              slugify(question),
+		Question:          question,
+		Description:       &description,
+		Category:          &categoryCopy,
+		Outcomes:          outcomes,
+		OutcomePrices:     outcomePrices,
+		ClobTokenIDs:      json.RawMessage("[]"),
+		Status:            status,
+		ResolvedOutcome:   resolvedOutcome,
+		ResolvedAt:        resolvedAt,
+		Volume:            volume,
+		Volume24h:         volume24h,
+		Liquidity:         math.Round(volume*(0.02+g.rng.Float64()*0.1)*100) / 100,
+		EndDate:           &end,
+		CreatedAt:         start,
+		UpdatedAt:         m.closeAt,
+		SyncedAt:          g.now,
+	})
+	m.event = &g.data.Events[len(g.data.Events)-1]
+	g.byEvent = append(g.byEvent, m)
+
+	for i, p := range m.path {
+		at := start.Add(time.Duration(i) * time.Hour)
+		g.data.Prices = append(g.data.Prices,
+			model.PriceHistory{EventID: m.event.ID, OutcomeLabel: "Yes", Price: p, RecordedAt: at},
+			model.PriceHistory{EventID: m.event.ID, OutcomeLabel: "No", Price: roundPrice(1 - p), RecordedAt: at},
+		)
+	}
+	return m
+}
+
+// pricePath returns hourly Yes prices from start to end as a random walk in
+// log-odds space. Resolved markets drift towards their outcome over the last
+// third of their life, as real markets do when the answer becomes clear.
+func (g *generator) pricePath(start, end time.Time, resolved, yesWins bool) []float64 {
+	n := int(end.Sub(start)/time.Hour) + 1
+	path := make([]float64, n)
+
+	x := (g.rng.Float64() - 0.5) * 5
+	target := -4.5
+	if yesWins {
+		target = 4.5
+	}
+	for i := range path {
+		x += g.rng.NormFloat64() * 0.06
+		if resolved && i > n*2/3 {
+			x += (target - x) * 0.03
+		}
+		path[i] = roundPrice(1 / (1 + math.Exp(-x)))
+	}
+	return path
+}
+
+// action is a bet settlement or cancellation waiting to be applied.
+type action struct {
+	at  time.Time
+	bet int // index into Dataset.Bets
+}
+
+func (g *generator) users() {
+	handles := make(map[string]bool)
+	g.data.Users = make([]model.User, 0, g.opts.Users)
+
+	for range g.opts.Users {
+		adj := adjectives[g.rng.IntN(len(adjectives))]
+		animal := animals[g.rng.IntN(len(animals))]
+		handle := fmt.Sprintf("%s_%s%d", adj, animal, g.rng.IntN(100))
+		for handles[handle] {
+			handle = fmt.Sprintf("%s_%s%d", adj, animal, g.rng.IntN(1000))
+		}
+		handles[handle] = true
+
+		created := g.now.Add(-g.between(15*24*time.Hour, 50*24*time.Hour))
+		g.data.Users = append(g.data.Users, model.User{
+			ID:          g.uuid(),
+			Handle:      handle,
+			DisplayName: titleCase(adj) + " " + titleCase(animal),
+			Balance:     g.opts.StartingBalance,
+			Level:       1,
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		})
+	}
+
+	for i := range g.data.Users {
+		g.bets(&g.data.Users[i])
+	}
+
+	for _, m := range g.byEvent {
+		if m.settleAt == nil {
+			continue
+		}
+		s := model.Settlement{EventID: m.event.ID, ResolvedOutcome: *m.event.ResolvedOutcome, SettledAt: *m.settleAt}
+		for _, b := range g.data.Bets {
+			if b.EventID == m.event.ID {
+				s.TotalBets++
+				if b.Status == model.BetStatusWon {
+					s.TotalPayouts += *b.Payout
+				}
+			}
+		}
+		g.data.Settlements = append(g.data.Settlements, s)
+	}
+
+	// Insert the ledger in time order so that IDs increase with time.
+	slices.SortStableFunc(g.data.Transactions, func(a, b model.CreditTransaction) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+}
+
+// bets simulates one user's betting history in time order: stakes are only
+// placed when the balance at that moment allows them, and settlements and
+// cancellations are applied as they fall due.
+func (g *generator) bets(u *model.User) {
+	// About one user in seven only browses.
+	if g.rng.IntN(7) == 0 {
+		return
+	}
+	count := 1 + min(int(g.rng.ExpFloat64()*8), 40)
+
+	type placement struct {
+		at     time.Time
+		market *market
+	}
+	var placements []placement
+	for range count {
+		m := g.byEvent[g.rng.IntN(len(g.byEvent))]
+		from := u.CreatedAt
+		if m.start.After(from) {
+			from = m.start
+		}
+		if !from.Before(m.closeAt) {
+			continue
+		}
+		placements = append(placements, placement{at: from.Add(g.between(0, m.closeAt.Sub(from))), market: m})
+	}
+	slices.SortFunc(placements, func(a, b placement) int { return a.at.Compare(b.at) })
+
+	var due []action
+	applyDue := func(until time.Time) {
+		for {
+			i := -1
+			for j, a := range due {
+				if !a.at.After(until) && (i == -1 || a.at.Before(due[i].at)) {
+					i = j
+				}
+			}
+			if i == -1 {
+				return
+			}
+			a := due[i]
+			due = slices.Delete(due, i, i+1)
+			g.resolveBet(u, a)
+		}
+	}
+
+	for _, p := range placements {
+		applyDue(p.at)
+
+		if u.Balance < 10 {
+			continue
+		}
+		amount := int64(float64(u.Balance)*(0.02+g.rng.Float64()*0.13)) / 10 * 10
+		amount = max(10, min(amount, 1000, u.Balance))
+
+		yes := p.market.yesPrice(p.at)
+		outcome, odds := "No", roundPrice(1-yes)
+		if g.rng.Float64() < 0.5+(yes-0.5)/2 {
+			outcome, odds = "Yes", yes
+		}
+
+		bet := model.Bet{
+			ID:              g.uuid(),
+			UserID:          u.ID,
+			EventID:         p.market.event.ID,
+			Outcome:         outcome,
+			Amount:          amount,
+			LockedOdds:      odds,
+			PotentialPayout: int64(float64(amount) / odds),
+			Status:          model.BetStatusPending,
+			CreatedAt:       p.at,
+		}
+		g.data.Bets = append(g.data.Bets, bet)
+		idx := len(g.data.Bets) - 1
+
+		u.Balance -= amount
+		u.FrozenBalance += amount
+		u.TotalBets++
+		g.record(u, "bet_placed", -amount, bet.ID, "Bet placed on event "+bet.EventID, p.at)
+
+		switch {
+		case p.market.settleAt != nil:
+			due = append(due, action{at: *p.market.settleAt, bet: idx})
+		case p.market.event.Status == model.EventStatusOpen && g.rng.IntN(25) == 0:
+			if at := p.at.Add(g.between(10*time.Minute, 48*time.Hour)); at.Before(g.now) {
+				due = append(due, action{at: at, bet: idx})
+			}
+		}
+	}
+	applyDue(g.now)
+}
+
+// resolveBet settles or cancels a bet, mirroring the settler's ledger
+// entries: winners are paid their potential payout, losers get a zero-amount
+// entry, and cancelled stakes are refunded.
+func (g *generator) resolveBet(u *model.User, a action) {
+	bet := &g.data.Bets[a.bet]
+	m := g.marketFor(bet.EventID)
+	at := a.at
+	bet.SettledAt = &at
+	u.FrozenBalance -= bet.Amount
+
+	switch {
+	case m.event.Status == model.EventStatusOpen:
+		payout := bet.Amount
+		bet.Status, bet.Payout = model.BetStatusCancelled, &payout
+		u.Balance += bet.Amount
+		g.record(u, "bet_refund", bet.Amount, bet.ID, "Bet cancelled - stake refunded", at)
+	case strings.EqualFold(bet.Outcome, *m.event.ResolvedOutcome):
+		payout := bet.PotentialPayout
+		bet.Status, bet.Payout = model.BetStatusWon, &payout
+		u.Balance += payout
+		u.TotalWins++
+		u.CurrentStreak++
+		u.MaxStreak = max(u.MaxStreak, u.CurrentStreak)
+		g.record(u, "bet_won", payout, bet.ID, "Won bet on: "+m.event.Question, at)
+	default:
+		var payout int64
+		bet.Status, bet.Payout = model.BetStatusLost, &payout
+		u.CurrentStreak = 0
+		g.record(u, "bet_lost", 0, bet.ID, "Lost bet on: "+m.event.Question, at)
+	}
+}
+
+func (g *generator) record(u *model.User, typ string, amount int64, ref, desc string, at time.Time) {
+	g.data.Transactions = append(g.data.Transactions, model.CreditTransaction{
+		UserID:       u.ID,
+		Type:         typ,
+		Amount:       amount,
+		BalanceAfter: u.Balance,
+		ReferenceID:  &ref,
+		Description:  &desc,
+		CreatedAt:    at,
+	})
+	u.UpdatedAt = at
+}
+
+func (g *generator) marketFor(eventID string) *market {
+	for _, m := range g.byEvent {
+		if m.event.ID == eventID {
+			return m
+		}
+	}
+	panic("seed: unknown event " + eventID)
+}
+
+// uuid returns a random version 4 UUID drawn from the generator's source.
+func (g *generator) uuid() string {
+	var id uuid.UUID
+	for i := range id {
+		id[i] = byte(g.rng.UintN(256))
+	}
+	id[6] = id[6]&0x0f | 0x40 // version 4
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return id.String()
+}
+
+// roundPrice keeps prices within Polymarket's tick range and precision.
+func roundPrice(p float64) float64 {
+	return math.Round(max(0.001, min(p, 0.999))*1000) / 1000
+}
+
+func formatPrice(p float64) string {
+	return strconv.FormatFloat(roundPrice(p), 'f', -1, 64)
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+func titleCase(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,86 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/poly-predict/backend/pkg/model"
+)
+
+var testOptions = Options{
+	Users:           200,
+	Seed:            7,
+	StartingBalance: 10000,
+	Now:             time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC),
+}
+
+// TestGenerateLedgerBalances checks that every user's ledger replays to their
+// balance and that frozen credits match their pending stakes.
+func TestGenerateLedgerBalances(t *testing.T) {
+	d := Generate(testOptions)
+
+	balance := make(map[string]int64)
+	for _, tx := range d.Transactions {
+		if _, ok := balance[tx.UserID]; !ok {
+			balance[tx.UserID] = testOptions.StartingBalance
+		}
+		balance[tx.UserID] += tx.Amount
+		if balance[tx.UserID] != tx.BalanceAfter {
+			t.Fatalf("transaction %s for %s: balance_after %d, ledger says %d", tx.Type, tx.UserID, tx.BalanceAfter, balance[tx.UserID])
+		}
+		if balance[tx.UserID] < 0 {
+			t.Fatalf("user %s went negative", tx.UserID)
+		}
+	}
+
+	frozen := make(map[string]int64)
+	for _, b := range d.Bets {
+		if b.Status == model.BetStatusPending {
+			frozen[b.UserID] += b.Amount
+		}
+	}
+	for _, u := range d.Users {
+		want := testOptions.StartingBalance
+		if b, ok := balance[u.ID]; ok {
+			want = b
+		}
+		if u.Balance != want || u.FrozenBalance != frozen[u.ID] {
+			t.Errorf("user %s: balance %d/%d frozen, ledger says %d/%d", u.Handle, u.Balance, u.FrozenBalance, want, frozen[u.ID])
+		}
+	}
+}
+
+func TestGenerateCoversEveryStatus(t *testing.T) {
+	d := Generate(testOptions)
+
+	events := make(map[model.EventStatus]int)
+	for _, e := range d.Events {
+		events[e.Status]++
+	}
+	for _, s := range []model.EventStatus{model.EventStatusOpen, model.EventStatusClosed, model.EventStatusResolved} {
+		if events[s] == 0 {
+			t.Errorf("no %s events", s)
+		}
+	}
+
+	bets := make(map[model.BetStatus]int)
+	for _, b := range d.Bets {
+		bets[b.Status]++
+	}
+	for _, s := range []model.BetStatus{model.BetStatusPending, model.BetStatusWon, model.BetStatusLost, model.BetStatusCancelled} {
+		if bets[s] == 0 {
+			t.Errorf("no %s bets", s)
+		}
+	}
+
+	if len(d.Settlements) != events[model.EventStatusResolved]-1 {
+		t.Errorf("expected all but one resolved event settled, got %d of %d", len(d.Settlements), events[model.EventStatusResolved])
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	if !reflect.DeepEqual(Generate(testOptions), Generate(testOptions)) {
+		t.Error("the same options produced different data")
+	}
+}
@@ -0,0 +1,212 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrNotEmpty is returned by Insert when the database already has events or
+// users, since demo data must never be mixed into real data.
+var ErrNotEmpty = errors.New("database already has events or users; seed only a freshly migrated database")
+
+// Insert writes d in a single transaction.
+func (d *Dataset) Insert(ctx context.Context, pool *pgxpool.Pool) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		var populated bool
+		if err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM events) OR EXISTS (SELECT 1 FROM users)`,
+		).Scan(&populated); err != nil {
+			return fmt.Errorf("check for existing data: %w", err)
+		}
+		if populated {
+			return ErrNotEmpty
+		}
+
+		// COPY needs the enum types registered to encode them.
+		for _, name := range []string{"event_status", "bet_status"} {
+			t, err := tx.Conn().LoadType(ctx, name)
+			if err != nil {
+				return fmt.Errorf("load type %s: %w", name, err)
+			}
+			tx.Conn().TypeMap().RegisterType(t)
+		}
+
+		if err := d.ensurePartitions(ctx, tx); err != nil {
+			return err
+		}
+
+		for _, table := range d.tables() {
+			n, err := tx.CopyFrom(ctx, pgx.Identifier{table.name}, table.columns, table.rows)
+			if err != nil {
+				return fmt.Errorf("copy %s: %w", table.name, err)
+			}
+			if int(n) != table.rows.len {
+				return fmt.Errorf("copy %s: wrote %d of %d rows", table.name, n, table.rows.len)
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE events SET search_vector =
+				setweight(to_tsvector('english', COALESCE(question, '')), 'A') ||
+				setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+				setweight(to_tsvector('english', COALESCE(category, '')), 'C')
+		`)
+		if err != nil {
+			return fmt.Errorf("index events for search: %w", err)
+		}
+		return nil
+	})
+}
+
+// ensurePartitions creates the monthly price_history partitions covering the
+// generated price points, named and bounded as the scraper names them.
+func (d *Dataset) ensurePartitions(ctx context.Context, tx pgx.Tx) error {
+	if len(d.Prices) == 0 {
+		return nil
+	}
+	first, last := d.Prices[0].RecordedAt, d.Prices[0].RecordedAt
+	for _, p := range d.Prices {
+		if p.RecordedAt.Before(first) {
+			first = p.RecordedAt
+		}
+		if p.RecordedAt.After(last) {
+			last = p.RecordedAt
+		}
+	}
+
+	month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(last) {
+		next := month.AddDate(0, 1, 0)
+		query := fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF price_history FOR VALUES FROM ('%s') TO ('%s')`,
+			pgx.Identifier{"price_history_p" + month.Format("200601")}.Sanitize(),
+			month.Format(time.RFC3339), next.Format(time.RFC3339),
+		)
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return fmt.Errorf("create price_history partition for %s: %w", month.Format("2006-01"), err)
+		}
+		month = next
+	}
+	return nil
+}
+
+type table struct {
+	name    string
+	columns []string
+	rows    *rowSource
+}
+
+// tables lists the data in foreign key order.
+func (d *Dataset) tables() []table {
+	return []table{
+		{"market_groups", []string{"id", "slug", "title", "tags", "volume_24h", "market_count"},
+			rows(len(d.Groups), func(i int) []any {
+				g := d.Groups[i]
+				return []any{g.ID, g.Slug, g.Title, g.Tags, g.Volume24h, g.MarketCount}
+			})},
+		{"events", []string{
+			"id", "polymarket_event_id", "slug", "question", "description", "category",
+			"outcomes", "outcome_prices", "clob_token_ids", "status", "resolved_outcome", "resolved_at",
+			"volume", "volume_24h", "liquidity", "end_date", "created_at", "updated_at", "synced_at",
+		}, rows(len(d.Events), func(i int) []any {
+			e := d.Events[i]
+			return []any{
+				e.ID, e.PolymarketEventID, e.Slug, e.Question, e.Description, e.Category,
+				e.Outcomes, e.OutcomePrices, e.ClobTokenIDs, string(e.Status), e.ResolvedOutcome, e.ResolvedAt,
+				e.Volume, e.Volume24h, e.Liquidity, e.EndDate, e.CreatedAt, e.UpdatedAt, e.SyncedAt,
+			}
+		})},
+		{"price_history", []string{"event_id", "outcome_label", "price", "recorded_at"},
+			rows(len(d.Prices), func(i int) []any {
+				p := d.Prices[i]
+				return []any{p.EventID, p.OutcomeLabel, p.Price, p.RecordedAt}
+			})},
+		{"users", []string{
+			"id", "handle", "display_name", "balance", "frozen_balance", "current_streak", "max_streak",
+			"total_bets", "total_wins", "created_at", "updated_at",
+		}, rows(len(d.Users), func(i int) []any {
+			u := d.Users[i]
+			return []any{
+				u.ID, u.Handle, u.DisplayName, u.Balance, u.FrozenBalance, u.CurrentStreak, u.MaxStreak,
+				u.TotalBets, u.TotalWins, u.CreatedAt, u.UpdatedAt,
+			}
+		})},
+		{"bets", []string{
+			"id", "user_id", "event_id", "outcome", "amount", "locked_odds", "potential_payout",
+			"status", "payout", "settled_at", "created_at",
+		}, rows(len(d.Bets), func(i int) []any {
+			b := d.Bets[i]
+			return []any{
+				b.ID, b.UserID, b.EventID, b.Outcome, b.Amount, b.LockedOdds, b.PotentialPayout,
+				string(b.Status), b.Payout, b.SettledAt, b.CreatedAt,
+			}
+		})},
+		{"settlements", []string{"event_id", "resolved_outcome", "total_bets", "total_payouts", "settled_at"},
+			rows(len(d.Settlements), func(i int) []any {
+				s := d.Settlements[i]
+				return []any{s.EventID, s.ResolvedOutcome, s.TotalBets, s.TotalPayouts, s.SettledAt}
+			})},
+		{"credit_transactions", []string{"user_id", "type", "amount", "balance_after", "reference_id", "description", "created_at"},
+			rows(len(d.Transactions), func(i int) []any {
+				t := d.Transactions[i]
+				return []any{t.UserID, t.Type, t.Amount, t.BalanceAfter, t.ReferenceID, t.Description, t.CreatedAt}
+			})},
+	}
+}
+
+// rowSource adapts an indexed slice to pgx.CopyFromSource.
+type rowSource struct {
+	len int
+	row func(i int) []any
+	i   int
+}
+
+func rows(n int, row func(i int) []any) *rowSource {
+	return &rowSource{len: n, row: row, i: -1}
+}
+
+func (r *rowSource) Next() bool {
+	r.i++
+	return r.i < r.len
+}
+
+func (r *rowSource) Values() ([]any, error) { return r.row(r.i), nil }
+
+func (r *rowSource) Err() error { return nil }
+
+// CreateAdmin creates the admin account with the given email. If it already
+// exists its password is reset when reset is set and left alone otherwise.
+// It reports whether the account was created.
+func CreateAdmin(ctx context.Context, pool *pgxpool.Pool, email, password string, reset bool) (bool, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("hash password: %w", err)
+	}
+
+	onConflict := "DO NOTHING"
+	if reset {
+		onConflict = "DO UPDATE SET password_hash = EXCLUDED.password_hash"
+	}
+
+	var created bool
+	err = pool.QueryRow(ctx, `
+		INSERT INTO admin_users (email, password_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (email) `+onConflict+`
+		RETURNING (xmax = 0)
+	`, email, string(hash)).Scan(&created)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The account exists and was left alone.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("upsert admin user: %w", err)
+	}
+	return created, nil
+}
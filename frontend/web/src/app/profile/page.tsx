@@ -31,7 +31,7 @@ interface Bet {
   outcome: 'yes' | 'no'
   amount: number
   odds: number
-  status: 'pending' | 'won' | 'lost' | 'cancelled'
+  status: 'pending' | 'won' | 'lost' | 'cancelled' | 'refunded'
   potential_payout: number
   created_at: string
 }
@@ -57,6 +57,7 @@ function betStatusColor(status: string) {
     case 'lost':
       return 'bg-red-500/10 text-red-700 dark:text-red-400 border-red-500/20'
     case 'cancelled':
+    case 'refunded':
       return 'bg-gray-500/10 text-gray-700 dark:text-gray-400 border-gray-500/20'
     default:
       return ''